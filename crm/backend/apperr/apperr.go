@@ -0,0 +1,108 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code クライアントに返すエラーコード（GraphQL の extensions.code）
+type Code string
+
+const (
+	CodeNotFound        Code = "NOT_FOUND"
+	CodeValidation      Code = "VALIDATION"
+	CodeUnauthenticated Code = "UNAUTHENTICATED"
+	CodeForbidden       Code = "FORBIDDEN"
+	CodeConflict        Code = "CONFLICT"
+	CodeInternal        Code = "INTERNAL"
+)
+
+// Error コード付きのドメインエラー
+//
+// Message はクライアントにそのまま返してよい文言にする。
+// Firestore などの内部エラーは Err に入れ、本番環境ではクライアントに返さない。
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError // VALIDATION の場合のフィールドごとのエラー
+	Err     error
+}
+
+// FieldError 入力フィールドごとのエラー
+type FieldError struct {
+	Field   string `json:"field"` // "input.email_address" のような引数からのパス
+	Message string `json:"message"`
+}
+
+// Error error の実装
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 元のエラーを返す
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New コードとメッセージからエラーを作成
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap 内部エラーにコードとメッセージを付ける
+func Wrap(code Code, err error, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// NotFound 対象が存在しない
+func NotFound(format string, args ...interface{}) *Error {
+	return New(CodeNotFound, format, args...)
+}
+
+// Validation 入力が不正
+func Validation(format string, args ...interface{}) *Error {
+	return New(CodeValidation, format, args...)
+}
+
+// InvalidFields フィールドごとの入力エラーをまとめた VALIDATION エラー
+func InvalidFields(fields []FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: "input validation failed", Fields: fields}
+}
+
+// Unauthenticated 認証されていない
+func Unauthenticated(format string, args ...interface{}) *Error {
+	return New(CodeUnauthenticated, format, args...)
+}
+
+// Forbidden 権限がない
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(CodeForbidden, format, args...)
+}
+
+// Conflict 既存のデータと競合している
+func Conflict(format string, args ...interface{}) *Error {
+	return New(CodeConflict, format, args...)
+}
+
+// Internal 内部エラー。err の内容は本番環境ではクライアントに返さない
+func Internal(err error, format string, args ...interface{}) *Error {
+	return Wrap(CodeInternal, err, format, args...)
+}
+
+// CodeOf err のコード。Error を含まない場合は CodeInternal
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// Is err が code のエラーかどうか
+func Is(err error, code Code) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
@@ -0,0 +1,71 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{name: "not found", err: NotFound("user not found: %s", "u1"), want: CodeNotFound},
+		{name: "validation", err: Validation("bad input"), want: CodeValidation},
+		{name: "unauthenticated", err: Unauthenticated("sign in"), want: CodeUnauthenticated},
+		{name: "forbidden", err: Forbidden("admin role required"), want: CodeForbidden},
+		{name: "conflict", err: Conflict("modified"), want: CodeConflict},
+		{name: "internal", err: Internal(errors.New("rpc error"), "failed"), want: CodeInternal},
+		{name: "wrapped with fmt.Errorf", err: fmt.Errorf("resolver: %w", Forbidden("no")), want: CodeForbidden},
+		{name: "joined", err: errors.Join(errors.New("other"), NotFound("gone")), want: CodeNotFound},
+		{name: "plain error", err: errors.New("boom"), want: CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		err  error
+		code Code
+		want bool
+	}{
+		{fmt.Errorf("resolver: %w", Conflict("modified")), CodeConflict, true},
+		{Conflict("modified"), CodeNotFound, false},
+		{errors.New("boom"), CodeInternal, false}, // コードのないエラーは Is では一致しない
+	}
+	for _, tt := range tests {
+		if got := Is(tt.err, tt.code); got != tt.want {
+			t.Errorf("Is(%v, %s) = %v, want %v", tt.err, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("rpc error: code = Unavailable")
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{name: "message", err: NotFound("user not found: %s", "u1"), want: "user not found: u1"},
+		{name: "with cause", err: Internal(cause, "failed to get %s", "user"), want: "failed to get user: rpc error: code = Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if err := Wrap(CodeConflict, cause, "x"); !errors.Is(err, cause) {
+		t.Error("errors.Is() does not find the wrapped cause")
+	}
+}
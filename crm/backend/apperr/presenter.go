@@ -0,0 +1,80 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// internalMessage 本番環境で内部エラーの代わりに返すメッセージ
+const internalMessage = "internal server error"
+
+// ErrorPresenter エラーを GraphQL のレスポンスに変換する gqlgen の ErrorPresenter
+//
+// Error のコードを extensions.code に設定する。gqlgen や拡張が既にコードを設定した
+// エラー（GRAPHQL_VALIDATION_FAILED、RATE_LIMITED など）はそのまま返し、
+// それ以外は INTERNAL として扱う。hideInternal が true の場合（本番環境）は
+// 内部エラーの詳細をログにだけ出力し、クライアントには汎用のメッセージを返す。
+func ErrorPresenter(hideInternal bool) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+		var appErr *Error
+		if errors.As(err, &appErr) {
+			gqlErr.Message = appErr.Message
+			if appErr.Code == CodeInternal {
+				logInternal(ctx, gqlErr, appErr)
+				if hideInternal {
+					gqlErr.Message = internalMessage
+				} else {
+					gqlErr.Message = appErr.Error()
+				}
+			}
+			setCode(gqlErr, appErr.Code)
+			if len(appErr.Fields) > 0 {
+				gqlErr.Extensions["fields"] = appErr.Fields
+			}
+			return gqlErr
+		}
+
+		if gqlErr.Extensions["code"] != nil {
+			return gqlErr
+		}
+
+		// コードのないエラー（resolver の fmt.Errorf など）は内部エラー扱い
+		logInternal(ctx, gqlErr, err)
+		if hideInternal {
+			gqlErr.Message = internalMessage
+		}
+		setCode(gqlErr, CodeInternal)
+		return gqlErr
+	}
+}
+
+// RecoverFunc resolver の panic を INTERNAL エラーに変換する gqlgen の RecoverFunc
+func RecoverFunc(ctx context.Context, p interface{}) error {
+	attrs := []any{slog.Any("panic", p), slog.String("stack", string(debug.Stack()))}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		attrs = append(attrs, slog.String("path", fc.Path().String()))
+	}
+	slog.ErrorContext(ctx, "panic in GraphQL resolver", attrs...)
+
+	return Internal(fmt.Errorf("panic: %v", p), internalMessage)
+}
+
+func setCode(gqlErr *gqlerror.Error, code Code) {
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions["code"] = string(code)
+}
+
+func logInternal(ctx context.Context, gqlErr *gqlerror.Error, err error) {
+	slog.ErrorContext(ctx, "GraphQL internal error",
+		slog.String("path", gqlErr.Path.String()), slog.Any("error", err))
+}
@@ -0,0 +1,121 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	coded := gqlerror.Errorf("too many requests")
+	coded.Extensions = map[string]interface{}{"code": "RATE_LIMITED"}
+
+	tests := []struct {
+		name         string
+		err          error
+		hideInternal bool
+		wantMessage  string
+		wantCode     string
+	}{
+		{
+			name:         "domain errors keep their message",
+			err:          NotFound("user not found: u1"),
+			hideInternal: true,
+			wantMessage:  "user not found: u1",
+			wantCode:     "NOT_FOUND",
+		},
+		{
+			name:         "wrapped domain errors",
+			err:          fmt.Errorf("resolver: %w", Forbidden("admin role required")),
+			hideInternal: true,
+			wantMessage:  "admin role required",
+			wantCode:     "FORBIDDEN",
+		},
+		{
+			name:         "internal errors are masked",
+			err:          Internal(errors.New("rpc error: permission denied on projects/p"), "failed to list users"),
+			hideInternal: true,
+			wantMessage:  "internal server error",
+			wantCode:     "INTERNAL",
+		},
+		{
+			name:        "internal errors are shown in development",
+			err:         Internal(errors.New("rpc error"), "failed to list users"),
+			wantMessage: "failed to list users: rpc error",
+			wantCode:    "INTERNAL",
+		},
+		{
+			name:         "errors without a code are masked",
+			err:          errors.New("firestore: document not found"),
+			hideInternal: true,
+			wantMessage:  "internal server error",
+			wantCode:     "INTERNAL",
+		},
+		{
+			name:        "errors without a code are shown in development",
+			err:         errors.New("firestore: document not found"),
+			wantMessage: "firestore: document not found",
+			wantCode:    "INTERNAL",
+		},
+		{
+			name:         "codes set by gqlgen or extensions are kept",
+			err:          coded,
+			hideInternal: true,
+			wantMessage:  "too many requests",
+			wantCode:     "RATE_LIMITED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ErrorPresenter(tt.hideInternal)(context.Background(), tt.err)
+			if got.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got.Message, tt.wantMessage)
+			}
+			if got.Extensions["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %s", got.Extensions["code"], tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRecoverFunc(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := RecoverFunc(context.Background(), "nil map")
+	if CodeOf(err) != CodeInternal {
+		t.Errorf("CodeOf() = %s, want %s", CodeOf(err), CodeInternal)
+	}
+	got := ErrorPresenter(true)(context.Background(), err)
+	if got.Message != "internal server error" {
+		t.Errorf("message = %q, the panic value must not be returned", got.Message)
+	}
+
+	var _ graphql.RecoverFunc = RecoverFunc
+}
+
+func TestErrorPresenterFields(t *testing.T) {
+	fields := []FieldError{{Field: "input.email_address", Message: "must be a valid email address"}}
+
+	got := ErrorPresenter(true)(context.Background(), InvalidFields(fields))
+	if got.Extensions["code"] != "VALIDATION" {
+		t.Errorf("code = %v, want VALIDATION", got.Extensions["code"])
+	}
+	if !reflect.DeepEqual(got.Extensions["fields"], fields) {
+		t.Errorf("fields = %v, want %v", got.Extensions["fields"], fields)
+	}
+
+	if got := ErrorPresenter(true)(context.Background(), Validation("bad input")); got.Extensions["fields"] != nil {
+		t.Errorf("fields = %v, want none", got.Extensions["fields"])
+	}
+}
@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/99designs/gqlgen/graphql"
+	"google.golang.org/api/iterator"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+	"narratives-shared/logging"
+)
+
+// collection 監査ログのコレクション
+const collection = "audit_logs"
+
+// 一覧の取得件数
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Entry 監査ログ（audit_logs ドキュメント）
+//
+// 作成後は変更・削除しない。このパッケージも作成と参照の関数だけを提供する。
+type Entry struct {
+	ID string `firestore:"-"`
+
+	ActorUID   string `firestore:"actor_uid"`
+	ActorEmail string `firestore:"actor_email"`
+	ActorRole  string `firestore:"actor_role"`
+	// TenantID 実行時のスコープの組織（スーパー管理者がすべての組織を対象に実行した場合は対象ドキュメントの組織）
+	TenantID string `firestore:"tenant_id"`
+
+	// Operation 実行したミューテーション（ルートフィールド名）
+	Operation  string                 `firestore:"operation"`
+	EntityType string                 `firestore:"entity_type"`
+	EntityID   string                 `firestore:"entity_id"`
+	Arguments  map[string]interface{} `firestore:"arguments"` // 秘匿情報はマスク済み
+	Changes    []Change               `firestore:"changes"`
+	Error      string                 `firestore:"error,omitempty"`
+
+	RequestID string    `firestore:"request_id"`
+	CreatedAt time.Time `firestore:"created_at"`
+}
+
+// Change 対象ドキュメントのフィールドごとの変更前後の値（作成時の Before・削除時の After は nil）
+type Change struct {
+	Field  string      `firestore:"field"`
+	Before interface{} `firestore:"before"`
+	After  interface{} `firestore:"after"`
+}
+
+// Record 監査ログを保存する
+func Record(ctx context.Context, client *firestore.Client, e *Entry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	ref := client.Collection(collection).NewDoc()
+	if _, err := ref.Create(ctx, e); err != nil {
+		return err
+	}
+	e.ID = ref.ID
+	return nil
+}
+
+// Query 監査ログの絞り込み条件（空の条件は無視する）
+type Query struct {
+	ActorUID   string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// List スコープの組織の監査ログを新しい順に取得する
+//
+// マルチテナント化より前の監査ログは tenant_id フィールドを持たず（作成後に変更しないため移行もしない）、
+// すべての組織を対象にするスーパー管理者の一覧にだけ既定の組織のログとして含まれる。
+func List(ctx context.Context, client *firestore.Client, q Query) ([]*Entry, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		return nil, apperr.Validation("limit must be at most %d", MaxLimit)
+	}
+
+	query := tenant.Query(ctx, client.Collection(collection).Query)
+	if q.ActorUID != "" {
+		query = query.Where("actor_uid", "==", q.ActorUID)
+	}
+	if q.EntityType != "" {
+		query = query.Where("entity_type", "==", q.EntityType)
+	}
+	if q.EntityID != "" {
+		query = query.Where("entity_id", "==", q.EntityID)
+	}
+	if q.From != nil {
+		query = query.Where("created_at", ">=", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("created_at", "<=", *q.To)
+	}
+	query = query.OrderBy("created_at", firestore.Desc).Limit(limit)
+
+	it := query.Documents(ctx)
+	defer it.Stop()
+
+	var entries []*Entry
+	for {
+		doc, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, apperr.Internal(err, "failed to list audit logs")
+		}
+		var e Entry
+		if err := doc.DataTo(&e); err != nil {
+			return nil, apperr.Internal(err, "failed to decode audit log %s", doc.Ref.ID)
+		}
+		e.ID = doc.Ref.ID
+		if _, ok := doc.Data()[tenant.FieldTenantID]; !ok {
+			e.TenantID = tenant.DefaultID
+		}
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+// Diff 変更前後のドキュメントで値が異なるフィールドを列挙する（フィールド名の順）
+func Diff(before, after map[string]interface{}) []Change {
+	fields := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		fields[k] = true
+	}
+	for k := range after {
+		fields[k] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := []Change{}
+	for _, name := range names {
+		b, a := before[name], after[name]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		if logging.IsSensitiveKey(name) {
+			// 値は残さず、変更があったことだけを記録する
+			b, a = redactPresent(b), redactPresent(a)
+		}
+		changes = append(changes, Change{Field: name, Before: b, After: a})
+	}
+	return changes
+}
+
+func redactPresent(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return logging.Redacted
+}
+
+// RedactArguments resolver の引数を保存できる形に変換し、秘匿情報をマスクする
+//
+// 入力型は JSON のキー（スキーマのフィールド名）で保存する。
+// アップロードされたファイルは中身を保存せず、ファイル名・種類・サイズだけを残す。
+func RedactArguments(args map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(args))
+	for name, v := range args {
+		redacted[name] = redactValue(name, plain(v))
+	}
+	return redacted
+}
+
+// plain 引数の値を map / slice / string / float64 / bool だけの値に変換
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case graphql.Upload:
+		return map[string]interface{}{"filename": v.Filename, "content_type": v.ContentType, "size": v.Size}
+	case *graphql.Upload:
+		if v == nil {
+			return nil
+		}
+		return plain(*v)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return reflect.TypeOf(v).String()
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return reflect.TypeOf(v).String()
+	}
+	return out
+}
+
+func redactValue(key string, v interface{}) interface{} {
+	if v != nil && logging.IsSensitiveKey(key) {
+		return logging.Redacted
+	}
+	switch v := v.(type) {
+	case string:
+		return logging.RedactString(v)
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = redactValue(k, child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(key, child)
+		}
+		return v
+	}
+	return v
+}
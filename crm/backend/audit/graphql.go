@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"cloud.google.com/go/firestore"
+	"github.com/99designs/gqlgen/graphql"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/tenant"
+	"narratives-shared/logging"
+)
+
+// Entity ミューテーションが変更するドキュメント
+type Entity struct {
+	// Type 監査ログの entity_type（例: "user"）
+	Type string
+	// Collection 変更前後のドキュメントを取得するコレクション（空の場合は差分を記録しない）
+	Collection string
+	// IDArg 対象のドキュメントIDを受け取る引数名
+	IDArg string
+	// ResultID 作成時など引数にIDがない場合に、resolver の結果からIDを取り出す
+	ResultID func(interface{}) string
+}
+
+// GraphQLExtension Mutation のルートフィールドごとに監査ログを記録する gqlgen 拡張
+//
+// Entities に登録したミューテーションは、resolver の前後で対象のドキュメントを取得して差分を残す。
+// 登録していないミューテーションも実行者・引数・結果（エラー）は記録する。
+// 引数の検証で拒否されたものを残さないよう、validate.GraphQLExtension より後に登録すること。
+type GraphQLExtension struct {
+	Client   *firestore.Client
+	Entities map[string]Entity
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "AuditLog"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (GraphQLExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField graphql.FieldInterceptor の実装
+func (e GraphQLExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if e.Client == nil || fc == nil || !fc.IsResolver || fc.Object != "Mutation" {
+		return next(ctx)
+	}
+
+	entity := e.Entities[fc.Field.Name]
+	id := argID(fc.Args[entity.IDArg])
+
+	var before map[string]interface{}
+	if entity.Collection != "" && id != "" {
+		before = e.snapshot(ctx, entity.Collection, id)
+	}
+
+	res, err := next(ctx)
+
+	entry := &Entry{
+		Operation:  fc.Field.Name,
+		EntityType: entity.Type,
+		Arguments:  RedactArguments(fc.Args),
+		Changes:    []Change{},
+		RequestID:  logging.RequestIDFromContext(ctx),
+	}
+	if caller := authn.CallerFromContext(ctx); caller != nil {
+		entry.ActorUID = caller.UID
+		entry.ActorEmail = caller.Email
+		entry.ActorRole = caller.Role
+	}
+	if scope, ok := tenant.ScopeFromContext(ctx); ok {
+		entry.TenantID = scope.TenantID
+	}
+
+	var after map[string]interface{}
+	if err != nil {
+		entry.Error = logging.RedactString(err.Error())
+	} else {
+		if id == "" && entity.ResultID != nil {
+			id = entity.ResultID(res)
+		}
+		if entity.Collection != "" && id != "" {
+			after = e.snapshot(ctx, entity.Collection, id)
+			entry.Changes = Diff(before, after)
+		}
+	}
+	entry.EntityID = id
+	if entry.TenantID == "" {
+		entry.TenantID = entityTenant(before, after)
+	}
+
+	// 記録に失敗してもミューテーションの結果は変えない
+	if rerr := Record(context.WithoutCancel(ctx), e.Client, entry); rerr != nil {
+		slog.ErrorContext(ctx, "failed to record audit log",
+			slog.String("operation", entry.Operation), slog.String("entity_id", id), slog.Any("error", rerr))
+	}
+	return res, err
+}
+
+// snapshot ドキュメントの内容（存在しない場合とスコープ外の組織のドキュメントは nil）
+func (e GraphQLExtension) snapshot(ctx context.Context, collection, id string) map[string]interface{} {
+	doc, err := e.Client.Collection(collection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) != codes.NotFound {
+			slog.WarnContext(ctx, "failed to get document for audit log",
+				slog.String("collection", collection), slog.String("id", id), slog.Any("error", err))
+		}
+		return nil
+	}
+	if !tenant.Allows(ctx, doc.Data()) {
+		return nil
+	}
+	return doc.Data()
+}
+
+// entityTenant 変更後（削除された場合は変更前）のドキュメントの組織（どちらもない場合は空）
+func entityTenant(before, after map[string]interface{}) string {
+	if after != nil {
+		return tenant.Of(after)
+	}
+	if before != nil {
+		return tenant.Of(before)
+	}
+	return ""
+}
+
+// argID ID 引数の値（ID! と ID の両方）
+func argID(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case *string:
+		if v != nil {
+			return *v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package authn
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+// Caller リクエストを送信した認証済みユーザー
+type Caller struct {
+	UID   string
+	Email string
+	Role  string
+	// TenantID 所属する組織（カスタムクレーム tenant_id、tenant.ScopeFor でスコープに変換する）
+	TenantID string
+}
+
+type callerContextKey struct{}
+
+// WithCaller コンテキストに呼び出し元を設定
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, caller)
+}
+
+// CallerFromContext コンテキストから呼び出し元を取得（未認証の場合は nil）
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerContextKey{}).(*Caller)
+	return caller
+}
+
+// Middleware Authorization: Bearer <Firebase ID トークン> を検証し、呼び出し元をコンテキストに設定する
+//
+// トークンがない、または検証できないリクエストも未認証として後続に渡す。
+// 認可が必要な処理は CallerFromContext の結果で判断すること。
+func Middleware(client *auth.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" || client == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			caller, err := verify(r.Context(), client, token)
+			if err != nil {
+				slog.WarnContext(r.Context(), "failed to verify ID token", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithCaller(r.Context(), caller)))
+		})
+	}
+}
+
+// verify Firebase ID トークンを検証して呼び出し元を返す
+func verify(ctx context.Context, client *auth.Client, token string) (*Caller, error) {
+	idToken, err := client.VerifyIDToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	caller := &Caller{UID: idToken.UID}
+	if email, ok := idToken.Claims["email"].(string); ok {
+		caller.Email = email
+	}
+	if role, ok := idToken.Claims["role"].(string); ok {
+		caller.Role = role
+	}
+	if tenantID, ok := idToken.Claims["tenant_id"].(string); ok {
+		caller.TenantID = tenantID
+	}
+	return caller, nil
+}
+
+// bearerToken Authorization ヘッダーからトークンを取り出す
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package authn
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// WebsocketInitFunc WebSocket の connection_init で送られた ID トークンを検証する
+//
+// ブラウザの WebSocket は Authorization ヘッダーを送れないため、クライアントは
+// connection_init のペイロードに {"Authorization": "Bearer <ID トークン>"} を含める。
+// HTTP の Middleware と同様に、トークンがない・検証できない接続も未認証として受け付ける。
+func WebsocketInitFunc(client *auth.Client) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		if CallerFromContext(ctx) != nil || client == nil {
+			return ctx, nil, nil
+		}
+
+		token := payload.Authorization()
+		if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+		if token == "" {
+			return ctx, nil, nil
+		}
+
+		caller, err := verify(ctx, client, token)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to verify ID token for websocket", slog.Any("error", err))
+			return ctx, nil, nil
+		}
+		return WithCaller(ctx, caller), nil, nil
+	}
+}
@@ -0,0 +1,226 @@
+package customfield
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Type カスタム項目の型
+type Type string
+
+const (
+	TypeText        Type = "text"         // 文字列
+	TypeNumber      Type = "number"       // 数値
+	TypeDate        Type = "date"         // 日付（UTC の 0 時に切り捨てて保存）
+	TypeEnum        Type = "enum"         // Options から1つ
+	TypeMultiSelect Type = "multi_select" // Options から複数
+)
+
+// Types 定義できる型
+var Types = []Type{TypeText, TypeNumber, TypeDate, TypeEnum, TypeMultiSelect}
+
+const (
+	// FieldCustomFields 値を保存する users のフィールド（key ごとのマップ）
+	FieldCustomFields = "custom_fields"
+	// maxTextLength TEXT の値の最大文字数
+	maxTextLength = 1000
+)
+
+// keyPattern Field.Key に使える文字列（Firestore のフィールドパスにそのまま使えるもの）
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// Field 組織ごとに管理者が定義する顧客の項目（custom_fields ドキュメント）
+type Field struct {
+	ID string `firestore:"-"`
+	// TenantID 項目を定義した組織（ドキュメントIDは組織IDと Key から決まる）
+	TenantID string `firestore:"tenant_id"`
+	// Key users.custom_fields のキー（組織内で一意、作成後は変更できない）
+	Key   string `firestore:"key"`
+	Label string `firestore:"label"`
+	Type  Type   `firestore:"type"`
+	// Options TypeEnum / TypeMultiSelect の選択肢
+	Options []string `firestore:"options"`
+	// Position 画面に表示する順番（小さいものが先）
+	Position int `firestore:"position"`
+
+	CreatedAt time.Time `firestore:"created_at"`
+	UpdatedAt time.Time `firestore:"updated_at"`
+}
+
+// Validate 項目の定義が正しいか
+func (f *Field) Validate() error {
+	if !keyPattern.MatchString(f.Key) {
+		return fmt.Errorf("invalid custom field key %q (lowercase letters, digits and _, starting with a letter)", f.Key)
+	}
+	if f.Label == "" {
+		return fmt.Errorf("custom field label is required")
+	}
+	if !slices.Contains(Types, f.Type) {
+		return fmt.Errorf("unknown custom field type: %q", f.Type)
+	}
+
+	if !f.hasOptions() {
+		if len(f.Options) > 0 {
+			return fmt.Errorf("custom field %s of type %s cannot have options", f.Key, f.Type)
+		}
+		return nil
+	}
+	if len(f.Options) == 0 {
+		return fmt.Errorf("custom field %s of type %s requires options", f.Key, f.Type)
+	}
+	for i, o := range f.Options {
+		if o == "" {
+			return fmt.Errorf("custom field %s has an empty option", f.Key)
+		}
+		if slices.Contains(f.Options[:i], o) {
+			return fmt.Errorf("custom field %s has a duplicate option %q", f.Key, o)
+		}
+	}
+	return nil
+}
+
+// hasOptions 選択肢から値を選ぶ型か
+func (f *Field) hasOptions() bool {
+	return f.Type == TypeEnum || f.Type == TypeMultiSelect
+}
+
+// Value 顧客のカスタム項目の値
+//
+// 型に応じて1つだけ指定する（TEXT / ENUM は Text、NUMBER は Number、DATE は Date、MULTI_SELECT は Options）。
+// すべて空の場合は値がないことを表す。
+type Value struct {
+	Key     string
+	Text    *string
+	Number  *float64
+	Date    *time.Time
+	Options []string
+}
+
+// IsEmpty 値が指定されていないか
+func (v Value) IsEmpty() bool {
+	return v.Text == nil && v.Number == nil && v.Date == nil && v.Options == nil
+}
+
+// Encode 値を項目の型にあわせて検証し、Firestore に保存する値に変換する（値が空の場合は nil）
+func (f *Field) Encode(v Value) (interface{}, error) {
+	if v.IsEmpty() {
+		return nil, nil
+	}
+
+	var set int
+	for _, ok := range []bool{v.Text != nil, v.Number != nil, v.Date != nil, v.Options != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("custom field %s: only one of text, number, date and options can be set", f.Key)
+	}
+
+	switch f.Type {
+	case TypeText:
+		if v.Text != nil {
+			if utf8.RuneCountInString(*v.Text) > maxTextLength {
+				return nil, fmt.Errorf("custom field %s must be at most %d characters", f.Key, maxTextLength)
+			}
+			return *v.Text, nil
+		}
+	case TypeNumber:
+		if v.Number != nil {
+			return *v.Number, nil
+		}
+	case TypeDate:
+		if v.Date != nil {
+			return truncateDate(*v.Date), nil
+		}
+	case TypeEnum:
+		if v.Text != nil {
+			if !slices.Contains(f.Options, *v.Text) {
+				return nil, fmt.Errorf("custom field %s: %q is not one of the options", f.Key, *v.Text)
+			}
+			return *v.Text, nil
+		}
+	case TypeMultiSelect:
+		if v.Options != nil {
+			selected := make([]string, 0, len(v.Options))
+			for _, o := range v.Options {
+				if !slices.Contains(f.Options, o) {
+					return nil, fmt.Errorf("custom field %s: %q is not one of the options", f.Key, o)
+				}
+				if !slices.Contains(selected, o) {
+					selected = append(selected, o)
+				}
+			}
+			return selected, nil
+		}
+	}
+	return nil, fmt.Errorf("custom field %s requires a %s value", f.Key, f.valueName())
+}
+
+// valueName 型に対応する Value のフィールド名（エラーメッセージ用）
+func (f *Field) valueName() string {
+	switch f.Type {
+	case TypeNumber:
+		return "number"
+	case TypeDate:
+		return "date"
+	case TypeMultiSelect:
+		return "options"
+	}
+	return "text"
+}
+
+// Values users.custom_fields を Value に変換（キーの順）
+//
+// 保存時に型を検証しているため、値の Go の型から Value のフィールドを決める。
+func Values(data map[string]interface{}) []Value {
+	fields, _ := data[FieldCustomFields].(map[string]interface{})
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	values := make([]Value, 0, len(keys))
+	for _, k := range keys {
+		if v, ok := decode(k, fields[k]); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// decode Firestore から読み取った値を Value に変換
+func decode(key string, raw interface{}) (Value, bool) {
+	v := Value{Key: key}
+	switch x := raw.(type) {
+	case string:
+		v.Text = &x
+	case int64:
+		n := float64(x)
+		v.Number = &n
+	case float64:
+		v.Number = &x
+	case time.Time:
+		v.Date = &x
+	case []interface{}:
+		v.Options = make([]string, 0, len(x))
+		for _, o := range x {
+			if s, ok := o.(string); ok {
+				v.Options = append(v.Options, s)
+			}
+		}
+	default:
+		return v, false
+	}
+	return v, true
+}
+
+// truncateDate 日付の比較が時刻に左右されないよう UTC の 0 時に切り捨てる
+func truncateDate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,156 @@
+package customfield
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+var (
+	plan  = &Field{Key: "plan", Label: "プラン", Type: TypeEnum, Options: []string{"free", "pro"}}
+	score = &Field{Key: "score", Label: "スコア", Type: TypeNumber}
+	memo  = &Field{Key: "memo", Label: "メモ", Type: TypeText}
+	since = &Field{Key: "since", Label: "契約日", Type: TypeDate}
+	likes = &Field{Key: "likes", Label: "興味", Type: TypeMultiSelect, Options: []string{"a", "b", "c"}}
+
+	testFields = []*Field{plan, score, memo, since, likes}
+)
+
+func TestFieldValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   Field
+		wantErr bool
+	}{
+		{name: "text", field: Field{Key: "memo", Label: "メモ", Type: TypeText}},
+		{name: "enum", field: Field{Key: "plan_2", Label: "プラン", Type: TypeEnum, Options: []string{"free", "pro"}}},
+		{name: "key with uppercase letters", field: Field{Key: "Plan", Label: "x", Type: TypeText}, wantErr: true},
+		{name: "key starting with a digit", field: Field{Key: "1st", Label: "x", Type: TypeText}, wantErr: true},
+		{name: "key with a dot", field: Field{Key: "a.b", Label: "x", Type: TypeText}, wantErr: true},
+		{name: "key too long", field: Field{Key: "a" + strings.Repeat("b", 40), Label: "x", Type: TypeText}, wantErr: true},
+		{name: "no label", field: Field{Key: "memo", Type: TypeText}, wantErr: true},
+		{name: "unknown type", field: Field{Key: "memo", Label: "x", Type: "bool"}, wantErr: true},
+		{name: "options on a text field", field: Field{Key: "memo", Label: "x", Type: TypeText, Options: []string{"a"}}, wantErr: true},
+		{name: "enum without options", field: Field{Key: "plan", Label: "x", Type: TypeEnum}, wantErr: true},
+		{name: "empty option", field: Field{Key: "plan", Label: "x", Type: TypeMultiSelect, Options: []string{"a", ""}}, wantErr: true},
+		{name: "duplicate option", field: Field{Key: "plan", Label: "x", Type: TypeEnum, Options: []string{"a", "a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.field.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFieldEncode(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name    string
+		field   *Field
+		value   Value
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "empty value", field: memo, value: Value{}, want: nil},
+		{name: "text", field: memo, value: Value{Text: ptr("hello")}, want: "hello"},
+		{name: "text too long", field: memo, value: Value{Text: ptr(strings.Repeat("あ", 1001))}, wantErr: true},
+		{name: "number", field: score, value: Value{Number: ptr(1.5)}, want: 1.5},
+		{
+			name:  "date is truncated to midnight UTC",
+			field: since,
+			value: Value{Date: ptr(time.Date(2025, 3, 2, 8, 30, 0, 0, jst))},
+			want:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "enum", field: plan, value: Value{Text: ptr("pro")}, want: "pro"},
+		{name: "enum outside the options", field: plan, value: Value{Text: ptr("enterprise")}, wantErr: true},
+		{name: "multi select removes duplicates", field: likes, value: Value{Options: []string{"b", "a", "b"}}, want: []string{"b", "a"}},
+		{name: "empty multi select", field: likes, value: Value{Options: []string{}}, want: []string{}},
+		{name: "multi select outside the options", field: likes, value: Value{Options: []string{"z"}}, wantErr: true},
+		{name: "wrong value type", field: score, value: Value{Text: ptr("1")}, wantErr: true},
+		{name: "more than one value", field: memo, value: Value{Text: ptr("a"), Number: ptr(1.0)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.field.Encode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got, err := Encode(testFields, []Value{{Key: "plan", Text: ptr("free")}, {Key: "score"}})
+	if err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	want := map[string]interface{}{"plan": "free", "score": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		values []Value
+	}{
+		{name: "unknown field", values: []Value{{Key: "color", Text: ptr("red")}}},
+		{name: "set twice", values: []Value{{Key: "memo", Text: ptr("a")}, {Key: "memo", Text: ptr("b")}}},
+		{name: "invalid value", values: []Value{{Key: "plan", Text: ptr("enterprise")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(testFields, tt.values); err == nil {
+				t.Error("Encode() want error")
+			}
+		})
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	got := Updates(map[string]interface{}{"score": 2.0, "memo": nil})
+	want := []firestore.Update{
+		{Path: "custom_fields.memo", Value: firestore.Delete},
+		{Path: "custom_fields.score", Value: 2.0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Updates() = %v, want %v", got, want)
+	}
+}
+
+func TestValues(t *testing.T) {
+	date := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := map[string]interface{}{
+		FieldCustomFields: map[string]interface{}{
+			"score": int64(3),
+			"plan":  "pro",
+			"since": date,
+			"likes": []interface{}{"a", "c"},
+			"ratio": 0.5,
+			"flag":  true, // 対応していない型は読み飛ばす
+		},
+	}
+	want := []Value{
+		{Key: "likes", Options: []string{"a", "c"}},
+		{Key: "plan", Text: ptr("pro")},
+		{Key: "ratio", Number: ptr(0.5)},
+		{Key: "score", Number: ptr(3.0)},
+		{Key: "since", Date: &date},
+	}
+	if got := Values(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %+v, want %+v", got, want)
+	}
+	if got := Values(map[string]interface{}{}); len(got) != 0 {
+		t.Errorf("Values() without custom fields = %+v", got)
+	}
+}
@@ -0,0 +1,319 @@
+package customfield
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// Operator 絞り込みの演算子
+type Operator string
+
+const (
+	OpEQ    Operator = "eq"
+	OpLT    Operator = "lt"
+	OpLTE   Operator = "lte"
+	OpGT    Operator = "gt"
+	OpGTE   Operator = "gte"
+	OpAny   Operator = "any"   // ENUM は Options のいずれか、MULTI_SELECT は Options のいずれかを含む
+	OpAll   Operator = "all"   // MULTI_SELECT が Options をすべて含む
+	OpSet   Operator = "set"   // 値がある
+	OpUnset Operator = "unset" // 値がない
+)
+
+// operators 型ごとに使える演算子
+var operators = map[Type][]Operator{
+	TypeText:        {OpEQ, OpSet, OpUnset},
+	TypeNumber:      {OpEQ, OpLT, OpLTE, OpGT, OpGTE, OpSet, OpUnset},
+	TypeDate:        {OpEQ, OpLT, OpLTE, OpGT, OpGTE, OpSet, OpUnset},
+	TypeEnum:        {OpEQ, OpAny, OpSet, OpUnset},
+	TypeMultiSelect: {OpAny, OpAll, OpSet, OpUnset},
+}
+
+// Filter カスタム項目の条件（Value.Key の項目を Value と比較する）
+type Filter struct {
+	Op    Operator
+	Value Value
+}
+
+// Sort カスタム項目での並べ替え（値がない顧客は順序によらず最後）
+type Sort struct {
+	Key  string
+	Desc bool
+}
+
+// Query 顧客一覧のカスタム項目・タグの条件
+type Query struct {
+	Filters []Filter
+	// Tags すべてのタグが付いた顧客だけにする
+	Tags []string
+	Sort *Sort
+}
+
+// IsEmpty 条件がないか
+func (q Query) IsEmpty() bool {
+	return len(q.Filters) == 0 && len(q.Tags) == 0 && q.Sort == nil
+}
+
+// Narrow 条件のうち Firestore のクエリで絞り込めるものを query に加える
+//
+// Apply で読み取る件数を減らすためのもので、絞り込んだあとも Apply ですべての条件を確かめる。
+// array-contains は1つのクエリに1つしか使えないため、arrayContains（すでに使っている場合は true）が
+// false のときだけ最初のタグを加える。equality が false のとき（範囲の条件と組み合わせると
+// 複合インデックスが必要になるとき）は項目の値の一致を加えない。
+func (q Query) Narrow(fields []*Field, query firestore.Query, arrayContains, equality bool) firestore.Query {
+	if !arrayContains {
+		if tags, err := NormalizeTags(q.Tags); err == nil && len(tags) > 0 {
+			query = query.Where(FieldTags, "array-contains", tags[0])
+		}
+	}
+	if !equality {
+		return query
+	}
+
+	byKey := make(map[string]*Field, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	for _, filter := range q.Filters {
+		if filter.Op != OpEQ {
+			continue
+		}
+		m, err := compile(byKey[filter.Value.Key], filter)
+		if err != nil {
+			continue
+		}
+		query = query.Where(valuePath(m.key), "==", m.operand)
+	}
+	return query
+}
+
+// matcher 検証済みの条件
+type matcher struct {
+	key     string
+	op      Operator
+	operand interface{}
+	options []string
+}
+
+// Apply 条件に一致する users ドキュメントを並べ替えて返す。fields はスコープの組織の項目の定義
+//
+// 管理者が定義する項目の組み合わせごとに Firestore の複合インデックスは用意できないため、
+// 読み取ったあとで絞り込み・並べ替えをする。
+func (q Query) Apply(fields []*Field, docs []*firestore.DocumentSnapshot) ([]*firestore.DocumentSnapshot, error) {
+	byKey := make(map[string]*Field, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+
+	matchers := make([]matcher, len(q.Filters))
+	for i, filter := range q.Filters {
+		m, err := compile(byKey[filter.Value.Key], filter)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i] = m
+	}
+	tags, err := NormalizeTags(q.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*firestore.DocumentSnapshot, 0, len(docs))
+	for _, doc := range docs {
+		data := doc.Data()
+		if !HasTags(data, tags) {
+			continue
+		}
+		values, _ := data[FieldCustomFields].(map[string]interface{})
+		ok := true
+		for _, m := range matchers {
+			ok = ok && m.match(values[m.key])
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+
+	if q.Sort == nil {
+		return matched, nil
+	}
+	f := byKey[q.Sort.Key]
+	if f == nil {
+		return nil, fmt.Errorf("unknown custom field: %q", q.Sort.Key)
+	}
+	if f.Type == TypeMultiSelect {
+		return nil, fmt.Errorf("custom field %s of type %s cannot be sorted", f.Key, f.Type)
+	}
+	slices.SortStableFunc(matched, func(a, b *firestore.DocumentSnapshot) int {
+		va, oka := fieldValue(a, f.Key)
+		vb, okb := fieldValue(b, f.Key)
+		switch {
+		case !oka || !okb:
+			// 値がない顧客は最後
+			return cmp.Compare(boolRank(oka), boolRank(okb))
+		case q.Sort.Desc:
+			return compare(vb, va)
+		default:
+			return compare(va, vb)
+		}
+	})
+	return matched, nil
+}
+
+// compile 条件を項目の定義で検証する
+func compile(f *Field, filter Filter) (matcher, error) {
+	if f == nil {
+		return matcher{}, fmt.Errorf("unknown custom field: %q", filter.Value.Key)
+	}
+	if !slices.Contains(operators[f.Type], filter.Op) {
+		return matcher{}, fmt.Errorf("operator %s cannot be used with custom field %s of type %s", filter.Op, f.Key, f.Type)
+	}
+
+	m := matcher{key: f.Key, op: filter.Op}
+	switch filter.Op {
+	case OpSet, OpUnset:
+		if !filter.Value.IsEmpty() {
+			return matcher{}, fmt.Errorf("operator %s does not take a value", filter.Op)
+		}
+	case OpAny, OpAll:
+		if len(filter.Value.Options) == 0 {
+			return matcher{}, fmt.Errorf("operator %s requires options", filter.Op)
+		}
+		for _, o := range filter.Value.Options {
+			if !slices.Contains(f.Options, o) {
+				return matcher{}, fmt.Errorf("custom field %s: %q is not one of the options", f.Key, o)
+			}
+		}
+		m.options = filter.Value.Options
+	default:
+		operand, err := f.Encode(filter.Value)
+		if err != nil {
+			return matcher{}, err
+		}
+		if operand == nil {
+			return matcher{}, fmt.Errorf("operator %s requires a %s value", filter.Op, f.valueName())
+		}
+		m.operand = operand
+	}
+	return m, nil
+}
+
+// match 保存されている値（ない場合は nil）が条件を満たすか
+func (m matcher) match(raw interface{}) bool {
+	switch m.op {
+	case OpSet:
+		return raw != nil
+	case OpUnset:
+		return raw == nil
+	case OpAny:
+		if s, ok := raw.(string); ok {
+			return slices.Contains(m.options, s)
+		}
+		selected := stringsOf(raw)
+		return slices.ContainsFunc(m.options, func(o string) bool { return slices.Contains(selected, o) })
+	case OpAll:
+		selected := stringsOf(raw)
+		return !slices.ContainsFunc(m.options, func(o string) bool { return !slices.Contains(selected, o) })
+	}
+
+	if raw == nil || !sameKind(raw, m.operand) {
+		return false
+	}
+	c := compare(raw, m.operand)
+	switch m.op {
+	case OpEQ:
+		return c == 0
+	case OpLT:
+		return c < 0
+	case OpLTE:
+		return c <= 0
+	case OpGT:
+		return c > 0
+	case OpGTE:
+		return c >= 0
+	}
+	return false
+}
+
+// fieldValue ドキュメントのカスタム項目の値
+func fieldValue(doc *firestore.DocumentSnapshot, key string) (interface{}, bool) {
+	values, _ := doc.Data()[FieldCustomFields].(map[string]interface{})
+	v, ok := values[key]
+	return v, ok && v != nil
+}
+
+// sameKind 2つの値を比較できるか（型の変更前に保存された値などは一致しないものとして扱う）
+func sameKind(a, b interface{}) bool {
+	return kind(a) == kind(b) && kind(a) != kindOther
+}
+
+// compare 同じ種類の値（数値・日時・文字列）を比較する。種類が違う場合は種類の順
+func compare(a, b interface{}) int {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	}
+	return cmp.Compare(kind(a), kind(b))
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// kindOther 数値・日時・文字列以外の値の種類
+const kindOther = 3
+
+// kind 種類が違う値を並べる順番
+func kind(v interface{}) int {
+	switch v.(type) {
+	case int64, float64:
+		return 0
+	case time.Time:
+		return 1
+	case string:
+		return 2
+	}
+	return kindOther
+}
+
+// boolRank 値がある顧客を先にするための順位
+func boolRank(ok bool) int {
+	if ok {
+		return 0
+	}
+	return 1
+}
+
+// stringsOf 配列の値を文字列のスライスに変換
+func stringsOf(raw interface{}) []string {
+	items, _ := raw.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package customfield
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr bool
+	}{
+		{name: "number range", filter: Filter{Op: OpGTE, Value: Value{Key: "score", Number: ptr(10.0)}}},
+		{name: "enum any", filter: Filter{Op: OpAny, Value: Value{Key: "plan", Options: []string{"free", "pro"}}}},
+		{name: "set", filter: Filter{Op: OpSet, Value: Value{Key: "memo"}}},
+		{name: "unknown field", filter: Filter{Op: OpEQ, Value: Value{Key: "color", Text: ptr("red")}}, wantErr: true},
+		{name: "range on text", filter: Filter{Op: OpLT, Value: Value{Key: "memo", Text: ptr("a")}}, wantErr: true},
+		{name: "eq on multi select", filter: Filter{Op: OpEQ, Value: Value{Key: "likes", Options: []string{"a"}}}, wantErr: true},
+		{name: "set with a value", filter: Filter{Op: OpSet, Value: Value{Key: "memo", Text: ptr("a")}}, wantErr: true},
+		{name: "any without options", filter: Filter{Op: OpAny, Value: Value{Key: "plan"}}, wantErr: true},
+		{name: "any outside the options", filter: Filter{Op: OpAny, Value: Value{Key: "plan", Options: []string{"x"}}}, wantErr: true},
+		{name: "eq without a value", filter: Filter{Op: OpEQ, Value: Value{Key: "score"}}, wantErr: true},
+		{name: "eq with the wrong type", filter: Filter{Op: OpEQ, Value: Value{Key: "score", Text: ptr("1")}}, wantErr: true},
+	}
+	byKey := make(map[string]*Field)
+	for _, f := range testFields {
+		byKey[f.Key] = f
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compile(byKey[tt.filter.Value.Key], tt.filter); (err != nil) != tt.wantErr {
+				t.Errorf("compile() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		field  *Field
+		filter Filter
+		raw    interface{}
+		want   bool
+	}{
+		{name: "number eq float", field: score, filter: Filter{OpEQ, Value{Number: ptr(3.0)}}, raw: 3.0, want: true},
+		{name: "number eq int", field: score, filter: Filter{OpEQ, Value{Number: ptr(3.0)}}, raw: int64(3), want: true},
+		{name: "number lt", field: score, filter: Filter{OpLT, Value{Number: ptr(3.0)}}, raw: int64(2), want: true},
+		{name: "number gt", field: score, filter: Filter{OpGT, Value{Number: ptr(3.0)}}, raw: 3.0, want: false},
+		{name: "number gte", field: score, filter: Filter{OpGTE, Value{Number: ptr(3.0)}}, raw: 3.0, want: true},
+		{name: "missing number", field: score, filter: Filter{OpLT, Value{Number: ptr(3.0)}}, raw: nil, want: false},
+		{name: "value of another type", field: score, filter: Filter{OpLT, Value{Number: ptr(3.0)}}, raw: "2", want: false},
+		{name: "date lte", field: since, filter: Filter{OpLTE, Value{Date: ptr(day(2))}}, raw: day(1), want: true},
+		{name: "date gt", field: since, filter: Filter{OpGT, Value{Date: ptr(day(2))}}, raw: day(1), want: false},
+		{name: "text eq", field: memo, filter: Filter{OpEQ, Value{Text: ptr("vip")}}, raw: "vip", want: true},
+		{name: "enum any", field: plan, filter: Filter{OpAny, Value{Options: []string{"free", "pro"}}}, raw: "pro", want: true},
+		{name: "enum any miss", field: plan, filter: Filter{OpAny, Value{Options: []string{"free"}}}, raw: "pro", want: false},
+		{name: "multi select any", field: likes, filter: Filter{OpAny, Value{Options: []string{"b", "c"}}}, raw: []interface{}{"a", "c"}, want: true},
+		{name: "multi select all", field: likes, filter: Filter{OpAll, Value{Options: []string{"a", "c"}}}, raw: []interface{}{"c", "b", "a"}, want: true},
+		{name: "multi select all miss", field: likes, filter: Filter{OpAll, Value{Options: []string{"a", "b"}}}, raw: []interface{}{"a"}, want: false},
+		{name: "set", field: memo, filter: Filter{Op: OpSet}, raw: "", want: true},
+		{name: "unset", field: memo, filter: Filter{Op: OpUnset}, raw: nil, want: true},
+		{name: "unset with a value", field: memo, filter: Filter{Op: OpUnset}, raw: "x", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			filter.Value.Key = tt.field.Key
+			m, err := compile(tt.field, filter)
+			if err != nil {
+				t.Fatalf("compile() = %v", err)
+			}
+			if got := m.match(tt.raw); got != tt.want {
+				t.Errorf("match(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		a, b interface{}
+		want int
+	}{
+		{int64(1), 2.0, -1},
+		{2.0, int64(2), 0},
+		{now, now.Add(-time.Hour), 1},
+		{"a", "b", -1},
+		{"a", int64(1), 1}, // 種類が違う場合は数値・日時・文字列の順
+		{now, "a", -1},
+	}
+	for _, tt := range tests {
+		if got := compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("compare(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestQueryIsEmpty(t *testing.T) {
+	tests := []struct {
+		query Query
+		want  bool
+	}{
+		{Query{}, true},
+		{Query{Tags: []string{"vip"}}, false},
+		{Query{Filters: []Filter{{Op: OpSet}}}, false},
+		{Query{Sort: &Sort{Key: "score"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.query.IsEmpty(); got != tt.want {
+			t.Errorf("%+v IsEmpty() = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package customfield
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+// Collection カスタム項目の定義のコレクション（組織で共通）
+const Collection = "custom_fields"
+
+// List スコープの組織の項目を Position の順に取得
+func List(ctx context.Context, client *firestore.Client) ([]*Field, error) {
+	docs, err := tenant.Query(ctx, client.Collection(Collection).Query).OrderBy("position", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to list custom fields")
+	}
+
+	fields := make([]*Field, 0, len(docs))
+	for _, doc := range docs {
+		f, err := fieldFromSnapshot(doc)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// Get 項目を取得（スコープ外の組織の項目は NOT_FOUND）
+func Get(ctx context.Context, client *firestore.Client, id string) (*Field, error) {
+	doc, err := client.Collection(Collection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("custom field not found: %s", id)
+		}
+		return nil, apperr.Internal(err, "failed to get custom field")
+	}
+	f, err := fieldFromSnapshot(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.Contains(ctx, f.TenantID) {
+		return nil, apperr.NotFound("custom field not found: %s", id)
+	}
+	return f, nil
+}
+
+// Save コンテキストの組織に項目を作成する（Key が同じ項目がある場合は更新）
+//
+// 保存済みの値と合わなくなるため、型は変更できない。選択肢から外した値は顧客に残り、
+// 次に顧客を更新するときに選び直す必要がある。
+func Save(ctx context.Context, client *firestore.Client, f *Field) (*Field, error) {
+	if err := f.Validate(); err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.ID = fieldID(tenantID, f.Key)
+	f.TenantID = tenantID
+	ref := client.Collection(Collection).Doc(f.ID)
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		f.CreatedAt = now
+		f.UpdatedAt = now
+
+		doc, err := tx.Get(ref)
+		switch {
+		case grpcstatus.Code(err) == codes.NotFound:
+			return tx.Create(ref, f)
+		case err != nil:
+			return apperr.Internal(err, "failed to get custom field")
+		}
+
+		current, err := fieldFromSnapshot(doc)
+		if err != nil {
+			return err
+		}
+		if current.Type != f.Type {
+			return apperr.Validation("type of custom field %s cannot be changed from %s", f.Key, current.Type)
+		}
+		f.CreatedAt = current.CreatedAt
+		return tx.Set(ref, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete 項目を削除し、その組織の顧客から項目の値を削除する
+func Delete(ctx context.Context, client *firestore.Client, id string) error {
+	f, err := Get(ctx, client, id)
+	if err != nil {
+		return err
+	}
+
+	// 値を先に削除する（途中で失敗しても定義が残り、削除をやり直せる）
+	docs, err := client.Collection(softdelete.Users.Collection).Where(tenant.FieldTenantID, "==", f.TenantID).Documents(ctx).GetAll()
+	if err != nil {
+		return apperr.Internal(err, "failed to list users")
+	}
+	bw := client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for _, doc := range docs {
+		values, _ := doc.Data()[FieldCustomFields].(map[string]interface{})
+		if _, ok := values[f.Key]; !ok {
+			continue
+		}
+		job, err := bw.Update(doc.Ref, []firestore.Update{{Path: valuePath(f.Key), Value: firestore.Delete}})
+		if err != nil {
+			bw.End()
+			return apperr.Internal(err, "failed to delete custom field values")
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return apperr.Internal(err, "failed to delete custom field values")
+		}
+	}
+
+	if _, err := client.Collection(Collection).Doc(id).Delete(ctx, firestore.Exists); err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return apperr.NotFound("custom field not found: %s", id)
+		}
+		return apperr.Internal(err, "failed to delete custom field")
+	}
+	return nil
+}
+
+// Encode 顧客の値を検証し、Key ごとの保存する値を返す（空の値は nil で、更新時は値を削除する）
+func Encode(fields []*Field, values []Value) (map[string]interface{}, error) {
+	byKey := make(map[string]*Field, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+
+	encoded := make(map[string]interface{}, len(values))
+	for _, v := range values {
+		f := byKey[v.Key]
+		if f == nil {
+			return nil, apperr.Validation("unknown custom field: %q", v.Key)
+		}
+		if _, ok := encoded[v.Key]; ok {
+			return nil, apperr.Validation("custom field %s is set more than once", v.Key)
+		}
+		raw, err := f.Encode(v)
+		if err != nil {
+			return nil, apperr.Validation("%v", err)
+		}
+		encoded[v.Key] = raw
+	}
+	return encoded, nil
+}
+
+// Updates Encode の結果を users ドキュメントの更新に変換する
+func Updates(encoded map[string]interface{}) []firestore.Update {
+	keys := make([]string, 0, len(encoded))
+	for key := range encoded {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	updates := make([]firestore.Update, 0, len(encoded))
+	for _, key := range keys {
+		raw := encoded[key]
+		if raw == nil {
+			raw = firestore.Delete
+		}
+		updates = append(updates, firestore.Update{Path: valuePath(key), Value: raw})
+	}
+	return updates
+}
+
+// valuePath users ドキュメントの値のフィールドパス（Key はフィールドパスにそのまま使える文字列に限っている）
+func valuePath(key string) string {
+	return FieldCustomFields + "." + key
+}
+
+// fieldID 項目のドキュメントID（組織ごとに Key が一意になるように組織IDを前に付ける）
+func fieldID(tenantID, key string) string {
+	return fmt.Sprintf("%s_%s", tenantID, key)
+}
+
+func fieldFromSnapshot(doc *firestore.DocumentSnapshot) (*Field, error) {
+	var f Field
+	if err := doc.DataTo(&f); err != nil {
+		return nil, apperr.Internal(err, "failed to decode custom field %s", doc.Ref.ID)
+	}
+	f.ID = doc.Ref.ID
+	return &f, nil
+}
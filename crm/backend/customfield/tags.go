@@ -0,0 +1,126 @@
+package customfield
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/optimistic"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+const (
+	// FieldTags タグを保存する users のフィールド
+	FieldTags = "tags"
+	// maxTagLength タグの最大文字数
+	maxTagLength = 50
+)
+
+// NormalizeTags 前後の空白を除き、重複を除いたタグ（空のタグ・長すぎるタグはエラー）
+//
+// タグは自由入力で、大文字・小文字は区別する。
+func NormalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("tag must not be empty")
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q must be at most %d characters", tag, maxTagLength)
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+// Tags users ドキュメントのタグ
+func Tags(data map[string]interface{}) []string {
+	return stringsOf(data[FieldTags])
+}
+
+// HasTags ドキュメントに tags がすべて付いているか
+func HasTags(data map[string]interface{}, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	current := Tags(data)
+	for _, tag := range tags {
+		if !slices.Contains(current, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateTags 顧客にタグをまとめて追加（remove の場合は削除）し、更新後の users ドキュメントを返す
+//
+// すべての顧客を1つのトランザクションで更新する。論理削除済み・スコープ外の組織の顧客が
+// 含まれる場合は NOT_FOUND で、どの顧客も更新しない。画面の編集とは競合しないため version は確認せずに進める。
+func UpdateTags(ctx context.Context, client *firestore.Client, userIDs, tags []string, remove bool) ([]*firestore.DocumentSnapshot, error) {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+	if len(tags) == 0 {
+		return nil, apperr.Validation("tags are required")
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(userIDs))
+	for i, id := range userIDs {
+		if !slices.Contains(userIDs[:i], id) {
+			refs = append(refs, client.Collection(softdelete.Users.Collection).Doc(id))
+		}
+	}
+
+	values := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		values[i] = tag
+	}
+	var change interface{} = firestore.ArrayUnion(values...)
+	if remove {
+		change = firestore.ArrayRemove(values...)
+	}
+
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docs, err := tx.GetAll(refs)
+		if err != nil {
+			return apperr.Internal(err, "failed to get users")
+		}
+		for _, doc := range docs {
+			if !doc.Exists() || softdelete.IsDeleted(doc.Data()) || !tenant.Allows(ctx, doc.Data()) {
+				return apperr.NotFound("user not found: %s", doc.Ref.ID)
+			}
+		}
+		now := time.Now()
+		for _, ref := range refs {
+			err := tx.Update(ref, []firestore.Update{
+				{Path: FieldTags, Value: change},
+				{Path: "updated_at", Value: now},
+				optimistic.Bump(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to get users")
+	}
+	return docs, nil
+}
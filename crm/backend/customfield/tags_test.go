@@ -0,0 +1,55 @@
+package customfield
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no tags", tags: nil, want: []string{}},
+		{name: "trims and removes duplicates", tags: []string{" vip ", "vip", "展示会"}, want: []string{"vip", "展示会"}},
+		{name: "case sensitive", tags: []string{"VIP", "vip"}, want: []string{"VIP", "vip"}},
+		{name: "empty tag", tags: []string{"vip", "  "}, wantErr: true},
+		{name: "tag too long", tags: []string{strings.Repeat("あ", 51)}, wantErr: true},
+		{name: "longest tag", tags: []string{strings.Repeat("あ", 50)}, want: []string{strings.Repeat("あ", 50)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTags(tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	data := map[string]interface{}{FieldTags: []interface{}{"vip", "展示会"}}
+	tests := []struct {
+		data map[string]interface{}
+		tags []string
+		want bool
+	}{
+		{data, nil, true},
+		{data, []string{"vip"}, true},
+		{data, []string{"展示会", "vip"}, true},
+		{data, []string{"vip", "休眠"}, false},
+		{map[string]interface{}{}, []string{"vip"}, false},
+		{map[string]interface{}{}, nil, true},
+	}
+	for _, tt := range tests {
+		if got := HasTags(tt.data, tt.tags); got != tt.want {
+			t.Errorf("HasTags(%v, %q) = %v, want %v", tt.data, tt.tags, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// デフォルトの設定
+const (
+	DefaultWait     = 2 * time.Millisecond
+	DefaultMaxBatch = 100
+)
+
+// BatchFunc keys をまとめて取得する関数
+//
+// 戻り値の map に含まれないキーはゼロ値として扱う（存在しないドキュメントなど）。
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader 同じリクエスト内の読み取りをまとめ、結果をキャッシュするローダー
+//
+// wait の間に呼ばれた Load を1回の BatchFunc にまとめる。
+// キャッシュはリクエスト単位で作り直す前提で、期限切れの仕組みは持たない。
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+	closed  bool
+}
+
+// New Loader のコンストラクタ。wait / maxBatch が 0 以下の場合はデフォルト値を使う
+func New[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	if wait <= 0 {
+		wait = DefaultWait
+	}
+	if maxBatch <= 0 {
+		maxBatch = DefaultMaxBatch
+	}
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load key の値を取得する
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.enqueue(ctx, key, r)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Prime 取得済みの値をキャッシュに登録する（既にある場合は何もしない）
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	r := &result[V]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.cache[key] = r
+}
+
+// enqueue 現在のバッチにキーを追加する。l.mu を保持した状態で呼ぶこと
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, r *result[V]) {
+	if l.batch == nil {
+		b := &batch[K, V]{}
+		l.batch = b
+		time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+	}
+
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if len(b.keys) >= l.maxBatch {
+		l.batch = nil
+		b.closed = true
+		go l.run(ctx, b)
+	}
+}
+
+// dispatch 待ち時間が経過したバッチを実行する
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if b.closed {
+		l.mu.Unlock()
+		return
+	}
+	b.closed = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	l.run(ctx, b)
+}
+
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	values, err := l.fetch(ctx, b.keys)
+
+	if err != nil {
+		// エラーはキャッシュせず、次の Load で再取得できるようにする
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.cache[key] == b.results[i] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	for i, key := range b.keys {
+		r := b.results[i]
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[key]
+		}
+		close(r.done)
+	}
+}
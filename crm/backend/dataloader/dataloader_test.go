@@ -0,0 +1,239 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder BatchFunc の呼び出しを記録する
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(_ context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	r.batches = append(r.batches, sorted)
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]string, len(keys))
+	for _, k := range keys {
+		if k >= 0 { // 負のキーは存在しないものとして扱う
+			values[k] = fmt.Sprint("v", k)
+		}
+	}
+	return values, nil
+}
+
+func (r *recorder) calls() [][]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.batches)
+}
+
+// loadAll keys を並行に Load し、結果をキーの順に返す
+func loadAll(l *Loader[int, string], keys []int) ([]string, []error) {
+	values := make([]string, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, k := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(context.Background(), k)
+		}()
+	}
+	wg.Wait()
+	return values, errs
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBatch    int
+		keys        []int
+		wantValues  []string
+		wantBatches [][]int // キーを昇順にしたもの
+	}{
+		{
+			name:        "loads within the wait window are batched",
+			maxBatch:    10,
+			keys:        []int{3, 1, 2},
+			wantValues:  []string{"v3", "v1", "v2"},
+			wantBatches: [][]int{{1, 2, 3}},
+		},
+		{
+			name:        "duplicate keys are fetched once",
+			maxBatch:    10,
+			keys:        []int{1, 1, 2, 1},
+			wantValues:  []string{"v1", "v1", "v2", "v1"},
+			wantBatches: [][]int{{1, 2}},
+		},
+		{
+			name:        "missing keys are zero values",
+			maxBatch:    10,
+			keys:        []int{1, -1},
+			wantValues:  []string{"v1", ""},
+			wantBatches: [][]int{{-1, 1}},
+		},
+		{
+			name:       "batches are split at maxBatch",
+			maxBatch:   2,
+			keys:       []int{1, 2, 3, 4, 5},
+			wantValues: []string{"v1", "v2", "v3", "v4", "v5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			// 並行に呼んだ Load が同じバッチに入るよう待ち時間を長めにする
+			l := New(rec.fetch, 50*time.Millisecond, tt.maxBatch)
+
+			values, errs := loadAll(l, tt.keys)
+			for i, err := range errs {
+				if err != nil {
+					t.Fatalf("Load(%d): %v", tt.keys[i], err)
+				}
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Errorf("values = %q, want %q", values, tt.wantValues)
+			}
+
+			calls := rec.calls()
+			if tt.wantBatches != nil && !reflect.DeepEqual(calls, tt.wantBatches) {
+				t.Errorf("batches = %v, want %v", calls, tt.wantBatches)
+			}
+			var fetched []int
+			for _, b := range calls {
+				if len(b) > tt.maxBatch {
+					t.Errorf("batch %v is larger than maxBatch %d", b, tt.maxBatch)
+				}
+				fetched = append(fetched, b...)
+			}
+			slices.Sort(fetched)
+			if want := slices.Compact(slices.Sorted(slices.Values(tt.keys))); !reflect.DeepEqual(fetched, want) {
+				t.Errorf("fetched keys = %v, want each of %v once", fetched, want)
+			}
+		})
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	rec := &recorder{}
+	l := New(rec.fetch, time.Millisecond, 10)
+
+	for i := 0; i < 3; i++ {
+		if v, err := l.Load(context.Background(), 1); err != nil || v != "v1" {
+			t.Fatalf("Load(1) = %q, %v", v, err)
+		}
+	}
+	if calls := rec.calls(); len(calls) != 1 {
+		t.Errorf("batches = %v, want one fetch", calls)
+	}
+}
+
+func TestLoadErrorsAreNotCached(t *testing.T) {
+	rec := &recorder{err: errors.New("unavailable")}
+	l := New(rec.fetch, time.Millisecond, 10)
+
+	_, errs := loadAll(l, []int{1, 2})
+	for _, err := range errs {
+		if !errors.Is(err, rec.err) {
+			t.Fatalf("Load() error = %v, want %v", err, rec.err)
+		}
+	}
+
+	rec.mu.Lock()
+	rec.err = nil
+	rec.mu.Unlock()
+
+	if v, err := l.Load(context.Background(), 1); err != nil || v != "v1" {
+		t.Errorf("Load(1) after the error = %q, %v, want v1", v, err)
+	}
+	if calls := rec.calls(); len(calls) != 2 {
+		t.Errorf("batches = %v, want the failed key to be fetched again", calls)
+	}
+}
+
+func TestPrime(t *testing.T) {
+	rec := &recorder{}
+	l := New(rec.fetch, time.Millisecond, 10)
+
+	l.Prime(1, "primed")
+	if v, err := l.Load(context.Background(), 1); err != nil || v != "primed" {
+		t.Errorf("Load(1) = %q, %v, want primed", v, err)
+	}
+	if calls := rec.calls(); len(calls) != 0 {
+		t.Errorf("batches = %v, want no fetch for a primed key", calls)
+	}
+
+	// 取得済みの値は上書きしない
+	if _, err := l.Load(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	l.Prime(2, "primed")
+	if v, _ := l.Load(context.Background(), 2); v != "v2" {
+		t.Errorf("Load(2) = %q, want v2", v)
+	}
+}
+
+func TestLoadCanceled(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	l := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		<-block
+		return nil, nil
+	}, time.Millisecond, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Load(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Load() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLoadConcurrent(t *testing.T) {
+	// go test -race で競合がないことを確かめる
+	rec := &recorder{}
+	l := New(rec.fetch, time.Millisecond, 7)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 50; k++ {
+				key := (g*13 + k) % 40
+				if g%5 == 0 {
+					l.Prime(key, fmt.Sprint("v", key))
+				}
+				if v, err := l.Load(context.Background(), key); err != nil || v != fmt.Sprint("v", key) {
+					t.Errorf("Load(%d) = %q, %v", key, v, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, b := range rec.calls() {
+		for _, k := range b {
+			if seen[k] {
+				t.Errorf("key %d was fetched more than once", k)
+			}
+			seen[k] = true
+		}
+	}
+}
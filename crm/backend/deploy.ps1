@@ -28,7 +28,9 @@ gcloud services enable containerregistry.googleapis.com
 
 # Build and push the Docker image
 Write-Host "Building Docker image..." -ForegroundColor Yellow
-gcloud builds submit --tag $IMAGE_NAME
+# The image is built from the repository root because go.mod refers to ../../shared
+docker build -f Dockerfile -t $IMAGE_NAME ../..
+docker push $IMAGE_NAME
 
 # Deploy to Cloud Run
 Write-Host "Deploying to Cloud Run..." -ForegroundColor Yellow
@@ -25,7 +25,9 @@ gcloud services enable containerregistry.googleapis.com
 
 # Build and push the Docker image
 echo "Building Docker image..."
-gcloud builds submit --tag $IMAGE_NAME
+# The image is built from the repository root because go.mod refers to ../../shared
+docker build -f Dockerfile -t $IMAGE_NAME ../..
+docker push $IMAGE_NAME
 
 # Deploy to Cloud Run
 echo "Deploying to Cloud Run..."
@@ -0,0 +1,237 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ColumnType 列の型
+type ColumnType int
+
+const (
+	String ColumnType = iota
+	Float
+	Int
+	Time
+)
+
+// Column 出力する列。Name は Firestore のフィールド名（DocID の場合はドキュメントID）
+type Column struct {
+	Name  string
+	Type  ColumnType
+	DocID bool
+}
+
+// Dataset エクスポート対象のコレクション
+type Dataset struct {
+	Name       string
+	Collection string
+	Columns    []Column
+
+	// filters 対応している絞り込み条件（一覧クエリと同じもの）
+	filters map[string]bool
+}
+
+// Filter 絞り込み条件。対応していない条件を指定した場合はエラー
+//
+// Status / Type は Firestore の値（小文字）で指定する。
+type Filter struct {
+	Search   string     // first_name の前方一致（users）
+	UserID   string     // user_id（wallets / orders / interactions）
+	Status   string     // status
+	Type     string     // type（interactions）
+	DateFrom *time.Time // order_date の範囲（orders）
+	DateTo   *time.Time
+}
+
+// データセット
+var (
+	Users = &Dataset{
+		Name:       "users",
+		Collection: "users",
+		Columns: []Column{
+			{Name: "user_id", DocID: true},
+			{Name: "last_name"},
+			{Name: "first_name"},
+			{Name: "last_name_katakana"},
+			{Name: "first_name_katakana"},
+			{Name: "email_address"},
+			{Name: "role"},
+			{Name: "balance", Type: Float},
+			{Name: "status"},
+			{Name: "created_at", Type: Time},
+			{Name: "updated_at", Type: Time},
+		},
+		filters: map[string]bool{"search": true, "status": true},
+	}
+	Wallets = &Dataset{
+		Name:       "wallets",
+		Collection: "wallets",
+		Columns: []Column{
+			{Name: "wallet_address", DocID: true},
+			{Name: "user_id"},
+			{Name: "balance", Type: Float},
+			{Name: "currency"},
+			{Name: "status"},
+			{Name: "created_at", Type: Time},
+			{Name: "updated_at", Type: Time},
+		},
+		filters: map[string]bool{"user_id": true, "status": true},
+	}
+	Orders = &Dataset{
+		Name:       "orders",
+		Collection: "orders",
+		Columns: []Column{
+			{Name: "id", DocID: true},
+			{Name: "user_id"},
+			{Name: "order_number"},
+			{Name: "status"},
+			{Name: "total_amount", Type: Float},
+			{Name: "currency"},
+			{Name: "order_date", Type: Time},
+			{Name: "delivery_date", Type: Time},
+			{Name: "notes"},
+			{Name: "created_at", Type: Time},
+			{Name: "updated_at", Type: Time},
+		},
+		filters: map[string]bool{"user_id": true, "status": true, "date": true},
+	}
+	Interactions = &Dataset{
+		Name:       "interactions",
+		Collection: "interactions",
+		Columns: []Column{
+			{Name: "id", DocID: true},
+			{Name: "user_id"},
+			{Name: "type"},
+			{Name: "subject"},
+			{Name: "content"},
+			{Name: "channel"},
+			{Name: "status"},
+			{Name: "assigned_to"},
+			{Name: "scheduled_at", Type: Time},
+			{Name: "completed_at", Type: Time},
+			{Name: "created_at", Type: Time},
+			{Name: "updated_at", Type: Time},
+		},
+		filters: map[string]bool{"user_id": true, "status": true, "type": true},
+	}
+)
+
+// Datasets 名前からデータセットを引く
+var Datasets = map[string]*Dataset{
+	Users.Name:        Users,
+	Wallets.Name:      Wallets,
+	Orders.Name:       Orders,
+	Interactions.Name: Interactions,
+}
+
+// LookupDataset 名前（大文字小文字を区別しない）からデータセットを取得
+func LookupDataset(name string) (*Dataset, error) {
+	if d, ok := Datasets[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("unknown export dataset: %q", name)
+}
+
+// Query 絞り込み条件を適用したクエリ
+func (d *Dataset) Query(client *firestore.Client, f Filter) (firestore.Query, error) {
+	q := client.Collection(d.Collection).Query
+
+	used := []struct {
+		name string
+		set  bool
+	}{
+		{"search", f.Search != ""},
+		{"user_id", f.UserID != ""},
+		{"status", f.Status != ""},
+		{"type", f.Type != ""},
+		{"date", f.DateFrom != nil || f.DateTo != nil},
+	}
+	for _, u := range used {
+		if u.set && !d.filters[u.name] {
+			return q, fmt.Errorf("filter %s is not supported for %s", u.name, d.Name)
+		}
+	}
+
+	if f.UserID != "" {
+		q = q.Where("user_id", "==", f.UserID)
+	}
+	if f.Status != "" {
+		q = q.Where("status", "==", f.Status)
+	}
+	if f.Type != "" {
+		q = q.Where("type", "==", f.Type)
+	}
+	if f.Search != "" {
+		// users クエリと同じく first_name の前方一致
+		q = q.Where("first_name", ">=", f.Search).Where("first_name", "<=", f.Search+"\uf8ff")
+	}
+	if f.DateFrom != nil {
+		q = q.Where("order_date", ">=", *f.DateFrom)
+	}
+	if f.DateTo != nil {
+		q = q.Where("order_date", "<=", *f.DateTo)
+	}
+	return q, nil
+}
+
+// row ドキュメントを列の順に変換する。値がない列は nil
+func (d *Dataset) row(doc *firestore.DocumentSnapshot) []interface{} {
+	data := doc.Data()
+	values := make([]interface{}, len(d.Columns))
+	for i, c := range d.Columns {
+		if c.DocID {
+			// wallet_address のようにフィールドがあればそれを優先する
+			if s, ok := data[c.Name].(string); ok && s != "" {
+				values[i] = s
+			} else {
+				values[i] = doc.Ref.ID
+			}
+			continue
+		}
+		values[i] = convertValue(data[c.Name], c.Type)
+	}
+	return values
+}
+
+// convertValue Firestore の値を列の型に合わせる（変換できない場合は nil）
+func convertValue(v interface{}, t ColumnType) interface{} {
+	switch t {
+	case String:
+		switch v := v.(type) {
+		case string:
+			return v
+		case nil:
+			return nil
+		default:
+			return fmt.Sprint(v)
+		}
+	case Float:
+		switch v := v.(type) {
+		case float64:
+			return v
+		case int64:
+			return float64(v)
+		}
+	case Int:
+		switch v := v.(type) {
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		}
+	case Time:
+		switch v := v.(type) {
+		case time.Time:
+			return v.UTC()
+		case string:
+			if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+				return parsed.UTC()
+			}
+		}
+	}
+	return nil
+}
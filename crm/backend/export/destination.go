@@ -0,0 +1,112 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+// File 書き込み中のファイル
+type File interface {
+	io.WriteCloser
+	// Abort 書き込みを中止して、作成途中のファイルを破棄する
+	Abort()
+}
+
+// Destination エクスポートしたファイルの保存先
+type Destination interface {
+	// Create name のファイルを作成する
+	Create(ctx context.Context, name string, contentType string) (File, error)
+	// Locate 作成したファイルの取得先（ローカルはパス、Cloud Storage は署名付きURL）と有効期限
+	Locate(ctx context.Context, name string) (location string, expiresAt *time.Time, err error)
+}
+
+// LocalDir ローカルのディレクトリに保存する
+type LocalDir struct {
+	Dir string
+}
+
+// Create ディレクトリがなければ作成してからファイルを作成する
+func (d LocalDir) Create(_ context.Context, name string, _ string) (File, error) {
+	if err := os.MkdirAll(d.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	f, err := os.Create(filepath.Join(d.Dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	return localFile{f}, nil
+}
+
+// Locate ファイルの絶対パス
+func (d LocalDir) Locate(_ context.Context, name string) (string, *time.Time, error) {
+	p, err := filepath.Abs(filepath.Join(d.Dir, name))
+	return p, nil, err
+}
+
+type localFile struct {
+	*os.File
+}
+
+func (f localFile) Abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// Bucket Cloud Storage に保存し、ダウンロード用の署名付きURLを発行する
+type Bucket struct {
+	Client *storage.Client
+	Name   string
+	Prefix string        // オブジェクト名の接頭辞（"exports" など）
+	Expiry time.Duration // 署名付きURLの有効期間
+}
+
+// Create オブジェクトへの書き込みを開始する（Close で確定）
+func (b Bucket) Create(ctx context.Context, name string, contentType string) (File, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := b.Client.Bucket(b.Name).Object(b.object(name)).NewWriter(ctx)
+	w.ContentType = contentType
+	return &bucketFile{Writer: w, cancel: cancel}, nil
+}
+
+// Locate GET 用の署名付きURL
+//
+// 認証情報にサービスアカウントの秘密鍵がない環境（Cloud Run など）では IAM の signBlob で署名する。
+func (b Bucket) Locate(_ context.Context, name string) (string, *time.Time, error) {
+	expiresAt := time.Now().Add(b.Expiry)
+	url, err := b.Client.Bucket(b.Name).SignedURL(b.object(name), &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: expiresAt,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign export URL: %w", err)
+	}
+	return url, &expiresAt, nil
+}
+
+func (b Bucket) object(name string) string {
+	return path.Join(b.Prefix, name)
+}
+
+type bucketFile struct {
+	*storage.Writer
+	cancel context.CancelFunc
+}
+
+func (f *bucketFile) Close() error {
+	defer f.cancel()
+	return f.Writer.Close()
+}
+
+// Abort コンテキストをキャンセルするとアップロードは確定されない
+func (f *bucketFile) Abort() {
+	f.cancel()
+	f.Writer.Close()
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+// デフォルトの設定
+const (
+	DefaultBucket    = "narratives-crm.appspot.com"
+	DefaultPrefix    = "exports"
+	DefaultURLExpiry = time.Hour
+)
+
+// Request エクスポートの条件
+type Request struct {
+	Dataset *Dataset
+	Format  Format
+	Filter  Filter
+}
+
+// Result エクスポートしたファイル
+type Result struct {
+	Dataset   string
+	Format    Format
+	FileName  string
+	Location  string     // ローカルのパス、または署名付きURL
+	ExpiresAt *time.Time // 署名付きURLの有効期限
+	Rows      int
+}
+
+// Exporter Firestore のコレクションをファイルにエクスポートする
+//
+// ドキュメントは1件ずつ読みながら書き出すため、件数が多くてもメモリに全件を持たない。
+// コンテキストにスコープがある場合はその組織のドキュメントだけを書き出す。
+type Exporter struct {
+	Client      *firestore.Client
+	Destination Destination
+}
+
+// DestinationFromEnv 環境変数から保存先を作成する
+//
+//	EXPORT_DIR         指定した場合はローカルのディレクトリに保存する
+//	EXPORT_BUCKET      Cloud Storage のバケット（デフォルト: narratives-crm.appspot.com）
+//	EXPORT_URL_EXPIRY  署名付きURLの有効期間（例: 30m、デフォルト: 1h）
+func DestinationFromEnv(ctx context.Context) (Destination, error) {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return LocalDir{Dir: dir}, nil
+	}
+
+	expiry := DefaultURLExpiry
+	if v := os.Getenv("EXPORT_URL_EXPIRY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid EXPORT_URL_EXPIRY: %q", v)
+		}
+		expiry = d
+	}
+
+	bucket := os.Getenv("EXPORT_BUCKET")
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+	return Bucket{Client: client, Name: bucket, Prefix: DefaultPrefix, Expiry: expiry}, nil
+}
+
+// Export 条件に一致するドキュメントを書き出し、保存先を返す
+func (e *Exporter) Export(ctx context.Context, req Request) (*Result, error) {
+	query, err := req.Dataset.Query(e.Client, req.Filter)
+	if err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+	query = tenant.Query(ctx, query)
+
+	name := fmt.Sprintf("%s_%s.%s", req.Dataset.Name, time.Now().UTC().Format("20060102T150405Z"), req.Format.Extension())
+	file, err := e.Destination.Create(ctx, name, req.Format.ContentType())
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to create export file")
+	}
+
+	rows, err := e.write(ctx, file, query, req)
+	if err != nil {
+		file.Abort()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, apperr.Internal(err, "failed to save export file")
+	}
+
+	location, expiresAt, err := e.Destination.Locate(ctx, name)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to locate export file")
+	}
+	return &Result{
+		Dataset:   req.Dataset.Name,
+		Format:    req.Format,
+		FileName:  name,
+		Location:  location,
+		ExpiresAt: expiresAt,
+		Rows:      rows,
+	}, nil
+}
+
+func (e *Exporter) write(ctx context.Context, file File, query firestore.Query, req Request) (int, error) {
+	w, err := newRowWriter(req.Format, file, req.Dataset.Columns)
+	if err != nil {
+		return 0, apperr.Internal(err, "failed to start %s export", req.Format)
+	}
+
+	it := query.Documents(ctx)
+	defer it.Stop()
+
+	rows := 0
+	for {
+		doc, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return rows, apperr.Internal(err, "failed to read %s from Firestore", req.Dataset.Collection)
+		}
+		// 一覧クエリと同じく論理削除済みは含めない
+		if softdelete.IsDeleted(doc.Data()) {
+			continue
+		}
+		if err := w.Write(req.Dataset.row(doc)); err != nil {
+			return rows, apperr.Internal(err, "failed to write %s export", req.Format)
+		}
+		rows++
+	}
+
+	if err := w.Close(); err != nil {
+		return rows, apperr.Internal(err, "failed to write %s export", req.Format)
+	}
+	return rows, nil
+}
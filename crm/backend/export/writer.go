@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Format 出力形式
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat 形式名（大文字小文字を区別しない）を Format に変換
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatCSV, FormatJSONL, FormatParquet:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported export format: %q", s)
+}
+
+// Extension ファイルの拡張子
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentType ファイルの Content-Type
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// rowWriter 行を1件ずつ書き出す
+type rowWriter interface {
+	Write(values []interface{}) error
+	// Close 書き出しを完了する（下位の io.Writer は閉じない）
+	Close() error
+}
+
+func newRowWriter(f Format, w io.Writer, columns []Column) (rowWriter, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	}
+	return nil, fmt.Errorf("unsupported export format: %q", f)
+}
+
+// csvWriter ヘッダー付きの CSV。nil は空欄、日時は RFC3339
+//
+// Excel で日本語が文字化けしないよう、先頭に BOM を付ける。
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		return nil, err
+	}
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, c := range columns {
+		cw.record[i] = c.Name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(values []interface{}) error {
+	for i, v := range values {
+		cw.record[i] = formatValue(v)
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonlWriter 1行1オブジェクトの JSON Lines。キーは列の順に出力する
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func newJSONLWriter(w io.Writer, columns []Column) *jsonlWriter {
+	jw := &jsonlWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(columns))}
+	for i, c := range columns {
+		jw.keys[i], _ = json.Marshal(c.Name)
+	}
+	return jw
+}
+
+func (jw *jsonlWriter) Write(values []interface{}) error {
+	jw.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			jw.w.WriteByte(',')
+		}
+		jw.w.Write(jw.keys[i])
+		jw.w.WriteByte(':')
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		jw.w.Write(b)
+	}
+	jw.w.WriteByte('}')
+	return jw.w.WriteByte('\n')
+}
+
+func (jw *jsonlWriter) Close() error {
+	return jw.w.Flush()
+}
+
+// parquetWriter 全列 OPTIONAL の Parquet。日時は TIMESTAMP_MILLIS
+type parquetWriter struct {
+	w *writer.CSVWriter
+}
+
+// parquetConcurrency Parquet のエンコードに使うゴルーチン数
+const parquetConcurrency = 4
+
+func newParquetWriter(w io.Writer, columns []Column) (*parquetWriter, error) {
+	md := make([]string, len(columns))
+	for i, c := range columns {
+		md[i] = fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", c.Name, parquetType(c.Type))
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(md, w, parquetConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetWriter{w: pw}, nil
+}
+
+func parquetType(t ColumnType) string {
+	switch t {
+	case Float:
+		return "type=DOUBLE"
+	case Int:
+		return "type=INT64"
+	case Time:
+		return "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	default:
+		return "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+}
+
+func (pw *parquetWriter) Write(values []interface{}) error {
+	rec := make([]interface{}, len(values))
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			rec[i] = t.UnixMilli()
+		} else {
+			rec[i] = v
+		}
+	}
+	return pw.w.Write(rec)
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.w.WriteStop()
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testColumns = []Column{
+	{Name: "user_id", DocID: true},
+	{Name: "first_name"},
+	{Name: "balance", Type: Float},
+	{Name: "created_at", Type: Time},
+}
+
+var testRows = [][]interface{}{
+	{"u1", "太郎", 1200.5, time.Date(2025, 4, 1, 9, 30, 0, 0, time.UTC)},
+	{"u2", nil, float64(0), nil},
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "csv", want: FormatCSV},
+		{in: "JSONL", want: FormatJSONL},
+		{in: " parquet ", want: FormatParquet},
+		{in: "json", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q (wantErr %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	tests := []struct {
+		format      Format
+		extension   string
+		contentType string
+	}{
+		{FormatCSV, "csv", "text/csv; charset=utf-8"},
+		{FormatJSONL, "jsonl", "application/x-ndjson"},
+		{FormatParquet, "parquet", "application/vnd.apache.parquet"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Extension(); got != tt.extension {
+			t.Errorf("%s.Extension() = %q, want %q", tt.format, got, tt.extension)
+		}
+		if got := tt.format.ContentType(); got != tt.contentType {
+			t.Errorf("%s.ContentType() = %q, want %q", tt.format, got, tt.contentType)
+		}
+	}
+}
+
+// writeRows rows を format で書き出した結果
+func writeRows(t *testing.T, format Format, rows [][]interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := newRowWriter(format, &buf, testColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRowWriter(t *testing.T) {
+	tests := []struct {
+		format Format
+		rows   [][]interface{}
+		want   string
+	}{
+		{
+			format: FormatCSV,
+			rows:   testRows,
+			want: "\xEF\xBB\xBFuser_id,first_name,balance,created_at\n" +
+				"u1,太郎,1200.5,2025-04-01T09:30:00Z\n" +
+				"u2,,0,\n",
+		},
+		{
+			format: FormatCSV,
+			want:   "\xEF\xBB\xBFuser_id,first_name,balance,created_at\n",
+		},
+		{
+			format: FormatJSONL,
+			rows:   testRows,
+			want: `{"user_id":"u1","first_name":"太郎","balance":1200.5,"created_at":"2025-04-01T09:30:00Z"}` + "\n" +
+				`{"user_id":"u2","first_name":null,"balance":0,"created_at":null}` + "\n",
+		},
+		{
+			format: FormatJSONL,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := string(writeRows(t, tt.format, tt.rows)); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	got := writeRows(t, FormatParquet, testRows)
+	// Parquet ファイルは先頭と末尾にマジックナンバーを持つ
+	if !bytes.HasPrefix(got, []byte("PAR1")) || !bytes.HasSuffix(got, []byte("PAR1")) {
+		t.Errorf("output is not a Parquet file: %q...", got[:min(len(got), 16)])
+	}
+	if !bytes.Contains(got, []byte("created_at")) {
+		t.Error("Parquet schema does not contain the created_at column")
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name string
+		v    interface{}
+		t    ColumnType
+		want interface{}
+	}{
+		{name: "string", v: "太郎", t: String, want: "太郎"},
+		{name: "string from number", v: int64(3), t: String, want: "3"},
+		{name: "nil string", v: nil, t: String, want: nil},
+		{name: "float", v: 1.5, t: Float, want: 1.5},
+		{name: "float from int", v: int64(2), t: Float, want: float64(2)},
+		{name: "float from string", v: "1.5", t: Float, want: nil},
+		{name: "int", v: int64(7), t: Int, want: int64(7)},
+		{name: "int from float", v: 7.9, t: Int, want: int64(7)},
+		{name: "time in UTC", v: time.Date(2025, 4, 1, 18, 0, 0, 0, jst), t: Time, want: time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "time from RFC3339", v: "2025-04-01T18:00:00+09:00", t: Time, want: time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "time from other string", v: "2025/04/01", t: Time, want: nil},
+		{name: "missing time", v: nil, t: Time, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertValue(tt.v, tt.t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertValue(%v) = %#v, want %#v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupDataset(t *testing.T) {
+	for _, name := range []string{"users", "Wallets", " orders ", "INTERACTIONS"} {
+		d, err := LookupDataset(name)
+		if err != nil {
+			t.Errorf("LookupDataset(%q) error = %v", name, err)
+			continue
+		}
+		if d.Name != strings.ToLower(strings.TrimSpace(name)) {
+			t.Errorf("LookupDataset(%q) = %s", name, d.Name)
+		}
+	}
+	if _, err := LookupDataset("audit_logs"); err == nil {
+		t.Error("LookupDataset(audit_logs) did not return an error")
+	}
+}
@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/audit"
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/customfield"
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/tenant"
+)
+
+// AuditEntities 監査ログで変更前後の差分を記録するミューテーション
+var AuditEntities = map[string]audit.Entity{
+	"createUser":  {Type: "user", Collection: "users", ResultID: resultID(func(u *model.User) string { return u.UserID })},
+	"updateUser":  {Type: "user", Collection: "users", IDArg: "user_id"},
+	"deleteUser":  {Type: "user", Collection: "users", IDArg: "user_id"},
+	"restoreUser": {Type: "user", Collection: "users", IDArg: "user_id"},
+
+	"inviteStaff":     {Type: "staff", Collection: "business_users", ResultID: resultID(func(s *model.StaffUser) string { return s.ID })},
+	"changeStaffRole": {Type: "staff", Collection: "business_users", IDArg: "id"},
+	"disableStaff":    {Type: "staff", Collection: "business_users", IDArg: "id"},
+
+	"createWallet":  {Type: "wallet", Collection: "wallets", ResultID: resultID(func(w *model.Wallet) string { return w.WalletAddress })},
+	"updateWallet":  {Type: "wallet", Collection: "wallets", IDArg: "wallet_address"},
+	"deleteWallet":  {Type: "wallet", Collection: "wallets", IDArg: "wallet_address"},
+	"restoreWallet": {Type: "wallet", Collection: "wallets", IDArg: "wallet_address"},
+
+	"createOrder":       {Type: "order", Collection: "orders", ResultID: resultID(func(o *model.Order) string { return o.ID })},
+	"updateOrderStatus": {Type: "order", Collection: "orders", IDArg: "id"},
+	"deleteOrder":       {Type: "order", Collection: "orders", IDArg: "id"},
+	"restoreOrder":      {Type: "order", Collection: "orders", IDArg: "id"},
+
+	"createInteraction":       {Type: "interaction", Collection: "interactions", ResultID: resultID(func(i *model.Interaction) string { return i.ID })},
+	"updateInteractionStatus": {Type: "interaction", Collection: "interactions", IDArg: "id"},
+	"completeInteraction":     {Type: "interaction", Collection: "interactions", IDArg: "id"},
+	"deleteInteraction":       {Type: "interaction", Collection: "interactions", IDArg: "id"},
+	"restoreInteraction":      {Type: "interaction", Collection: "interactions", IDArg: "id"},
+
+	"saveCustomField":   {Type: "custom_field", Collection: customfield.Collection, ResultID: resultID(func(f *model.CustomField) string { return f.ID })},
+	"deleteCustomField": {Type: "custom_field", Collection: customfield.Collection, IDArg: "id"},
+	"tagUsers":          {Type: "user"},
+	"untagUsers":        {Type: "user"},
+
+	"saveSegment":   {Type: "segment", Collection: "segments", IDArg: "id", ResultID: resultID(func(s *model.Segment) string { return s.ID })},
+	"deleteSegment": {Type: "segment", Collection: "segments", IDArg: "id"},
+
+	"importUsers":    {Type: "import_job", ResultID: resultID(func(j *model.ImportJob) string { return j.ID })},
+	"retryImportJob": {Type: "import_job", IDArg: "id"},
+
+	"createOrganization": {Type: "organization", Collection: tenant.Collection, ResultID: resultID(func(o *model.Organization) string { return o.ID })},
+	"updateOrganization": {Type: "organization", Collection: tenant.Collection, IDArg: "id", ResultID: resultID(func(o *model.Organization) string { return o.ID })},
+}
+
+// resultID resolver の結果（*T）からIDを取り出す関数
+func resultID[T any](id func(*T) string) func(interface{}) string {
+	return func(res interface{}) string {
+		if v, ok := res.(*T); ok && v != nil {
+			return id(v)
+		}
+		return ""
+	}
+}
+
+// adminRoles 管理者として扱うロール（Firebase Auth のカスタムクレーム role）
+var adminRoles = []string{"admin", "root", tenant.RoleSuperAdmin}
+
+// requireAdmin 呼び出し元が管理者でなければエラーを返す
+func requireAdmin(ctx context.Context) error {
+	caller := authn.CallerFromContext(ctx)
+	if caller == nil {
+		return apperr.Unauthenticated("authentication required")
+	}
+	for _, role := range adminRoles {
+		if strings.EqualFold(caller.Role, role) {
+			return nil
+		}
+	}
+	return apperr.Forbidden("admin role required")
+}
+
+// requireStaff 呼び出し元がスタッフ（ロールのカスタムクレームを持つビジネスユーザー）でなければエラーを返す
+func requireStaff(ctx context.Context) (*authn.Caller, error) {
+	caller := authn.CallerFromContext(ctx)
+	if caller == nil {
+		return nil, apperr.Unauthenticated("authentication required")
+	}
+	if caller.Role == "" {
+		return nil, apperr.Forbidden("staff role required")
+	}
+	return caller, nil
+}
+
+// requireSuperAdmin 呼び出し元がスーパー管理者（すべての組織を操作できる）でなければエラーを返す
+func requireSuperAdmin(ctx context.Context) error {
+	caller := authn.CallerFromContext(ctx)
+	if caller == nil {
+		return apperr.Unauthenticated("authentication required")
+	}
+	if !tenant.IsSuperAdmin(caller) {
+		return apperr.Forbidden("super admin role required")
+	}
+	return nil
+}
+
+// auditLogToModel audit.Entry を model.AuditLog に変換
+func auditLogToModel(e *audit.Entry) *model.AuditLog {
+	changes := make([]*model.AuditChange, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = &model.AuditChange{Field: c.Field, Before: jsonValue(c.Before), After: jsonValue(c.After)}
+	}
+
+	m := &model.AuditLog{
+		ID:        e.ID,
+		Operation: e.Operation,
+		Changes:   changes,
+		CreatedAt: e.CreatedAt,
+	}
+	if args := jsonValue(e.Arguments); args != nil {
+		m.Arguments = *args
+	} else {
+		m.Arguments = "{}"
+	}
+	m.TenantID = optionalString(e.TenantID)
+	m.ActorUID = optionalString(e.ActorUID)
+	m.ActorEmail = optionalString(e.ActorEmail)
+	m.ActorRole = optionalString(e.ActorRole)
+	m.EntityType = optionalString(e.EntityType)
+	m.EntityID = optionalString(e.EntityID)
+	m.Error = optionalString(e.Error)
+	m.RequestID = optionalString(e.RequestID)
+	return m
+}
+
+// jsonValue 値を JSON 文字列に変換（nil の場合は nil）
+func jsonValue(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+// optionalString 空文字を nil として返す
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
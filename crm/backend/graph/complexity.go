@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"time"
+
+	"narratives-crm-backend/graph/generated"
+	"narratives-crm-backend/graph/model"
+)
+
+const (
+	// relationCost Firestore の読み取りが発生するリレーションフィールドのコスト
+	relationCost = 5
+	// relationListSize 一覧のリレーション（User.wallets など）で想定する件数
+	relationListSize = 10
+	// defaultPageLimit PaginationInput.limit のデフォルト値
+	defaultPageLimit = 10
+)
+
+// Complexity フィールドごとのクエリ複雑度
+//
+// 一覧は取得件数を子フィールドの複雑度に掛け、リレーションには
+// Firestore 読み取り分のコストを加算する。User.wallets → Wallet.user → …
+// のような循環を深く辿るクエリは extension.FixedComplexityLimit で拒否される。
+func Complexity() generated.ComplexityRoot {
+	var c generated.ComplexityRoot
+
+	// 一覧クエリ
+	c.Query.Users = func(childComplexity int, pagination *model.PaginationInput, _ *string, _ *model.UserStatus, _ *string, _ []string, _ []*model.CustomFieldFilterInput, _ *model.CustomFieldSortInput) int {
+		return listComplexity(childComplexity, pageLimit(pagination))
+	}
+	c.Query.Wallets = func(childComplexity int, pagination *model.PaginationInput, _ *string, _ *model.WalletStatus) int {
+		return listComplexity(childComplexity, pageLimit(pagination))
+	}
+	c.Query.Orders = func(childComplexity int, pagination *model.PaginationInput, _ *string, _ *model.OrderStatus, _, _ *time.Time) int {
+		return listComplexity(childComplexity, pageLimit(pagination))
+	}
+	c.Query.Interactions = func(childComplexity int, pagination *model.PaginationInput, _ *string, _ *model.InteractionType, _ *model.InteractionStatus) int {
+		return listComplexity(childComplexity, pageLimit(pagination))
+	}
+	c.Query.ImportJobs = func(childComplexity int, limit *int) int {
+		if limit != nil && *limit > 0 {
+			return listComplexity(childComplexity, *limit)
+		}
+		return listComplexity(childComplexity, defaultPageLimit)
+	}
+
+	// リレーション
+	c.User.Wallets = relationList
+	c.Wallet.User = relation
+	c.Order.User = relation
+	c.Order.Items = relationList
+	c.OrderItem.Order = relation
+	c.Interaction.User = relation
+	c.DashboardData.RecentOrders = relationList
+	c.DashboardData.UpcomingInteractions = relationList
+
+	return c
+}
+
+func relation(childComplexity int) int {
+	return relationCost + childComplexity
+}
+
+func relationList(childComplexity int) int {
+	return relationCost + listComplexity(childComplexity, relationListSize)
+}
+
+func listComplexity(childComplexity, size int) int {
+	return 1 + childComplexity*size
+}
+
+// pageLimit 複雑度の計算に使う取得件数
+func pageLimit(pagination *model.PaginationInput) int {
+	if pagination != nil && pagination.Limit != nil && *pagination.Limit > 0 {
+		return *pagination.Limit
+	}
+	return defaultPageLimit
+}
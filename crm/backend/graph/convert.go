@@ -0,0 +1,426 @@
+package graph
+
+import (
+	"narratives-crm-backend/customfield"
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/importer"
+	"narratives-crm-backend/optimistic"
+	"narratives-crm-backend/segment"
+	"narratives-crm-backend/tenant"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// userFromSnapshot users ドキュメントを model.User に変換
+func userFromSnapshot(doc *firestore.DocumentSnapshot) *model.User {
+	data := doc.Data()
+
+	// ロールの変換
+	role := model.UserRoleUser // デフォルト
+	if roleStr, ok := data["role"].(string); ok {
+		switch roleStr {
+		case "admin":
+			role = model.UserRoleAdmin
+		case "moderator":
+			role = model.UserRoleModerator
+		default:
+			role = model.UserRoleUser
+		}
+	}
+
+	// ステータスの変換
+	userStatus := model.UserStatusActive // デフォルト
+	if statusStr, ok := data["status"].(string); ok {
+		switch statusStr {
+		case "inactive":
+			userStatus = model.UserStatusInactive
+		case "hot":
+			userStatus = model.UserStatusHot
+		case "cold":
+			userStatus = model.UserStatusCold
+		case "pending":
+			userStatus = model.UserStatusPending
+		default:
+			userStatus = model.UserStatusActive
+		}
+	}
+
+	return &model.User{
+		UserID:            doc.Ref.ID,
+		TenantID:          tenant.Of(data),
+		FirstName:         getStringFromData(data, "first_name"),
+		LastName:          getStringFromData(data, "last_name"),
+		FirstNameKatakana: getStringFromData(data, "first_name_katakana"),
+		LastNameKatakana:  getStringFromData(data, "last_name_katakana"),
+		EmailAddress:      getStringFromData(data, "email_address"),
+		Role:              role,
+		Balance:           getFloatFromData(data, "balance"),
+		Status:            userStatus,
+		CreatedAt:         getTimeFromData(data, "created_at"),
+		UpdatedAt:         getTimeFromData(data, "updated_at"),
+		Version:           optimistic.Version(data),
+		DeletedAt:         getOptionalTimeFromData(data, "deleted_at"),
+		DeletedBy:         getOptionalStringFromData(data, "deleted_by"),
+		Segments:          getStringsFromData(data, "segments"),
+		Rfm:               rfmFromData(data),
+		CustomFields:      customFieldValuesFromData(data),
+		Tags:              customfield.Tags(data),
+	}
+}
+
+// rfmFromData users.rfm を model.Rfm に変換（未評価の場合は nil）
+func rfmFromData(data map[string]interface{}) *model.Rfm {
+	rfm, ok := data["rfm"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	m := &model.Rfm{
+		Frequency:         int(getFloatFromData(rfm, "frequency")),
+		Monetary:          getFloatFromData(rfm, "monetary"),
+		RScore:            int(getFloatFromData(rfm, "r_score")),
+		FScore:            int(getFloatFromData(rfm, "f_score")),
+		MScore:            int(getFloatFromData(rfm, "m_score")),
+		InteractionCount:  int(getFloatFromData(rfm, "interaction_count")),
+		LastOrderAt:       getOptionalTimeFromData(rfm, "last_order_at"),
+		LastInteractionAt: getOptionalTimeFromData(rfm, "last_interaction_at"),
+	}
+	if _, ok := rfm["recency_days"].(int64); ok {
+		days := int(getFloatFromData(rfm, "recency_days"))
+		m.RecencyDays = &days
+	}
+	return m
+}
+
+// segmentToModel segment.Segment を model.Segment に変換
+func segmentToModel(s *segment.Segment) *model.Segment {
+	rules := make([]*model.SegmentRule, len(s.Rules))
+	for i, r := range s.Rules {
+		rules[i] = &model.SegmentRule{
+			Field: model.SegmentField(strings.ToUpper(string(r.Field))),
+			Op:    model.SegmentOperator(strings.ToUpper(string(r.Op))),
+			Value: r.Value,
+		}
+	}
+
+	m := &model.Segment{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		Rules:       rules,
+		Priority:    s.Priority,
+		UserCount:   s.UserCount,
+		EvaluatedAt: s.EvaluatedAt,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.Status != "" {
+		status := model.UserStatus(strings.ToUpper(s.Status))
+		m.Status = &status
+	}
+	return m
+}
+
+// segmentFromInput model.SegmentInput を segment.Segment に変換
+func segmentFromInput(id string, input model.SegmentInput) *segment.Segment {
+	s := &segment.Segment{
+		ID:       id,
+		Name:     input.Name,
+		Rules:    make([]segment.Rule, len(input.Rules)),
+		Status:   firestoreEnum(input.Status),
+		Priority: 100,
+	}
+	if input.Description != nil {
+		s.Description = *input.Description
+	}
+	if input.Priority != nil {
+		s.Priority = *input.Priority
+	}
+	for i, r := range input.Rules {
+		s.Rules[i] = segment.Rule{
+			Field: segment.Field(strings.ToLower(r.Field.String())),
+			Op:    segment.Operator(strings.ToLower(r.Op.String())),
+			Value: r.Value,
+		}
+	}
+	return s
+}
+
+// walletFromSnapshot wallets ドキュメントを model.Wallet に変換
+func walletFromSnapshot(doc *firestore.DocumentSnapshot) *model.Wallet {
+	data := doc.Data()
+
+	// ステータスの変換
+	walletStatus := model.WalletStatusActive // デフォルト
+	if statusStr, ok := data["status"].(string); ok {
+		switch statusStr {
+		case "inactive":
+			walletStatus = model.WalletStatusInactive
+		case "frozen":
+			walletStatus = model.WalletStatusFrozen
+		default:
+			walletStatus = model.WalletStatusActive
+		}
+	}
+
+	wallet := &model.Wallet{
+		WalletAddress: getStringFromData(data, "wallet_address"),
+		UserID:        getStringFromData(data, "user_id"),
+		TenantID:      tenant.Of(data),
+		Balance:       getFloatFromData(data, "balance"),
+		Currency:      getStringFromData(data, "currency"),
+		Status:        walletStatus,
+		CreatedAt:     getTimeFromData(data, "created_at"),
+		UpdatedAt:     getTimeFromData(data, "updated_at"),
+		Version:       optimistic.Version(data),
+		DeletedAt:     getOptionalTimeFromData(data, "deleted_at"),
+		DeletedBy:     getOptionalStringFromData(data, "deleted_by"),
+	}
+
+	// wallet_addressが空の場合は、document IDを使用
+	if wallet.WalletAddress == "" {
+		wallet.WalletAddress = doc.Ref.ID
+	}
+
+	return wallet
+}
+
+// orderFromSnapshot orders ドキュメントを model.Order に変換
+func orderFromSnapshot(doc *firestore.DocumentSnapshot) *model.Order {
+	data := doc.Data()
+
+	status := model.OrderStatus(strings.ToUpper(getStringFromData(data, "status")))
+	if !status.IsValid() {
+		status = model.OrderStatusPending
+	}
+
+	return &model.Order{
+		ID:           doc.Ref.ID,
+		UserID:       getStringFromData(data, "user_id"),
+		TenantID:     tenant.Of(data),
+		OrderNumber:  getStringFromData(data, "order_number"),
+		Status:       status,
+		TotalAmount:  getFloatFromData(data, "total_amount"),
+		Currency:     getStringFromData(data, "currency"),
+		OrderDate:    getTimeFromData(data, "order_date"),
+		DeliveryDate: getOptionalTimeFromData(data, "delivery_date"),
+		Notes:        getOptionalStringFromData(data, "notes"),
+		CreatedAt:    getTimeFromData(data, "created_at"),
+		UpdatedAt:    getTimeFromData(data, "updated_at"),
+		Version:      optimistic.Version(data),
+		DeletedAt:    getOptionalTimeFromData(data, "deleted_at"),
+		DeletedBy:    getOptionalStringFromData(data, "deleted_by"),
+	}
+}
+
+// orderItemFromSnapshot order_items ドキュメントを model.OrderItem に変換
+func orderItemFromSnapshot(doc *firestore.DocumentSnapshot) *model.OrderItem {
+	data := doc.Data()
+
+	return &model.OrderItem{
+		ID:          doc.Ref.ID,
+		OrderID:     getStringFromData(data, "order_id"),
+		ProductName: getStringFromData(data, "product_name"),
+		Quantity:    int(getFloatFromData(data, "quantity")),
+		UnitPrice:   getFloatFromData(data, "unit_price"),
+		TotalPrice:  getFloatFromData(data, "total_price"),
+	}
+}
+
+// interactionFromSnapshot interactions ドキュメントを model.Interaction に変換
+func interactionFromSnapshot(doc *firestore.DocumentSnapshot) *model.Interaction {
+	data := doc.Data()
+
+	typ := model.InteractionType(strings.ToUpper(getStringFromData(data, "type")))
+	if !typ.IsValid() {
+		typ = model.InteractionTypeNote
+	}
+	channel := model.InteractionChannel(strings.ToUpper(getStringFromData(data, "channel")))
+	if !channel.IsValid() {
+		channel = model.InteractionChannelEmail
+	}
+	status := model.InteractionStatus(strings.ToUpper(getStringFromData(data, "status")))
+	if !status.IsValid() {
+		status = model.InteractionStatusPending
+	}
+
+	return &model.Interaction{
+		ID:          doc.Ref.ID,
+		UserID:      getStringFromData(data, "user_id"),
+		TenantID:    tenant.Of(data),
+		Type:        typ,
+		Subject:     getStringFromData(data, "subject"),
+		Content:     getStringFromData(data, "content"),
+		Channel:     channel,
+		Status:      status,
+		AssignedTo:  getOptionalStringFromData(data, "assigned_to"),
+		ScheduledAt: getOptionalTimeFromData(data, "scheduled_at"),
+		CompletedAt: getOptionalTimeFromData(data, "completed_at"),
+		CreatedAt:   getTimeFromData(data, "created_at"),
+		UpdatedAt:   getTimeFromData(data, "updated_at"),
+		Version:     optimistic.Version(data),
+		DeletedAt:   getOptionalTimeFromData(data, "deleted_at"),
+		DeletedBy:   getOptionalStringFromData(data, "deleted_by"),
+	}
+}
+
+// notificationFromSnapshot notifications ドキュメントを model.Notification に変換
+func notificationFromSnapshot(doc *firestore.DocumentSnapshot) *model.Notification {
+	data := doc.Data()
+
+	processed, _ := data["processed"].(bool)
+
+	return &model.Notification{
+		ID:               doc.Ref.ID,
+		NotificationID:   getStringFromData(data, "notification_id"),
+		NotificationType: getStringFromData(data, "notification_type"),
+		BusinessUserID:   getOptionalStringFromData(data, "business_user_id"),
+		TenantID:         tenant.Of(data),
+		Processed:        processed,
+		CreatedAt:        getTimeFromData(data, "created_at"),
+	}
+}
+
+// ヘルパー関数：Firestoreのデータから数値を取得（整数で保存されている場合も含む）
+func getFloatFromData(data map[string]interface{}, key string) float64 {
+	switch v := data[key].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
+
+// ヘルパー関数：Firestoreのデータから文字列の配列を取得
+func getStringsFromData(data map[string]interface{}, key string) []string {
+	values, _ := data[key].([]interface{})
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// ヘルパー関数：Firestoreのデータから任意の文字列を取得（空の場合は nil）
+func getOptionalStringFromData(data map[string]interface{}, key string) *string {
+	if val, ok := data[key].(string); ok && val != "" {
+		return &val
+	}
+	return nil
+}
+
+// ヘルパー関数：Firestoreのデータから日時を取得
+//
+// タイムスタンプ型と RFC3339 形式の文字列に対応し、取得できない場合は現在時刻を返す。
+func getTimeFromData(data map[string]interface{}, key string) time.Time {
+	if t := getOptionalTimeFromData(data, key); t != nil {
+		return *t
+	}
+	return time.Now() // デフォルト値
+}
+
+// ヘルパー関数：Firestoreのデータから任意の日時を取得
+func getOptionalTimeFromData(data map[string]interface{}, key string) *time.Time {
+	switch v := data[key].(type) {
+	case time.Time:
+		return &v
+	case string:
+		// 文字列の場合はパース
+		if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+// importJobToModel importer.Job を model.ImportJob に変換
+func importJobToModel(job *importer.Job) *model.ImportJob {
+	progress := 1.0
+	if job.TotalRows > 0 {
+		progress = float64(job.ProcessedRows) / float64(job.TotalRows)
+	}
+
+	errs := make([]*model.ImportRowError, len(job.Errors))
+	for i, e := range job.Errors {
+		errs[i] = &model.ImportRowError{Line: e.Line, Message: e.Message}
+		if e.Field != "" {
+			errs[i].Field = &e.Field
+		}
+	}
+
+	m := &model.ImportJob{
+		ID:            job.ID,
+		FileName:      job.FileName,
+		Format:        model.ImportFormat(strings.ToUpper(string(job.Format))),
+		Status:        model.ImportJobStatus(strings.ToUpper(string(job.Status))),
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		CreatedCount:  job.CreatedCount,
+		SkippedCount:  job.SkippedCount,
+		FailedCount:   job.FailedCount,
+		Progress:      progress,
+		Errors:        errs,
+		CreatedAt:     job.CreatedAt,
+		UpdatedAt:     job.UpdatedAt,
+		FinishedAt:    job.FinishedAt,
+	}
+	if job.Error != "" {
+		m.Error = &job.Error
+	}
+	if job.CreatedBy != "" {
+		m.CreatedBy = &job.CreatedBy
+	}
+	return m
+}
+
+// organizationToModel tenant.Organization を model.Organization に変換
+func organizationToModel(org *tenant.Organization) *model.Organization {
+	b := org.Settings.Branding
+	return &model.Organization{
+		ID:   org.ID,
+		Name: org.Name,
+		Settings: &model.OrganizationSettings{
+			Currency: org.Settings.Currency,
+			Locale:   org.Settings.Locale,
+			Branding: &model.EmailBranding{
+				ProductName:  b.ProductName,
+				SenderName:   b.SenderName,
+				SupportEmail: optionalString(b.SupportEmail),
+				LogoURL:      optionalString(b.LogoURL),
+				PrimaryColor: optionalString(b.PrimaryColor),
+				FooterText:   optionalString(b.FooterText),
+			},
+		},
+		CreatedAt: org.CreatedAt,
+		UpdatedAt: org.UpdatedAt,
+	}
+}
+
+// applySettingsInput 指定された設定の項目だけを変更する
+func applySettingsInput(s *tenant.Settings, input *model.OrganizationSettingsInput) {
+	if input == nil {
+		return
+	}
+	setString(&s.Currency, input.Currency)
+	setString(&s.Locale, input.Locale)
+	if b := input.Branding; b != nil {
+		setString(&s.Branding.ProductName, b.ProductName)
+		setString(&s.Branding.SenderName, b.SenderName)
+		setString(&s.Branding.SupportEmail, b.SupportEmail)
+		setString(&s.Branding.LogoURL, b.LogoURL)
+		setString(&s.Branding.PrimaryColor, b.PrimaryColor)
+		setString(&s.Branding.FooterText, b.FooterText)
+	}
+}
+
+// setString v が指定されている場合だけ dst に設定する
+func setString(dst *string, v *string) {
+	if v != nil {
+		*dst = strings.TrimSpace(*v)
+	}
+}
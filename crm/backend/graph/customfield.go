@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"strings"
+
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/customfield"
+	"narratives-crm-backend/graph/model"
+)
+
+// maxCustomFieldScan タグ・カスタム項目で絞り込む顧客一覧で読み取る顧客の上限
+const maxCustomFieldScan = 5000
+
+// customFieldToModel customfield.Field を model.CustomField に変換
+func customFieldToModel(f *customfield.Field) *model.CustomField {
+	options := f.Options
+	if options == nil {
+		options = []string{}
+	}
+	return &model.CustomField{
+		ID:        f.ID,
+		Key:       f.Key,
+		Label:     f.Label,
+		Type:      model.CustomFieldType(strings.ToUpper(string(f.Type))),
+		Options:   options,
+		Position:  f.Position,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
+
+// customFieldFromInput model.CustomFieldInput を customfield.Field に変換
+func customFieldFromInput(input model.CustomFieldInput) *customfield.Field {
+	f := &customfield.Field{
+		Key:      input.Key,
+		Label:    input.Label,
+		Type:     customfield.Type(strings.ToLower(input.Type.String())),
+		Options:  input.Options,
+		Position: 100,
+	}
+	if input.Position != nil {
+		f.Position = *input.Position
+	}
+	return f
+}
+
+// customFieldValuesFromData users.custom_fields を model.CustomFieldValue に変換
+func customFieldValuesFromData(data map[string]interface{}) []*model.CustomFieldValue {
+	values := customfield.Values(data)
+	result := make([]*model.CustomFieldValue, len(values))
+	for i, v := range values {
+		result[i] = &model.CustomFieldValue{Key: v.Key, Text: v.Text, Number: v.Number, Date: v.Date, Options: v.Options}
+	}
+	return result
+}
+
+// customFieldValuesFromInput model.CustomFieldValueInput を customfield.Value に変換
+func customFieldValuesFromInput(inputs []*model.CustomFieldValueInput) []customfield.Value {
+	values := make([]customfield.Value, len(inputs))
+	for i, in := range inputs {
+		values[i] = customfield.Value{Key: in.Key, Text: in.Text, Number: in.Number, Date: in.Date, Options: in.Options}
+	}
+	return values
+}
+
+// customFieldQuery users クエリのタグ・カスタム項目の条件を customfield.Query に変換
+func customFieldQuery(tags []string, filters []*model.CustomFieldFilterInput, sort *model.CustomFieldSortInput) customfield.Query {
+	q := customfield.Query{Tags: tags}
+	for _, f := range filters {
+		q.Filters = append(q.Filters, customfield.Filter{
+			Op:    customfield.Operator(strings.ToLower(f.Op.String())),
+			Value: customfield.Value{Key: f.Key, Text: f.Text, Number: f.Number, Date: f.Date, Options: f.Options},
+		})
+	}
+	if sort != nil {
+		q.Sort = &customfield.Sort{Key: sort.Key, Desc: sort.Order != nil && *sort.Order == model.SortOrderDesc}
+	}
+	return q
+}
+
+// usersFromSnapshots users ドキュメントを model.User に変換
+func usersFromSnapshots(docs []*firestore.DocumentSnapshot) []*model.User {
+	users := make([]*model.User, len(docs))
+	for i, doc := range docs {
+		users[i] = userFromSnapshot(doc)
+	}
+	return users
+}
+
+// userTags 顧客に保存するタグ（空白を除き、重複を除く）
+func userTags(tags []string) ([]string, error) {
+	normalized, err := customfield.NormalizeTags(tags)
+	if err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,176 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/pubsub"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// listenerRetryInterval Firestore のリスナーが切断された場合の再接続間隔
+	listenerRetryInterval = 5 * time.Second
+	// listenerRefreshInterval 監視の開始日時を進めるためにリスナーを張り直す間隔
+	listenerRefreshInterval = time.Hour
+)
+
+// Events サブスクリプションに配信するイベント
+//
+// ミューテーションからの Publish と Firestore の変更リスナーの両方から送られる。
+// 同じ変更は dedup キー（ドキュメントID と更新時刻）で1回だけ配信される。
+type Events struct {
+	OrderStatusChanged  *pubsub.Bus[*model.Order]
+	InteractionAssigned *pubsub.Bus[*model.Interaction]
+	NotificationCreated *pubsub.Bus[*model.Notification]
+}
+
+// NewEvents Events のコンストラクタ
+func NewEvents() *Events {
+	return &Events{
+		OrderStatusChanged:  pubsub.NewBus[*model.Order]("orderStatusChanged"),
+		InteractionAssigned: pubsub.NewBus[*model.Interaction]("interactionAssigned"),
+		NotificationCreated: pubsub.NewBus[*model.Notification]("notificationCreated"),
+	}
+}
+
+// publishOrderStatusChanged 注文ステータスの変更を配信
+func (e *Events) publishOrderStatusChanged(order *model.Order, changedAt time.Time) {
+	if e == nil {
+		return
+	}
+	e.OrderStatusChanged.Publish(eventKey(order.ID, changedAt), order)
+}
+
+// publishInteractionAssigned インタラクションの担当者割り当てを配信
+func (e *Events) publishInteractionAssigned(interaction *model.Interaction, assignedAt time.Time) {
+	if e == nil {
+		return
+	}
+	e.InteractionAssigned.Publish(eventKey(interaction.ID, assignedAt), interaction)
+}
+
+// publishNotificationCreated 通知の作成を配信
+func (e *Events) publishNotificationCreated(notification *model.Notification) {
+	if e == nil {
+		return
+	}
+	e.NotificationCreated.Publish(notification.ID, notification)
+}
+
+// eventKey 重複除外のキー。Firestore のタイムスタンプはマイクロ秒精度のため丸めて比較する
+func eventKey(id string, at time.Time) string {
+	return fmt.Sprintf("%s@%d", id, at.UnixMicro())
+}
+
+// Listen Firestore の変更を監視してイベントを配信する（ctx が終了するまでブロック）
+//
+// 他のサービスやフロントエンドからの書き込みも配信するため、以下のフィールドを監視する。
+// 対象のフィールドが変わらない更新（メモの編集など）は配信しない。
+//   - orders.status_changed_at       ステータスを変更したときに更新する
+//   - interactions.assigned_at       担当者を割り当てたときに更新する
+//   - notifications.created_at       作成日時
+func (e *Events) Listen(ctx context.Context, client *firestore.Client) {
+	since := time.Now()
+
+	var wg sync.WaitGroup
+	listen := func(collection, field string, handle func(*firestore.DocumentSnapshot, time.Time)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listenQuery(ctx, client.Collection(collection), field, since, handle)
+		}()
+	}
+
+	listen("orders", "status_changed_at", func(doc *firestore.DocumentSnapshot, changedAt time.Time) {
+		e.publishOrderStatusChanged(orderFromSnapshot(doc), changedAt)
+	})
+	listen("interactions", "assigned_at", func(doc *firestore.DocumentSnapshot, assignedAt time.Time) {
+		e.publishInteractionAssigned(interactionFromSnapshot(doc), assignedAt)
+	})
+	listen("notifications", "created_at", func(doc *firestore.DocumentSnapshot, _ time.Time) {
+		e.publishNotificationCreated(notificationFromSnapshot(doc))
+	})
+
+	wg.Wait()
+}
+
+// listenQuery collection の field が since 以降のドキュメントを監視し、field の日時が変わったドキュメントを handle に渡す
+//
+// エラーで切断された場合は listenerRetryInterval 後に再接続し、エラーがなくても listenerRefreshInterval ごとに張り直す。
+// 再接続のたびに監視の開始日時を最後に配信した日時まで進め、それより古い重複除外の記録を捨てる
+// （監視の対象と記録がプロセスの起動からの変更すべてに増え続けないようにする）。
+func listenQuery(ctx context.Context, collection *firestore.CollectionRef, field string, since time.Time, handle func(*firestore.DocumentSnapshot, time.Time)) {
+	// 再接続時の初回スナップショットで同じ変更を再配信しないよう、最後に見た日時を保持する
+	seen := make(map[string]time.Time)
+
+	for {
+		listenCtx, cancel := context.WithTimeout(ctx, listenerRefreshInterval)
+		it := collection.Where(field, ">=", since).Snapshots(listenCtx)
+		err := consumeSnapshots(it, func(doc *firestore.DocumentSnapshot) {
+			at, ok := doc.Data()[field].(time.Time)
+			if !ok || seen[doc.Ref.ID].Equal(at) {
+				return
+			}
+			seen[doc.Ref.ID] = at
+			if at.After(since) {
+				since = at
+			}
+			handle(doc, at)
+		})
+		it.Stop()
+		refresh := listenCtx.Err() != nil
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		// since と同じ日時の記録は、次の接続の初回スナップショットで再配信しないよう残す
+		for id, at := range seen {
+			if at.Before(since) {
+				delete(seen, id)
+			}
+		}
+		if refresh {
+			continue
+		}
+
+		slog.Error("Firestore listener stopped, retrying",
+			slog.String("collection", collection.ID), slog.Any("error", err), slog.Duration("retry_in", listenerRetryInterval))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenerRetryInterval):
+		}
+	}
+}
+
+func consumeSnapshots(it *firestore.QuerySnapshotIterator, handle func(*firestore.DocumentSnapshot)) error {
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			if errors.Is(err, iterator.Done) {
+				return nil
+			}
+			return err
+		}
+		for _, change := range snap.Changes {
+			if change.Kind == firestore.DocumentRemoved {
+				continue
+			}
+			handle(change.Doc)
+		}
+	}
+}
+
+// matches サブスクリプションの絞り込み条件（未指定の場合は全件）
+func matches(want *string, got string) bool {
+	return want == nil || *want == got
+}
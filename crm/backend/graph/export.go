@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/export"
+	"narratives-crm-backend/graph/model"
+	"strings"
+)
+
+// runExport エクスポートを実行して model.ExportResult に変換（管理者だけが実行できる）
+func (r *Resolver) runExport(ctx context.Context, dataset *export.Dataset, format model.ExportFormat, filter export.Filter) (*model.ExportResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if r.Exporter == nil {
+		return nil, apperr.New(apperr.CodeInternal, "export is not configured")
+	}
+
+	exportFormat, err := export.ParseFormat(format.String())
+	if err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+
+	result, err := r.Exporter.Export(ctx, export.Request{Dataset: dataset, Format: exportFormat, Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ExportResult{
+		Dataset:   result.Dataset,
+		Format:    format,
+		FileName:  result.FileName,
+		URL:       result.Location,
+		ExpiresAt: result.ExpiresAt,
+		Rows:      result.Rows,
+	}, nil
+}
+
+// firestoreEnum GraphQL の列挙値を Firestore に保存している小文字の値に変換（未指定の場合は空文字）
+func firestoreEnum[T fmt.Stringer](v *T) string {
+	if v == nil {
+		return ""
+	}
+	return strings.ToLower((*v).String())
+}
+
+// stringValue 任意の文字列引数（未指定の場合は空文字）
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
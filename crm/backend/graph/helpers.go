@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/firestore"
+)
+
+type ServiceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+func readServiceAccountKey(filePath string) (*ServiceAccountKey, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account file: %v", err)
+	}
+
+	var key ServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse service account JSON: %v", err)
+	}
+
+	return &key, nil
+}
+
+// ヘルパー関数：Firestoreのデータから安全に文字列を取得
+func getStringFromData(data map[string]interface{}, key string) string {
+	if val, ok := data[key].(string); ok {
+		return val
+	}
+	return ""
+}
+
+// appendUpdate 任意の入力項目が指定されている場合だけ更新に加える
+func appendUpdate[T any](updates []firestore.Update, path string, v *T) []firestore.Update {
+	if v == nil {
+		return updates
+	}
+	return append(updates, firestore.Update{Path: path, Value: *v})
+}
@@ -0,0 +1,852 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/audit"
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/graph"
+	"narratives-crm-backend/graph/generated"
+	"narratives-crm-backend/services"
+	"narratives-crm-backend/tenant"
+	"narratives-crm-backend/testenv"
+	"narratives-crm-backend/validate"
+)
+
+// newClient main.go と同じ resolver・エラー表示・拡張・ミドルウェアでスキーマを組み立てた GraphQL クライアント
+//
+// リクエストは既定の組織の管理者として送る（signInAs などのオプションで呼び出し元を変えられる）。
+func newClient(t *testing.T, env *testenv.Env) *client.Client {
+	t.Helper()
+
+	resolver := &graph.Resolver{
+		FirebaseApp:     env.App,
+		AuthClient:      env.Auth,
+		FirestoreClient: env.Firestore,
+		Auth:            services.NewFirebaseAuthService(env.Auth, env.Firestore),
+		Events:          graph.NewEvents(),
+	}
+
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: resolver, Complexity: graph.Complexity()}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(apperr.ErrorPresenter(false))
+	srv.SetRecoverFunc(apperr.RecoverFunc)
+	srv.Use(tenant.GraphQLExtension{})
+	srv.Use(validate.GraphQLExtension{})
+	srv.Use(audit.GraphQLExtension{Client: env.Firestore, Entities: graph.AuditEntities})
+
+	h := graph.LoaderMiddleware(env.Firestore)(srv)
+	h = authn.Middleware(env.Auth)(h)
+	return client.New(h, signInAs(t, env, "default-admin@example.com", "admin"))
+}
+
+// anonymous Authorization ヘッダーを付けずに送る
+func anonymous(r *client.Request) {
+	r.HTTP.Header.Del("Authorization")
+}
+
+// signInAs 既定の組織で role をカスタムクレームに持つユーザーでサインインし、Authorization ヘッダーを返す
+func signInAs(t *testing.T, env *testenv.Env, email, role string) client.Option {
+	t.Helper()
+	return signInWithClaims(t, env, email, map[string]interface{}{"role": role, "tenant_id": tenant.DefaultID})
+}
+
+// signInWithClaims claims をカスタムクレームに持つユーザーでサインインし、Authorization ヘッダーを返す
+func signInWithClaims(t *testing.T, env *testenv.Env, email string, claims map[string]interface{}) client.Option {
+	t.Helper()
+
+	const password = "password123"
+	env.CreateUser(t, email, password, claims)
+	token := env.SignIn(t, email, password)
+	return func(r *client.Request) {
+		r.HTTP.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// errorCodes レスポンスのエラーの extensions.code
+func errorCodes(t *testing.T, resp *client.Response) []string {
+	t.Helper()
+
+	if len(resp.Errors) == 0 {
+		return nil
+	}
+	var errs []struct {
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(resp.Errors, &errs); err != nil {
+		t.Fatalf("failed to decode errors %s: %v", resp.Errors, err)
+	}
+	codes := make([]string, len(errs))
+	for i, e := range errs {
+		codes[i] = e.Extensions.Code
+	}
+	return codes
+}
+
+func customer(firstName, status string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"first_name":          firstName,
+		"last_name":           "テスト",
+		"first_name_katakana": "テスト",
+		"last_name_katakana":  "テスト",
+		"email_address":       firstName + "@example.com",
+		"role":                "user",
+		"status":              status,
+		"balance":             0.0,
+		"tenant_id":           tenant.DefaultID,
+		"created_at":          now,
+		"updated_at":          now,
+	}
+}
+
+func TestUsersQuery(t *testing.T) {
+	env := testenv.New(t)
+	deleted := customer("Jiro", "active")
+	deleted["deleted_at"] = time.Now()
+	env.Seed(t, testenv.Fixtures{
+		"users": {
+			"u1": customer("Taro", "active"),
+			"u2": customer("Hanako", "hot"),
+			"u3": deleted,
+		},
+	})
+	c := newClient(t, env)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "all active users", query: `{ users { users { user_id } } }`, want: []string{"u1", "u2"}},
+		{name: "status filter", query: `{ users(status: HOT) { users { user_id } } }`, want: []string{"u2"}},
+		{name: "prefix search", query: `{ users(search: "Ta") { users { user_id } } }`, want: []string{"u1"}},
+		{name: "deleted users are excluded", query: `{ users(search: "Ji") { users { user_id } } }`, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				Users struct {
+					Users []struct {
+						UserID string `json:"user_id"`
+					} `json:"users"`
+				} `json:"users"`
+			}
+			if err := c.Post(tt.query, &resp); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			got := make([]string, 0, len(resp.Users.Users))
+			for _, u := range resp.Users.Users {
+				got = append(got, u.UserID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("users = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginationLimit(t *testing.T) {
+	env := testenv.New(t)
+	env.Seed(t, testenv.Fixtures{
+		"users": {
+			"u1": customer("Taro", "active"),
+			"u2": customer("Hanako", "active"),
+		},
+	})
+	c := newClient(t, env)
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantCode string
+		wantLen  int
+	}{
+		{name: "zero is rejected instead of returning every user", limit: 0, wantCode: string(apperr.CodeValidation)},
+		{name: "negative", limit: -1, wantCode: string(apperr.CodeValidation)},
+		{name: "over the maximum", limit: 101, wantCode: string(apperr.CodeValidation)},
+		{name: "within range", limit: 1, wantLen: 1},
+		{name: "maximum", limit: 100, wantLen: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := c.RawPost(`query($limit: Int) { users(pagination: { limit: $limit }) { users { user_id } } }`, client.Var("limit", tt.limit))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, resp)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", resp.Errors)
+			}
+			var data struct {
+				Users struct {
+					Users []struct {
+						UserID string `json:"user_id"`
+					} `json:"users"`
+				} `json:"users"`
+			}
+			if err := remarshal(resp.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Users.Users) != tt.wantLen {
+				t.Errorf("len(users) = %d, want %d", len(data.Users.Users), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestUpdateUserVersion(t *testing.T) {
+	env := testenv.New(t)
+	current := customer("Taro", "active")
+	current["version"] = 3
+	env.Seed(t, testenv.Fixtures{"users": {"u1": current}})
+	c := newClient(t, env)
+
+	const mutation = `mutation($id: ID!, $version: Int!) {
+		updateUser(user_id: $id, input: { first_name: "Ichiro", version: $version }) { first_name version }
+	}`
+	tests := []struct {
+		name        string
+		id          string
+		version     int
+		wantCode    string
+		wantVersion int
+	}{
+		{name: "stale version is a conflict", id: "u1", version: 2, wantCode: string(apperr.CodeConflict)},
+		{name: "current version is applied", id: "u1", version: 3, wantVersion: 4},
+		{name: "the same version cannot be applied twice", id: "u1", version: 3, wantCode: string(apperr.CodeConflict)},
+		{name: "missing user", id: "missing", version: 0, wantCode: string(apperr.CodeNotFound)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := c.RawPost(mutation, client.Var("id", tt.id), client.Var("version", tt.version))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, resp)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", resp.Errors)
+			}
+			if got := env.Doc(t, "users", tt.id)["version"]; got != int64(tt.wantVersion) {
+				t.Errorf("version = %v, want %d", got, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	env := testenv.New(t)
+	env.Seed(t, testenv.Fixtures{
+		"users":   {"u1": customer("Taro", "active")},
+		"wallets": {"w1": {"user_id": "u1", "balance": 0.0, "currency": "JPY", "status": "active", "created_at": time.Now()}},
+	})
+	c := newClient(t, env)
+
+	resp, err := c.RawPost(`mutation { deleteUser(user_id: "u1") }`, anonymous)
+	if err != nil {
+		t.Fatalf("deleteUser failed: %v", err)
+	}
+	if codes := errorCodes(t, resp); !slices.Equal(codes, []string{string(apperr.CodeUnauthenticated)}) {
+		t.Fatalf("anonymous deleteUser error codes = %v, want [%s]", codes, apperr.CodeUnauthenticated)
+	}
+
+	var deleted struct {
+		DeleteUser bool `json:"deleteUser"`
+	}
+	if err := c.Post(`mutation { deleteUser(user_id: "u1") }`, &deleted); err != nil {
+		t.Fatalf("deleteUser failed: %v", err)
+	}
+	if wallet := env.Doc(t, "wallets", "w1"); wallet["deleted_with"] != "users/u1" {
+		t.Errorf("wallet deleted_with = %v, want users/u1", wallet["deleted_with"])
+	}
+
+	var restored struct {
+		RestoreUser struct {
+			UserID    string  `json:"user_id"`
+			DeletedAt *string `json:"deleted_at"`
+		} `json:"restoreUser"`
+	}
+	if err := c.Post(`mutation { restoreUser(user_id: "u1") { user_id deleted_at } }`, &restored); err != nil {
+		t.Fatalf("restoreUser failed: %v", err)
+	}
+	if restored.RestoreUser.DeletedAt != nil {
+		t.Errorf("restored user still has deleted_at %v", restored.RestoreUser.DeletedAt)
+	}
+	if wallet := env.Doc(t, "wallets", "w1"); wallet["deleted_at"] != nil {
+		t.Errorf("wallet was not restored with its user: %v", wallet)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	env := testenv.New(t)
+	c := newClient(t, env)
+
+	const mutation = `mutation($email: String!) {
+		createUser(input: {
+			first_name: "太郎", last_name: "山田",
+			first_name_katakana: "タロウ", last_name_katakana: "ヤマダ",
+			email_address: $email, status: HOT
+		}) { user_id tenant_id status }
+	}`
+	tests := []struct {
+		name     string
+		email    string
+		wantCode string
+	}{
+		{name: "new customer", email: "taro@example.com"},
+		{name: "duplicate email", email: "Taro@example.com", wantCode: string(apperr.CodeConflict)},
+		{name: "invalid email", email: "not-an-email", wantCode: string(apperr.CodeValidation)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				CreateUser struct {
+					UserID   string `json:"user_id"`
+					TenantID string `json:"tenant_id"`
+					Status   string `json:"status"`
+				} `json:"createUser"`
+			}
+			raw, err := c.RawPost(mutation, client.Var("email", tt.email))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, raw)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", raw.Errors)
+			}
+			if err := remarshal(raw.Data, &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			// 顧客は CRM の連絡先で、Firebase Auth のアカウントは作成しない
+			user := env.Doc(t, "users", resp.CreateUser.UserID)
+			if user == nil || user["status"] != "hot" || user["tenant_id"] != tenant.DefaultID {
+				t.Errorf("users document = %v", user)
+			}
+			if _, err := env.Auth.GetUserByEmail(t.Context(), tt.email); err == nil {
+				t.Error("an auth account was created for a customer")
+			}
+			if docs := env.Docs(t, "business_users"); len(docs) != 0 {
+				t.Errorf("business_users = %v, want none", docs)
+			}
+		})
+	}
+}
+
+func TestStaffManagement(t *testing.T) {
+	env := testenv.New(t)
+	c := newClient(t, env)
+
+	admin := signInAs(t, env, "admin@example.com", "admin")
+	staff := signInAs(t, env, "staff@example.com", "user")
+
+	const invite = `mutation($email: String!, $role: StaffRole) {
+		inviteStaff(input: { first_name: "太郎", last_name: "山田", email_address: $email, role: $role }) { id role }
+	}`
+	var invited struct {
+		InviteStaff struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		} `json:"inviteStaff"`
+	}
+	if err := c.Post(invite, &invited, admin, client.Var("email", "taro@example.com"), client.Var("role", "MODERATOR")); err != nil {
+		t.Fatalf("inviteStaff failed: %v", err)
+	}
+	uid := invited.InviteStaff.ID
+	if user, err := env.Auth.GetUser(t.Context(), uid); err != nil || user.CustomClaims["role"] != "moderator" {
+		t.Fatalf("auth user = %v, %v; want role claim moderator", user, err)
+	}
+	var welcome int
+	for _, n := range env.Docs(t, "notifications") {
+		if n["business_user_id"] == uid && n["notification_type"] == "welcome_email" {
+			welcome++
+		}
+	}
+	if welcome != 1 {
+		t.Errorf("welcome_email notifications = %d, want 1", welcome)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		opts     []client.Option
+		wantCode string
+	}{
+		{name: "staff cannot invite", query: invite, opts: []client.Option{staff, client.Var("email", "jiro@example.com")}, wantCode: string(apperr.CodeForbidden)},
+		{name: "admin cannot grant super admin", query: invite, opts: []client.Option{admin, client.Var("email", "jiro@example.com"), client.Var("role", "SUPER_ADMIN")}, wantCode: string(apperr.CodeForbidden)},
+		{name: "duplicate email", query: invite, opts: []client.Option{admin, client.Var("email", "taro@example.com")}, wantCode: string(apperr.CodeConflict)},
+		{name: "change role", query: `mutation($id: ID!) { changeStaffRole(id: $id, role: ADMIN) { role } }`, opts: []client.Option{admin, client.Var("id", uid)}},
+		{name: "disable", query: `mutation($id: ID!) { disableStaff(id: $id) { disabled } }`, opts: []client.Option{admin, client.Var("id", uid)}},
+		{name: "missing staff", query: `mutation { disableStaff(id: "missing") { id } }`, opts: []client.Option{admin}, wantCode: string(apperr.CodeNotFound)},
+		{name: "staff cannot list", query: `{ listStaff { id } }`, opts: []client.Option{staff}, wantCode: string(apperr.CodeForbidden)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := c.RawPost(tt.query, tt.opts...)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, raw)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", raw.Errors)
+			}
+		})
+	}
+
+	user, err := env.Auth.GetUser(t.Context(), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Disabled || user.CustomClaims["role"] != "admin" || user.CustomClaims["tenant_id"] != tenant.DefaultID {
+		t.Errorf("auth user disabled = %v, claims = %v", user.Disabled, user.CustomClaims)
+	}
+
+	var listed struct {
+		ListStaff []struct {
+			ID string `json:"id"`
+		} `json:"listStaff"`
+	}
+	if err := c.Post(`{ listStaff { id } }`, &listed, admin); err != nil {
+		t.Fatalf("listStaff failed: %v", err)
+	}
+	if len(listed.ListStaff) != 0 {
+		t.Errorf("listStaff = %v, want disabled staff to be excluded", listed.ListStaff)
+	}
+	if err := c.Post(`{ listStaff(includeDisabled: true) { id } }`, &listed, admin); err != nil {
+		t.Fatalf("listStaff failed: %v", err)
+	}
+	if len(listed.ListStaff) != 1 || listed.ListStaff[0].ID != uid {
+		t.Errorf("listStaff(includeDisabled) = %v, want [%s]", listed.ListStaff, uid)
+	}
+}
+
+func TestAuditLogs(t *testing.T) {
+	env := testenv.New(t)
+	env.Seed(t, testenv.Fixtures{"users": {"u1": customer("Taro", "active")}})
+	c := newClient(t, env)
+
+	admin := signInAs(t, env, "admin@example.com", "admin")
+	staff := signInAs(t, env, "staff@example.com", "user")
+
+	var updated struct {
+		UpdateUser struct {
+			Version int `json:"version"`
+		} `json:"updateUser"`
+	}
+	if err := c.Post(`mutation { updateUser(user_id: "u1", input: { first_name: "Ichiro", version: 0 }) { version } }`, &updated, admin); err != nil {
+		t.Fatalf("updateUser failed: %v", err)
+	}
+
+	const query = `{ auditLogs(entity_type: "user", entity_id: "u1") { operation actor_email changes { field } } }`
+	tests := []struct {
+		name     string
+		options  []client.Option
+		wantCode string
+	}{
+		{name: "unauthenticated", wantCode: string(apperr.CodeUnauthenticated)},
+		{name: "non-admin", options: []client.Option{staff}, wantCode: string(apperr.CodeForbidden)},
+		{name: "admin", options: []client.Option{admin}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := c.RawPost(query, tt.options...)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, raw)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", raw.Errors)
+			}
+
+			var resp struct {
+				AuditLogs []struct {
+					Operation  string  `json:"operation"`
+					ActorEmail *string `json:"actor_email"`
+					Changes    []struct {
+						Field string `json:"field"`
+					} `json:"changes"`
+				} `json:"auditLogs"`
+			}
+			if err := remarshal(raw.Data, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.AuditLogs) != 1 {
+				t.Fatalf("audit logs = %d, want 1", len(resp.AuditLogs))
+			}
+			entry := resp.AuditLogs[0]
+			if entry.Operation != "updateUser" || entry.ActorEmail == nil || *entry.ActorEmail != "admin@example.com" {
+				t.Errorf("unexpected audit log: %+v", entry)
+			}
+			var changedName bool
+			for _, change := range entry.Changes {
+				changedName = changedName || change.Field == "first_name"
+			}
+			if !changedName {
+				t.Errorf("first_name change was not recorded: %+v", entry.Changes)
+			}
+		})
+	}
+}
+
+func TestLegacyAuditLogs(t *testing.T) {
+	env := testenv.New(t)
+	env.Seed(t, testenv.Fixtures{
+		"audit_logs": {
+			// マルチテナント化より前に記録された tenant_id フィールドのない監査ログ
+			"legacy": {"operation": "updateUser", "entity_type": "user", "entity_id": "u1", "arguments": map[string]interface{}{}, "created_at": time.Now()},
+		},
+	})
+	c := newClient(t, env)
+
+	superAdmin := signInAs(t, env, "root@example.com", tenant.RoleSuperAdmin)
+
+	const query = `{ auditLogs(entity_type: "user", entity_id: "u1") { id tenant_id } }`
+	tests := []struct {
+		name string
+		opts []client.Option
+		want []string
+	}{
+		{name: "tenant admins do not see legacy logs", want: []string{}},
+		{name: "super admin sees legacy logs in the default organization", opts: []client.Option{superAdmin}, want: []string{tenant.DefaultID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				AuditLogs []struct {
+					ID       string  `json:"id"`
+					TenantID *string `json:"tenant_id"`
+				} `json:"auditLogs"`
+			}
+			if err := c.Post(query, &resp, tt.opts...); err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			got := make([]string, 0, len(resp.AuditLogs))
+			for _, e := range resp.AuditLogs {
+				if e.TenantID == nil {
+					t.Fatalf("audit log %s has no tenant_id", e.ID)
+				}
+				got = append(got, *e.TenantID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tenant_id = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// remarshal RawPost の data を構造体に変換する
+func remarshal(data interface{}, v interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	env := testenv.New(t)
+	acmeUser := customer("Jiro", "active")
+	acmeUser["tenant_id"] = "acme"
+	env.Seed(t, testenv.Fixtures{
+		"organizations": {"acme": {"tenant_id": "acme", "name": "Acme", "created_at": time.Now()}},
+		"users":         {"u1": customer("Taro", "active"), "u2": acmeUser},
+	})
+	c := newClient(t, env)
+
+	acmeAdmin := signInWithClaims(t, env, "admin@acme.example.com", map[string]interface{}{"role": "admin", "tenant_id": "acme"})
+	superAdmin := signInAs(t, env, "root@example.com", tenant.RoleSuperAdmin)
+	legacyStaff := signInWithClaims(t, env, "legacy@example.com", map[string]interface{}{"role": "admin"})
+
+	const users = `{ users { users { user_id tenant_id } } }`
+	const update = `mutation { updateUser(user_id: "u1", input: { first_name: "Ichiro", version: 0 }) { user_id } }`
+	const create = `mutation {
+		createUser(input: {
+			first_name: "太郎", last_name: "山田", first_name_katakana: "タロウ", last_name_katakana: "ヤマダ",
+			email_address: "taro@example.com"
+		}) { user_id }
+	}`
+	tests := []struct {
+		name      string
+		query     string
+		opts      []client.Option
+		wantUsers []string
+		wantCode  string
+	}{
+		{name: "tenant admin sees only their organization", query: users, opts: []client.Option{acmeAdmin}, wantUsers: []string{"u2"}},
+		{name: "default organization", query: users, wantUsers: []string{"u1"}},
+		{name: "anonymous callers see no organization", query: users, opts: []client.Option{anonymous}, wantUsers: []string{}},
+		{name: "callers without a tenant claim see no organization", query: users, opts: []client.Option{legacyStaff}, wantUsers: []string{}},
+		{name: "anonymous callers cannot create customers", query: create, opts: []client.Option{anonymous}, wantCode: string(apperr.CodeForbidden)},
+		{name: "other organizations cannot be selected", query: users, opts: []client.Option{acmeAdmin, client.AddHeader(tenant.HeaderTenantID, tenant.DefaultID)}, wantCode: string(apperr.CodeForbidden)},
+		{name: "super admin sees every organization", query: users, opts: []client.Option{superAdmin}, wantUsers: []string{"u1", "u2"}},
+		{name: "super admin selects an organization", query: users, opts: []client.Option{superAdmin, client.AddHeader(tenant.HeaderTenantID, "acme")}, wantUsers: []string{"u2"}},
+		{name: "documents of other organizations are not found", query: update, opts: []client.Option{acmeAdmin}, wantCode: string(apperr.CodeNotFound)},
+		{name: "organizations requires a super admin", query: `{ organizations { id } }`, opts: []client.Option{acmeAdmin}, wantCode: string(apperr.CodeForbidden)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := c.RawPost(tt.query, tt.opts...)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, raw)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", raw.Errors)
+			}
+
+			var resp struct {
+				Users struct {
+					Users []struct {
+						UserID string `json:"user_id"`
+					} `json:"users"`
+				} `json:"users"`
+			}
+			if err := remarshal(raw.Data, &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(resp.Users.Users))
+			for _, u := range resp.Users.Users {
+				got = append(got, u.UserID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.wantUsers) {
+				t.Errorf("users = %v, want %v", got, tt.wantUsers)
+			}
+		})
+	}
+}
+
+func TestCustomFieldsAndTags(t *testing.T) {
+	env := testenv.New(t)
+	c := newClient(t, env)
+	admin := signInAs(t, env, "admin@example.com", "admin")
+	staff := signInAs(t, env, "staff@example.com", "user")
+
+	const save = `mutation($input: CustomFieldInput!) { saveCustomField(input: $input) { id key type options } }`
+	raw, err := c.RawPost(save, staff, client.Var("input", map[string]interface{}{"key": "plan", "label": "プラン", "type": "ENUM", "options": []string{"free", "pro"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codes := errorCodes(t, raw); len(codes) != 1 || codes[0] != string(apperr.CodeForbidden) {
+		t.Fatalf("saveCustomField as staff: error codes = %v, want [FORBIDDEN]", codes)
+	}
+	for _, input := range []map[string]interface{}{
+		{"key": "plan", "label": "プラン", "type": "ENUM", "options": []string{"free", "pro"}},
+		{"key": "score", "label": "スコア", "type": "NUMBER"},
+		{"key": "renewal", "label": "更新日", "type": "DATE"},
+		{"key": "interests", "label": "興味", "type": "MULTI_SELECT", "options": []string{"golf", "wine", "travel"}},
+	} {
+		if err := c.Post(save, &struct{}{}, admin, client.Var("input", input)); err != nil {
+			t.Fatalf("saveCustomField(%v) failed: %v", input["key"], err)
+		}
+	}
+
+	u1 := customer("Taro", "active")
+	u1["custom_fields"] = map[string]interface{}{"plan": "pro", "score": 80.0, "interests": []interface{}{"golf", "wine"}}
+	u1["tags"] = []interface{}{"vip", "tokyo"}
+	u2 := customer("Hanako", "active")
+	u2["custom_fields"] = map[string]interface{}{"plan": "free", "score": 30.0, "renewal": time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)}
+	u2["tags"] = []interface{}{"tokyo"}
+	u3 := customer("Jiro", "active")
+	u3["custom_fields"] = map[string]interface{}{"plan": "pro", "interests": []interface{}{"travel"}}
+	u3["tags"] = []interface{}{"vip"}
+	env.Seed(t, testenv.Fixtures{"users": {"u1": u1, "u2": u2, "u3": u3}})
+
+	tests := []struct {
+		name     string
+		args     string
+		want     []string
+		ordered  bool
+		wantCode string
+	}{
+		{name: "tags", args: `tags: ["vip"]`, want: []string{"u1", "u3"}},
+		{name: "all tags", args: `tags: ["vip", "tokyo"]`, want: []string{"u1"}},
+		{name: "enum", args: `customFields: [{ key: "plan", op: EQ, text: "pro" }]`, want: []string{"u1", "u3"}},
+		{name: "number range", args: `customFields: [{ key: "score", op: GTE, number: 50 }]`, want: []string{"u1"}},
+		{name: "date", args: `customFields: [{ key: "renewal", op: LT, date: "2026-05-01T00:00:00Z" }]`, want: []string{"u2"}},
+		{name: "multi-select any", args: `customFields: [{ key: "interests", op: ANY, options: ["wine", "travel"] }]`, want: []string{"u1", "u3"}},
+		{name: "multi-select all", args: `customFields: [{ key: "interests", op: ALL, options: ["golf", "wine"] }]`, want: []string{"u1"}},
+		{name: "unset", args: `customFields: [{ key: "score", op: UNSET }]`, want: []string{"u3"}},
+		{name: "filters and tags", args: `tags: ["tokyo"], customFields: [{ key: "plan", op: EQ, text: "pro" }]`, want: []string{"u1"}},
+		{name: "sort descending, missing values last", args: `customFieldSort: { key: "score", order: DESC }`, want: []string{"u1", "u2", "u3"}, ordered: true},
+		{name: "sort ascending", args: `customFieldSort: { key: "score" }`, want: []string{"u2", "u1", "u3"}, ordered: true},
+		{name: "limit", args: `pagination: { limit: 1 }, customFieldSort: { key: "score" }`, want: []string{"u2"}, ordered: true},
+		{name: "operator not allowed for type", args: `customFields: [{ key: "plan", op: GT, text: "free" }]`, wantCode: string(apperr.CodeValidation)},
+		{name: "unknown option", args: `customFields: [{ key: "plan", op: EQ, text: "gold" }]`, wantCode: string(apperr.CodeValidation)},
+		{name: "unknown field", args: `customFieldSort: { key: "missing" }`, wantCode: string(apperr.CodeValidation)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := c.RawPost(`{ users(` + tt.args + `) { users { user_id } pageInfo { total } } }`)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			codes := errorCodes(t, raw)
+			if tt.wantCode != "" {
+				if len(codes) != 1 || codes[0] != tt.wantCode {
+					t.Fatalf("error codes = %v, want [%s]", codes, tt.wantCode)
+				}
+				return
+			}
+			if len(codes) > 0 {
+				t.Fatalf("unexpected errors: %s", raw.Errors)
+			}
+			var resp struct {
+				Users struct {
+					Users []struct {
+						UserID string `json:"user_id"`
+					} `json:"users"`
+				} `json:"users"`
+			}
+			if err := remarshal(raw.Data, &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(resp.Users.Users))
+			for _, u := range resp.Users.Users {
+				got = append(got, u.UserID)
+			}
+			if !tt.ordered {
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("users = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("update values", func(t *testing.T) {
+		const update = `mutation($input: UserUpdateInput!) { updateUser(user_id: "u2", input: $input) { custom_fields { key text number } tags } }`
+		raw, err := c.RawPost(update, client.Var("input", map[string]interface{}{
+			"version": 0, "custom_fields": []map[string]interface{}{{"key": "plan", "text": "gold"}},
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes := errorCodes(t, raw); len(codes) != 1 || codes[0] != string(apperr.CodeValidation) {
+			t.Fatalf("error codes = %v, want [VALIDATION]", codes)
+		}
+
+		if err := c.Post(update, &struct{}{}, client.Var("input", map[string]interface{}{
+			"version":       0,
+			"custom_fields": []map[string]interface{}{{"key": "plan", "text": "pro"}, {"key": "score"}},
+			"tags":          []string{" tokyo ", "new", "new"},
+		})); err != nil {
+			t.Fatalf("updateUser failed: %v", err)
+		}
+		doc := env.Doc(t, "users", "u2")
+		fields, _ := doc["custom_fields"].(map[string]interface{})
+		if _, ok := fields["score"]; ok || fields["plan"] != "pro" || fields["renewal"] == nil {
+			t.Errorf("custom_fields = %v", fields)
+		}
+		if tags := fmt.Sprint(doc["tags"]); tags != "[tokyo new]" {
+			t.Errorf("tags = %s, want [tokyo new]", tags)
+		}
+	})
+
+	t.Run("bulk tags", func(t *testing.T) {
+		const tag = `mutation($ids: [ID!]!) { tagUsers(input: { user_ids: $ids, tags: ["campaign"] }) { user_id tags } }`
+		const untag = `mutation($ids: [ID!]!) { untagUsers(input: { user_ids: $ids, tags: ["vip"] }) { user_id tags } }`
+		if err := c.Post(tag, &struct{}{}, client.Var("ids", []string{"u1", "u2"})); err != nil {
+			t.Fatalf("tagUsers failed: %v", err)
+		}
+		if err := c.Post(untag, &struct{}{}, client.Var("ids", []string{"u1", "u3"})); err != nil {
+			t.Fatalf("untagUsers failed: %v", err)
+		}
+		want := map[string]string{"u1": "[tokyo campaign]", "u2": "[tokyo new campaign]", "u3": "[]"}
+		for id, tags := range want {
+			if got := fmt.Sprint(env.Doc(t, "users", id)["tags"]); got != tags {
+				t.Errorf("%s tags = %s, want %s", id, got, tags)
+			}
+		}
+
+		raw, err := c.RawPost(tag, client.Var("ids", []string{"u1", "missing"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes := errorCodes(t, raw); len(codes) != 1 || codes[0] != string(apperr.CodeNotFound) {
+			t.Fatalf("error codes = %v, want [NOT_FOUND]", codes)
+		}
+		if got := fmt.Sprint(env.Doc(t, "users", "u1")["tags"]); got != want["u1"] {
+			t.Errorf("u1 tags = %s after a failed bulk update, want %s", got, want["u1"])
+		}
+	})
+
+	t.Run("delete field", func(t *testing.T) {
+		raw, err := c.RawPost(`mutation { saveCustomField(input: { key: "score", label: "スコア", type: TEXT }) { id } }`, admin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes := errorCodes(t, raw); len(codes) != 1 || codes[0] != string(apperr.CodeValidation) {
+			t.Fatalf("changing the type: error codes = %v, want [VALIDATION]", codes)
+		}
+
+		if err := c.Post(`mutation { deleteCustomField(id: "default_interests") }`, &struct{}{}, admin); err != nil {
+			t.Fatalf("deleteCustomField failed: %v", err)
+		}
+		for _, id := range []string{"u1", "u3"} {
+			fields, _ := env.Doc(t, "users", id)["custom_fields"].(map[string]interface{})
+			if _, ok := fields["interests"]; ok || fields["plan"] == nil {
+				t.Errorf("%s custom_fields = %v, want interests to be removed", id, fields)
+			}
+		}
+		var resp struct {
+			CustomFields []struct {
+				Key string `json:"key"`
+			} `json:"customFields"`
+		}
+		if err := c.Post(`{ customFields { key } }`, &resp); err != nil {
+			t.Fatalf("customFields failed: %v", err)
+		}
+		if len(resp.CustomFields) != 3 {
+			t.Errorf("customFields = %v, want 3 fields", resp.CustomFields)
+		}
+	})
+}
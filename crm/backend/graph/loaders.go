@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"context"
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/dataloader"
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+)
+
+// firestoreInLimit Firestore の "in" クエリに指定できる値の上限
+const firestoreInLimit = 30
+
+// Loaders リクエスト単位の DataLoader
+//
+// リレーションフィールド（User.wallets、Wallet.user など）の読み取りを
+// GetAll / "in" クエリにまとめ、同じリクエスト内では結果を再利用する。
+type Loaders struct {
+	UserByID            *dataloader.Loader[string, *model.User]
+	WalletsByUserID     *dataloader.Loader[string, []*model.Wallet]
+	OrderByID           *dataloader.Loader[string, *model.Order]
+	OrderItemsByOrderID *dataloader.Loader[string, []*model.OrderItem]
+}
+
+// NewLoaders Loaders のコンストラクタ
+func NewLoaders(client *firestore.Client) *Loaders {
+	return &Loaders{
+		UserByID: dataloader.New(func(ctx context.Context, ids []string) (map[string]*model.User, error) {
+			return getAllByID(ctx, client, "users", ids, userFromSnapshot)
+		}, 0, 0),
+		WalletsByUserID: dataloader.New(func(ctx context.Context, userIDs []string) (map[string][]*model.Wallet, error) {
+			return queryIn(ctx, client, "wallets", "user_id", userIDs, walletFromSnapshot, func(w *model.Wallet) string { return w.UserID })
+		}, 0, 0),
+		OrderByID: dataloader.New(func(ctx context.Context, ids []string) (map[string]*model.Order, error) {
+			return getAllByID(ctx, client, "orders", ids, orderFromSnapshot)
+		}, 0, 0),
+		OrderItemsByOrderID: dataloader.New(func(ctx context.Context, orderIDs []string) (map[string][]*model.OrderItem, error) {
+			return queryIn(ctx, client, "order_items", "order_id", orderIDs, orderItemFromSnapshot, func(i *model.OrderItem) string { return i.OrderID })
+		}, 0, 0),
+	}
+}
+
+type loadersContextKey struct{}
+
+// LoaderMiddleware リクエストごとに Loaders を作成してコンテキストに設定する
+//
+// WebSocket（サブスクリプション）は接続が長時間続き、キャッシュが古くなるため対象外とする。
+// その場合 loaders がリレーションを解決するたびに Loaders を作成するため、読み取りはまとめられず1件ずつになる。
+func LoaderMiddleware(client *firestore.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), loadersContextKey{}, NewLoaders(client))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// loaders コンテキストの Loaders。ミドルウェアを経由していない場合は呼び出しごとに作成する
+func (r *Resolver) loaders(ctx context.Context) *Loaders {
+	if l, ok := ctx.Value(loadersContextKey{}).(*Loaders); ok {
+		return l
+	}
+	return NewLoaders(r.FirestoreClient)
+}
+
+// getAllByID ドキュメントIDを指定して GetAll でまとめて取得（論理削除済み・スコープ外の組織のドキュメントは除く）
+func getAllByID[V any](ctx context.Context, client *firestore.Client, collection string, ids []string, convert func(*firestore.DocumentSnapshot) V) (map[string]V, error) {
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = client.Collection(collection).Doc(id)
+	}
+
+	docs, err := client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to get %s from Firestore", collection)
+	}
+
+	values := make(map[string]V, len(docs))
+	for _, doc := range docs {
+		if doc.Exists() && !softdelete.IsDeleted(doc.Data()) && tenant.Allows(ctx, doc.Data()) {
+			values[doc.Ref.ID] = convert(doc)
+		}
+	}
+	return values, nil
+}
+
+// queryIn field の値が keys のいずれかに一致するドキュメントを "in" クエリで取得し、キーごとにまとめる（論理削除済み・スコープ外の組織のものは除く）
+func queryIn[V any](ctx context.Context, client *firestore.Client, collection, field string, keys []string, convert func(*firestore.DocumentSnapshot) V, keyOf func(V) string) (map[string][]V, error) {
+	values := make(map[string][]V, len(keys))
+	for _, key := range keys {
+		values[key] = []V{}
+	}
+
+	for start := 0; start < len(keys); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(keys))
+		query := tenant.Query(ctx, client.Collection(collection).Where(field, "in", keys[start:end]))
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, apperr.Internal(err, "failed to get %s from Firestore", collection)
+		}
+		for _, doc := range softdelete.Active(docs) {
+			v := convert(doc)
+			values[keyOf(v)] = append(values[keyOf(v)], v)
+		}
+	}
+	return values, nil
+}
+
+// loadUser DataLoader 経由でユーザーを取得（存在しない場合はエラー）
+func (r *Resolver) loadUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := r.loaders(ctx).UserByID.Load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperr.NotFound("user not found: %s", userID)
+	}
+	return user, nil
+}
@@ -10,6 +10,77 @@ import (
 	"time"
 )
 
+type AuditChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+type AuditLog struct {
+	ID         string         `json:"id"`
+	TenantID   *string        `json:"tenant_id,omitempty"`
+	ActorUID   *string        `json:"actor_uid,omitempty"`
+	ActorEmail *string        `json:"actor_email,omitempty"`
+	ActorRole  *string        `json:"actor_role,omitempty"`
+	Operation  string         `json:"operation"`
+	EntityType *string        `json:"entity_type,omitempty"`
+	EntityID   *string        `json:"entity_id,omitempty"`
+	Arguments  string         `json:"arguments"`
+	Changes    []*AuditChange `json:"changes"`
+	Error      *string        `json:"error,omitempty"`
+	RequestID  *string        `json:"request_id,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type CustomField struct {
+	ID        string          `json:"id"`
+	Key       string          `json:"key"`
+	Label     string          `json:"label"`
+	Type      CustomFieldType `json:"type"`
+	Options   []string        `json:"options"`
+	Position  int             `json:"position"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type CustomFieldFilterInput struct {
+	Key     string              `json:"key"`
+	Op      CustomFieldOperator `json:"op"`
+	Text    *string             `json:"text,omitempty"`
+	Number  *float64            `json:"number,omitempty"`
+	Date    *time.Time          `json:"date,omitempty"`
+	Options []string            `json:"options,omitempty"`
+}
+
+type CustomFieldInput struct {
+	Key      string          `json:"key" validate:"required,max=40"`
+	Label    string          `json:"label" validate:"required,max=100"`
+	Type     CustomFieldType `json:"type"`
+	Options  []string        `json:"options,omitempty" validate:"max=100"`
+	Position *int            `json:"position,omitempty"`
+}
+
+type CustomFieldSortInput struct {
+	Key   string     `json:"key"`
+	Order *SortOrder `json:"order,omitempty"`
+}
+
+type CustomFieldValue struct {
+	Key     string     `json:"key"`
+	Text    *string    `json:"text,omitempty"`
+	Number  *float64   `json:"number,omitempty"`
+	Date    *time.Time `json:"date,omitempty"`
+	Options []string   `json:"options,omitempty"`
+}
+
+type CustomFieldValueInput struct {
+	Key     string     `json:"key" validate:"required"`
+	Text    *string    `json:"text,omitempty"`
+	Number  *float64   `json:"number,omitempty"`
+	Date    *time.Time `json:"date,omitempty"`
+	Options []string   `json:"options,omitempty"`
+}
+
 type DashboardData struct {
 	UserStats            *UserStats     `json:"userStats"`
 	WalletStats          *WalletStats   `json:"walletStats"`
@@ -18,9 +89,62 @@ type DashboardData struct {
 	UpcomingInteractions []*Interaction `json:"upcomingInteractions"`
 }
 
+type EmailBranding struct {
+	ProductName  string  `json:"product_name"`
+	SenderName   string  `json:"sender_name"`
+	SupportEmail *string `json:"support_email,omitempty"`
+	LogoURL      *string `json:"logo_url,omitempty"`
+	PrimaryColor *string `json:"primary_color,omitempty"`
+	FooterText   *string `json:"footer_text,omitempty"`
+}
+
+type EmailBrandingInput struct {
+	ProductName  *string `json:"product_name,omitempty" validate:"max=100"`
+	SenderName   *string `json:"sender_name,omitempty" validate:"max=100"`
+	SupportEmail *string `json:"support_email,omitempty" validate:"email,max=254"`
+	LogoURL      *string `json:"logo_url,omitempty" validate:"max=2048"`
+	PrimaryColor *string `json:"primary_color,omitempty" validate:"max=7"`
+	FooterText   *string `json:"footer_text,omitempty" validate:"max=1000"`
+}
+
+type ExportResult struct {
+	Dataset   string       `json:"dataset"`
+	Format    ExportFormat `json:"format"`
+	FileName  string       `json:"file_name"`
+	URL       string       `json:"url"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+	Rows      int          `json:"rows"`
+}
+
+type ImportJob struct {
+	ID            string            `json:"id"`
+	FileName      string            `json:"file_name"`
+	Format        ImportFormat      `json:"format"`
+	Status        ImportJobStatus   `json:"status"`
+	TotalRows     int               `json:"total_rows"`
+	ProcessedRows int               `json:"processed_rows"`
+	CreatedCount  int               `json:"created_count"`
+	SkippedCount  int               `json:"skipped_count"`
+	FailedCount   int               `json:"failed_count"`
+	Progress      float64           `json:"progress"`
+	Errors        []*ImportRowError `json:"errors"`
+	Error         *string           `json:"error,omitempty"`
+	CreatedBy     *string           `json:"created_by,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+}
+
+type ImportRowError struct {
+	Line    int     `json:"line"`
+	Field   *string `json:"field,omitempty"`
+	Message string  `json:"message"`
+}
+
 type Interaction struct {
 	ID          string             `json:"id"`
 	UserID      string             `json:"user_id"`
+	TenantID    string             `json:"tenant_id"`
 	Type        InteractionType    `json:"type"`
 	Subject     string             `json:"subject"`
 	Content     string             `json:"content"`
@@ -31,22 +155,36 @@ type Interaction struct {
 	CompletedAt *time.Time         `json:"completedAt,omitempty"`
 	CreatedAt   time.Time          `json:"createdAt"`
 	UpdatedAt   time.Time          `json:"updatedAt"`
+	Version     int                `json:"version"`
+	DeletedAt   *time.Time         `json:"deletedAt,omitempty"`
+	DeletedBy   *string            `json:"deletedBy,omitempty"`
 	User        *User              `json:"user"`
 }
 
 type InteractionInput struct {
-	UserID      string             `json:"user_id"`
+	UserID      string             `json:"user_id" validate:"required"`
 	Type        InteractionType    `json:"type"`
-	Subject     string             `json:"subject"`
-	Content     string             `json:"content"`
+	Subject     string             `json:"subject" validate:"required,max=200"`
+	Content     string             `json:"content" validate:"max=5000"`
 	Channel     InteractionChannel `json:"channel"`
-	AssignedTo  *string            `json:"assignedTo,omitempty"`
+	AssignedTo  *string            `json:"assignedTo,omitempty" validate:"max=128"`
 	ScheduledAt *time.Time         `json:"scheduledAt,omitempty"`
 }
 
+type Notification struct {
+	ID               string    `json:"id"`
+	NotificationID   string    `json:"notification_id"`
+	NotificationType string    `json:"notification_type"`
+	BusinessUserID   *string   `json:"business_user_id,omitempty"`
+	TenantID         string    `json:"tenant_id"`
+	Processed        bool      `json:"processed"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
 type Order struct {
 	ID           string       `json:"id"`
 	UserID       string       `json:"user_id"`
+	TenantID     string       `json:"tenant_id"`
 	OrderNumber  string       `json:"orderNumber"`
 	Status       OrderStatus  `json:"status"`
 	TotalAmount  float64      `json:"totalAmount"`
@@ -56,6 +194,9 @@ type Order struct {
 	Notes        *string      `json:"notes,omitempty"`
 	CreatedAt    time.Time    `json:"createdAt"`
 	UpdatedAt    time.Time    `json:"updatedAt"`
+	Version      int          `json:"version"`
+	DeletedAt    *time.Time   `json:"deletedAt,omitempty"`
+	DeletedBy    *string      `json:"deletedBy,omitempty"`
 	User         *User        `json:"user"`
 	Items        []*OrderItem `json:"items"`
 }
@@ -66,14 +207,14 @@ type OrderConnection struct {
 }
 
 type OrderInput struct {
-	UserID       string            `json:"user_id"`
-	OrderNumber  string            `json:"orderNumber"`
-	TotalAmount  float64           `json:"totalAmount"`
-	Currency     string            `json:"currency"`
+	UserID       string            `json:"user_id" validate:"required"`
+	OrderNumber  string            `json:"orderNumber" validate:"required,max=64"`
+	TotalAmount  float64           `json:"totalAmount" validate:"gte=0"`
+	Currency     string            `json:"currency" validate:"min=3,max=3"`
 	OrderDate    time.Time         `json:"orderDate"`
 	DeliveryDate *time.Time        `json:"deliveryDate,omitempty"`
-	Notes        *string           `json:"notes,omitempty"`
-	Items        []*OrderItemInput `json:"items"`
+	Notes        *string           `json:"notes,omitempty" validate:"max=1000"`
+	Items        []*OrderItemInput `json:"items" validate:"required,max=100"`
 }
 
 type OrderItem struct {
@@ -87,9 +228,9 @@ type OrderItem struct {
 }
 
 type OrderItemInput struct {
-	ProductName string  `json:"productName"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unitPrice"`
+	ProductName string  `json:"productName" validate:"required,max=200"`
+	Quantity    int     `json:"quantity" validate:"gt=0"`
+	UnitPrice   float64 `json:"unitPrice" validate:"gte=0"`
 }
 
 type OrderStats struct {
@@ -100,6 +241,32 @@ type OrderStats struct {
 	AverageOrderValue float64 `json:"averageOrderValue"`
 }
 
+type Organization struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Settings  *OrganizationSettings `json:"settings"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type OrganizationInput struct {
+	ID       string                     `json:"id" validate:"required,max=63"`
+	Name     string                     `json:"name" validate:"required,max=100"`
+	Settings *OrganizationSettingsInput `json:"settings,omitempty"`
+}
+
+type OrganizationSettings struct {
+	Currency string         `json:"currency"`
+	Locale   string         `json:"locale"`
+	Branding *EmailBranding `json:"branding"`
+}
+
+type OrganizationSettingsInput struct {
+	Currency *string             `json:"currency,omitempty" validate:"min=3,max=3"`
+	Locale   *string             `json:"locale,omitempty" validate:"max=35"`
+	Branding *EmailBrandingInput `json:"branding,omitempty"`
+}
+
 type PageInfo struct {
 	Page    int  `json:"page"`
 	Limit   int  `json:"limit"`
@@ -110,12 +277,89 @@ type PageInfo struct {
 }
 
 type PaginationInput struct {
-	Page      *int       `json:"page,omitempty"`
-	Limit     *int       `json:"limit,omitempty"`
+	Page      *int       `json:"page,omitempty" validate:"gte=1"`
+	Limit     *int       `json:"limit,omitempty" validate:"gte=1,max=100"`
 	SortBy    *string    `json:"sortBy,omitempty"`
 	SortOrder *SortOrder `json:"sortOrder,omitempty"`
 }
 
+type Rfm struct {
+	RecencyDays       *int       `json:"recency_days,omitempty"`
+	Frequency         int        `json:"frequency"`
+	Monetary          float64    `json:"monetary"`
+	RScore            int        `json:"r_score"`
+	FScore            int        `json:"f_score"`
+	MScore            int        `json:"m_score"`
+	InteractionCount  int        `json:"interaction_count"`
+	LastOrderAt       *time.Time `json:"last_order_at,omitempty"`
+	LastInteractionAt *time.Time `json:"last_interaction_at,omitempty"`
+}
+
+type Segment struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Rules       []*SegmentRule `json:"rules"`
+	Status      *UserStatus    `json:"status,omitempty"`
+	Priority    int            `json:"priority"`
+	UserCount   int            `json:"user_count"`
+	EvaluatedAt *time.Time     `json:"evaluated_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type SegmentInput struct {
+	Name        string              `json:"name" validate:"required,max=100"`
+	Description *string             `json:"description,omitempty" validate:"max=500"`
+	Rules       []*SegmentRuleInput `json:"rules" validate:"required,max=20"`
+	Status      *UserStatus         `json:"status,omitempty"`
+	Priority    *int                `json:"priority,omitempty"`
+}
+
+type SegmentRule struct {
+	Field SegmentField    `json:"field"`
+	Op    SegmentOperator `json:"op"`
+	Value float64         `json:"value"`
+}
+
+type SegmentRuleInput struct {
+	Field SegmentField    `json:"field"`
+	Op    SegmentOperator `json:"op"`
+	Value float64         `json:"value"`
+}
+
+type SegmentRun struct {
+	ID            *string   `json:"id,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Users         int       `json:"users"`
+	StatusChanges int       `json:"status_changes"`
+}
+
+type StaffInviteInput struct {
+	FirstName         string     `json:"first_name" validate:"required,max=50"`
+	LastName          string     `json:"last_name" validate:"required,max=50"`
+	FirstNameKatakana *string    `json:"first_name_katakana,omitempty" validate:"katakana,max=50"`
+	LastNameKatakana  *string    `json:"last_name_katakana,omitempty" validate:"katakana,max=50"`
+	EmailAddress      string     `json:"email_address" validate:"required,email,max=254"`
+	Role              *StaffRole `json:"role,omitempty"`
+}
+
+type StaffUser struct {
+	ID                string     `json:"id"`
+	TenantID          string     `json:"tenant_id"`
+	FirstName         string     `json:"first_name"`
+	LastName          string     `json:"last_name"`
+	FirstNameKatakana string     `json:"first_name_katakana"`
+	LastNameKatakana  string     `json:"last_name_katakana"`
+	EmailAddress      string     `json:"email_address"`
+	Role              StaffRole  `json:"role"`
+	Disabled          bool       `json:"disabled"`
+	DisabledAt        *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
 type UploadInput struct {
 	FileName    string  `json:"fileName"`
 	ContentType string  `json:"contentType"`
@@ -132,18 +376,26 @@ type UploadURL struct {
 }
 
 type User struct {
-	UserID            string     `json:"user_id"`
-	FirstName         string     `json:"first_name"`
-	LastName          string     `json:"last_name"`
-	FirstNameKatakana string     `json:"first_name_katakana"`
-	LastNameKatakana  string     `json:"last_name_katakana"`
-	EmailAddress      string     `json:"email_address"`
-	Role              UserRole   `json:"role"`
-	Balance           float64    `json:"balance"`
-	Status            UserStatus `json:"status"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
-	Wallets           []*Wallet  `json:"wallets"`
+	UserID            string              `json:"user_id"`
+	TenantID          string              `json:"tenant_id"`
+	FirstName         string              `json:"first_name"`
+	LastName          string              `json:"last_name"`
+	FirstNameKatakana string              `json:"first_name_katakana"`
+	LastNameKatakana  string              `json:"last_name_katakana"`
+	EmailAddress      string              `json:"email_address"`
+	Role              UserRole            `json:"role"`
+	Balance           float64             `json:"balance"`
+	Status            UserStatus          `json:"status"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	Version           int                 `json:"version"`
+	DeletedAt         *time.Time          `json:"deleted_at,omitempty"`
+	DeletedBy         *string             `json:"deleted_by,omitempty"`
+	Segments          []string            `json:"segments"`
+	Rfm               *Rfm                `json:"rfm,omitempty"`
+	CustomFields      []*CustomFieldValue `json:"custom_fields"`
+	Tags              []string            `json:"tags"`
+	Wallets           []*Wallet           `json:"wallets"`
 }
 
 type UserConnection struct {
@@ -152,14 +404,16 @@ type UserConnection struct {
 }
 
 type UserInput struct {
-	FirstName         string      `json:"first_name"`
-	LastName          string      `json:"last_name"`
-	FirstNameKatakana string      `json:"first_name_katakana"`
-	LastNameKatakana  string      `json:"last_name_katakana"`
-	EmailAddress      string      `json:"email_address"`
-	Role              *UserRole   `json:"role,omitempty"`
-	Balance           *float64    `json:"balance,omitempty"`
-	Status            *UserStatus `json:"status,omitempty"`
+	FirstName         string                   `json:"first_name" validate:"required,max=50"`
+	LastName          string                   `json:"last_name" validate:"required,max=50"`
+	FirstNameKatakana string                   `json:"first_name_katakana" validate:"required,katakana,max=50"`
+	LastNameKatakana  string                   `json:"last_name_katakana" validate:"required,katakana,max=50"`
+	EmailAddress      string                   `json:"email_address" validate:"required,email,max=254"`
+	Role              *UserRole                `json:"role,omitempty"`
+	Balance           *float64                 `json:"balance,omitempty" validate:"gte=0"`
+	Status            *UserStatus              `json:"status,omitempty"`
+	CustomFields      []*CustomFieldValueInput `json:"custom_fields,omitempty" validate:"max=100"`
+	Tags              []string                 `json:"tags,omitempty" validate:"max=50"`
 }
 
 type UserStats struct {
@@ -169,25 +423,37 @@ type UserStats struct {
 	UserGrowthRate    float64 `json:"userGrowthRate"`
 }
 
+type UserTagsInput struct {
+	UserIds []string `json:"user_ids" validate:"required,max=500"`
+	Tags    []string `json:"tags" validate:"required,max=50"`
+}
+
 type UserUpdateInput struct {
-	FirstName         *string     `json:"first_name,omitempty"`
-	LastName          *string     `json:"last_name,omitempty"`
-	FirstNameKatakana *string     `json:"first_name_katakana,omitempty"`
-	LastNameKatakana  *string     `json:"last_name_katakana,omitempty"`
-	EmailAddress      *string     `json:"email_address,omitempty"`
-	Role              *UserRole   `json:"role,omitempty"`
-	Balance           *float64    `json:"balance,omitempty"`
-	Status            *UserStatus `json:"status,omitempty"`
+	FirstName         *string                  `json:"first_name,omitempty" validate:"required,max=50"`
+	LastName          *string                  `json:"last_name,omitempty" validate:"required,max=50"`
+	FirstNameKatakana *string                  `json:"first_name_katakana,omitempty" validate:"required,katakana,max=50"`
+	LastNameKatakana  *string                  `json:"last_name_katakana,omitempty" validate:"required,katakana,max=50"`
+	EmailAddress      *string                  `json:"email_address,omitempty" validate:"required,email,max=254"`
+	Role              *UserRole                `json:"role,omitempty"`
+	Balance           *float64                 `json:"balance,omitempty" validate:"gte=0"`
+	Status            *UserStatus              `json:"status,omitempty"`
+	CustomFields      []*CustomFieldValueInput `json:"custom_fields,omitempty" validate:"max=100"`
+	Tags              []string                 `json:"tags,omitempty" validate:"max=50"`
+	Version           int                      `json:"version" validate:"gte=0"`
 }
 
 type Wallet struct {
 	WalletAddress string       `json:"wallet_address"`
 	UserID        string       `json:"user_id"`
+	TenantID      string       `json:"tenant_id"`
 	Balance       float64      `json:"balance"`
 	Currency      string       `json:"currency"`
 	Status        WalletStatus `json:"status"`
 	CreatedAt     time.Time    `json:"created_at"`
 	UpdatedAt     time.Time    `json:"updated_at"`
+	Version       int          `json:"version"`
+	DeletedAt     *time.Time   `json:"deleted_at,omitempty"`
+	DeletedBy     *string      `json:"deleted_by,omitempty"`
 	User          *User        `json:"user"`
 }
 
@@ -197,10 +463,10 @@ type WalletConnection struct {
 }
 
 type WalletInput struct {
-	UserID        string        `json:"user_id"`
-	WalletAddress string        `json:"wallet_address"`
-	Balance       *float64      `json:"balance,omitempty"`
-	Currency      *string       `json:"currency,omitempty"`
+	UserID        string        `json:"user_id" validate:"required"`
+	WalletAddress string        `json:"wallet_address" validate:"required,max=128"`
+	Balance       *float64      `json:"balance,omitempty" validate:"gte=0"`
+	Currency      *string       `json:"currency,omitempty" validate:"min=3,max=3"`
 	Status        *WalletStatus `json:"status,omitempty"`
 }
 
@@ -212,9 +478,311 @@ type WalletStats struct {
 }
 
 type WalletUpdateInput struct {
-	Balance  *float64      `json:"balance,omitempty"`
-	Currency *string       `json:"currency,omitempty"`
+	Balance  *float64      `json:"balance,omitempty" validate:"gte=0"`
+	Currency *string       `json:"currency,omitempty" validate:"min=3,max=3"`
 	Status   *WalletStatus `json:"status,omitempty"`
+	Version  int           `json:"version" validate:"gte=0"`
+}
+
+type CustomFieldOperator string
+
+const (
+	CustomFieldOperatorEq    CustomFieldOperator = "EQ"
+	CustomFieldOperatorLt    CustomFieldOperator = "LT"
+	CustomFieldOperatorLte   CustomFieldOperator = "LTE"
+	CustomFieldOperatorGt    CustomFieldOperator = "GT"
+	CustomFieldOperatorGte   CustomFieldOperator = "GTE"
+	CustomFieldOperatorAny   CustomFieldOperator = "ANY"
+	CustomFieldOperatorAll   CustomFieldOperator = "ALL"
+	CustomFieldOperatorSet   CustomFieldOperator = "SET"
+	CustomFieldOperatorUnset CustomFieldOperator = "UNSET"
+)
+
+var AllCustomFieldOperator = []CustomFieldOperator{
+	CustomFieldOperatorEq,
+	CustomFieldOperatorLt,
+	CustomFieldOperatorLte,
+	CustomFieldOperatorGt,
+	CustomFieldOperatorGte,
+	CustomFieldOperatorAny,
+	CustomFieldOperatorAll,
+	CustomFieldOperatorSet,
+	CustomFieldOperatorUnset,
+}
+
+func (e CustomFieldOperator) IsValid() bool {
+	switch e {
+	case CustomFieldOperatorEq, CustomFieldOperatorLt, CustomFieldOperatorLte, CustomFieldOperatorGt, CustomFieldOperatorGte, CustomFieldOperatorAny, CustomFieldOperatorAll, CustomFieldOperatorSet, CustomFieldOperatorUnset:
+		return true
+	}
+	return false
+}
+
+func (e CustomFieldOperator) String() string {
+	return string(e)
+}
+
+func (e *CustomFieldOperator) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CustomFieldOperator(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CustomFieldOperator", str)
+	}
+	return nil
+}
+
+func (e CustomFieldOperator) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CustomFieldOperator) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CustomFieldOperator) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type CustomFieldType string
+
+const (
+	CustomFieldTypeText        CustomFieldType = "TEXT"
+	CustomFieldTypeNumber      CustomFieldType = "NUMBER"
+	CustomFieldTypeDate        CustomFieldType = "DATE"
+	CustomFieldTypeEnum        CustomFieldType = "ENUM"
+	CustomFieldTypeMultiSelect CustomFieldType = "MULTI_SELECT"
+)
+
+var AllCustomFieldType = []CustomFieldType{
+	CustomFieldTypeText,
+	CustomFieldTypeNumber,
+	CustomFieldTypeDate,
+	CustomFieldTypeEnum,
+	CustomFieldTypeMultiSelect,
+}
+
+func (e CustomFieldType) IsValid() bool {
+	switch e {
+	case CustomFieldTypeText, CustomFieldTypeNumber, CustomFieldTypeDate, CustomFieldTypeEnum, CustomFieldTypeMultiSelect:
+		return true
+	}
+	return false
+}
+
+func (e CustomFieldType) String() string {
+	return string(e)
+}
+
+func (e *CustomFieldType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CustomFieldType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CustomFieldType", str)
+	}
+	return nil
+}
+
+func (e CustomFieldType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CustomFieldType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CustomFieldType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV     ExportFormat = "CSV"
+	ExportFormatJSONL   ExportFormat = "JSONL"
+	ExportFormatParquet ExportFormat = "PARQUET"
+)
+
+var AllExportFormat = []ExportFormat{
+	ExportFormatCSV,
+	ExportFormatJSONL,
+	ExportFormatParquet,
+}
+
+func (e ExportFormat) IsValid() bool {
+	switch e {
+	case ExportFormatCSV, ExportFormatJSONL, ExportFormatParquet:
+		return true
+	}
+	return false
+}
+
+func (e ExportFormat) String() string {
+	return string(e)
+}
+
+func (e *ExportFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ExportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ExportFormat", str)
+	}
+	return nil
+}
+
+func (e ExportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ExportFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ExportFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV  ImportFormat = "CSV"
+	ImportFormatXlsx ImportFormat = "XLSX"
+)
+
+var AllImportFormat = []ImportFormat{
+	ImportFormatCSV,
+	ImportFormatXlsx,
+}
+
+func (e ImportFormat) IsValid() bool {
+	switch e {
+	case ImportFormatCSV, ImportFormatXlsx:
+		return true
+	}
+	return false
+}
+
+func (e ImportFormat) String() string {
+	return string(e)
+}
+
+func (e *ImportFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImportFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImportFormat", str)
+	}
+	return nil
+}
+
+func (e ImportFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ImportFormat) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ImportFormat) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusPending   ImportJobStatus = "PENDING"
+	ImportJobStatusRunning   ImportJobStatus = "RUNNING"
+	ImportJobStatusCompleted ImportJobStatus = "COMPLETED"
+	ImportJobStatusFailed    ImportJobStatus = "FAILED"
+)
+
+var AllImportJobStatus = []ImportJobStatus{
+	ImportJobStatusPending,
+	ImportJobStatusRunning,
+	ImportJobStatusCompleted,
+	ImportJobStatusFailed,
+}
+
+func (e ImportJobStatus) IsValid() bool {
+	switch e {
+	case ImportJobStatusPending, ImportJobStatusRunning, ImportJobStatusCompleted, ImportJobStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e ImportJobStatus) String() string {
+	return string(e)
+}
+
+func (e *ImportJobStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImportJobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImportJobStatus", str)
+	}
+	return nil
+}
+
+func (e ImportJobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ImportJobStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ImportJobStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
 
 type InteractionChannel string
@@ -467,6 +1035,136 @@ func (e OrderStatus) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type SegmentField string
+
+const (
+	SegmentFieldRecencyDays          SegmentField = "RECENCY_DAYS"
+	SegmentFieldFrequency            SegmentField = "FREQUENCY"
+	SegmentFieldMonetary             SegmentField = "MONETARY"
+	SegmentFieldRScore               SegmentField = "R_SCORE"
+	SegmentFieldFScore               SegmentField = "F_SCORE"
+	SegmentFieldMScore               SegmentField = "M_SCORE"
+	SegmentFieldInteractionCount     SegmentField = "INTERACTION_COUNT"
+	SegmentFieldDaysSinceInteraction SegmentField = "DAYS_SINCE_INTERACTION"
+	SegmentFieldAccountAgeDays       SegmentField = "ACCOUNT_AGE_DAYS"
+)
+
+var AllSegmentField = []SegmentField{
+	SegmentFieldRecencyDays,
+	SegmentFieldFrequency,
+	SegmentFieldMonetary,
+	SegmentFieldRScore,
+	SegmentFieldFScore,
+	SegmentFieldMScore,
+	SegmentFieldInteractionCount,
+	SegmentFieldDaysSinceInteraction,
+	SegmentFieldAccountAgeDays,
+}
+
+func (e SegmentField) IsValid() bool {
+	switch e {
+	case SegmentFieldRecencyDays, SegmentFieldFrequency, SegmentFieldMonetary, SegmentFieldRScore, SegmentFieldFScore, SegmentFieldMScore, SegmentFieldInteractionCount, SegmentFieldDaysSinceInteraction, SegmentFieldAccountAgeDays:
+		return true
+	}
+	return false
+}
+
+func (e SegmentField) String() string {
+	return string(e)
+}
+
+func (e *SegmentField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SegmentField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SegmentField", str)
+	}
+	return nil
+}
+
+func (e SegmentField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SegmentField) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SegmentField) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SegmentOperator string
+
+const (
+	SegmentOperatorLt  SegmentOperator = "LT"
+	SegmentOperatorLte SegmentOperator = "LTE"
+	SegmentOperatorGt  SegmentOperator = "GT"
+	SegmentOperatorGte SegmentOperator = "GTE"
+	SegmentOperatorEq  SegmentOperator = "EQ"
+)
+
+var AllSegmentOperator = []SegmentOperator{
+	SegmentOperatorLt,
+	SegmentOperatorLte,
+	SegmentOperatorGt,
+	SegmentOperatorGte,
+	SegmentOperatorEq,
+}
+
+func (e SegmentOperator) IsValid() bool {
+	switch e {
+	case SegmentOperatorLt, SegmentOperatorLte, SegmentOperatorGt, SegmentOperatorGte, SegmentOperatorEq:
+		return true
+	}
+	return false
+}
+
+func (e SegmentOperator) String() string {
+	return string(e)
+}
+
+func (e *SegmentOperator) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SegmentOperator(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SegmentOperator", str)
+	}
+	return nil
+}
+
+func (e SegmentOperator) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SegmentOperator) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SegmentOperator) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type SortOrder string
 
 const (
@@ -522,6 +1220,65 @@ func (e SortOrder) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type StaffRole string
+
+const (
+	StaffRoleUser       StaffRole = "USER"
+	StaffRoleModerator  StaffRole = "MODERATOR"
+	StaffRoleAdmin      StaffRole = "ADMIN"
+	StaffRoleSuperAdmin StaffRole = "SUPER_ADMIN"
+)
+
+var AllStaffRole = []StaffRole{
+	StaffRoleUser,
+	StaffRoleModerator,
+	StaffRoleAdmin,
+	StaffRoleSuperAdmin,
+}
+
+func (e StaffRole) IsValid() bool {
+	switch e {
+	case StaffRoleUser, StaffRoleModerator, StaffRoleAdmin, StaffRoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+func (e StaffRole) String() string {
+	return string(e)
+}
+
+func (e *StaffRole) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StaffRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StaffRole", str)
+	}
+	return nil
+}
+
+func (e StaffRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StaffRole) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StaffRole) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type UserRole string
 
 const (
@@ -4,6 +4,11 @@ import (
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
+
+	"narratives-crm-backend/export"
+	"narratives-crm-backend/importer"
+	"narratives-crm-backend/segment"
+	"narratives-crm-backend/services"
 )
 
 // This file will not be regenerated automatically.
@@ -14,4 +19,19 @@ type Resolver struct {
 	FirebaseApp     *firebase.App
 	AuthClient      *auth.Client
 	FirestoreClient *firestore.Client
+
+	// Auth Firebase Auth のユーザー操作（ビジネスユーザーの招待など）
+	Auth *services.FirebaseAuthService
+
+	// Events サブスクリプションへ配信するイベント
+	Events *Events
+
+	// Importer ユーザー一括取り込みジョブの実行
+	Importer *importer.Runner
+
+	// Exporter コレクションのエクスポート
+	Exporter *export.Exporter
+
+	// Segments セグメントの評価（RFM と HOT / COLD の更新）
+	Segments *segment.Engine
 }
@@ -6,151 +6,379 @@ package graph
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/json"
 	"fmt"
-	"math/big"
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/audit"
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/customfield"
+	"narratives-crm-backend/export"
 	"narratives-crm-backend/graph/generated"
 	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/importer"
+	"narratives-crm-backend/optimistic"
+	"narratives-crm-backend/segment"
+	"narratives-crm-backend/services"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"cloud.google.com/go/storage"
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/auth"
+	"github.com/99designs/gqlgen/graphql"
 	"google.golang.org/api/option"
 )
 
-// CreateUser is the resolver for the createUser field.
-func (r *mutationResolver) CreateUser(ctx context.Context, input model.UserInput) (*model.User, error) {
-	// Firebase Authクライアントを初期化
-	app, err := getFirebaseApp(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Firebase app: %v", err)
-	}
+// User is the resolver for the user field.
+func (r *interactionResolver) User(ctx context.Context, obj *model.Interaction) (*model.User, error) {
+	return r.loadUser(ctx, obj.UserID)
+}
 
-	authClient, err := app.Auth(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get Firebase Auth client: %v", err)
+// InviteStaff is the resolver for the inviteStaff field.
+func (r *mutationResolver) InviteStaff(ctx context.Context, input model.StaffInviteInput) (*model.StaffUser, error) {
+	role := model.StaffRoleUser
+	if input.Role != nil {
+		role = *input.Role
 	}
-
-	// Firestoreクライアントを初期化
-	firestoreClient, err := getFirestoreClient(ctx)
+	if err := requireStaffManager(ctx, role); err != nil {
+		return nil, err
+	}
+	tenantID, err := tenant.ID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Firestore client: %v", err)
+		return nil, err
 	}
-	defer firestoreClient.Close()
 
-	// 一時パスワードを生成
-	tempPassword, err := generateTemporaryPassword()
+	created, err := r.Auth.CreateBusinessUser(ctx, services.BusinessUserInput{
+		FirstName:         input.FirstName,
+		LastName:          input.LastName,
+		FirstNameKatakana: stringValue(input.FirstNameKatakana),
+		LastNameKatakana:  stringValue(input.LastNameKatakana),
+		EmailAddress:      input.EmailAddress,
+		Role:              staffRoleClaim(role),
+		TenantID:          tenantID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate temporary password: %v", err)
+		return nil, err
 	}
+	return staffToModel(created), nil
+}
 
-	// Firebase Authでユーザーを作成
-	userToCreate := (&auth.UserToCreate{}).
-		Email(input.EmailAddress).
-		EmailVerified(false).
-		Password(tempPassword).
-		DisplayName(fmt.Sprintf("%s %s", input.LastName, input.FirstName)).
-		Disabled(false)
+// ChangeStaffRole is the resolver for the changeStaffRole field.
+func (r *mutationResolver) ChangeStaffRole(ctx context.Context, id string, role model.StaffRole) (*model.StaffUser, error) {
+	if err := r.checkStaffUpdate(ctx, id, role); err != nil {
+		return nil, err
+	}
 
-	userRecord, err := authClient.CreateUser(ctx, userToCreate)
+	staff, err := r.Auth.ChangeBusinessUserRole(ctx, id, staffRoleClaim(role))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user in Firebase Auth: %v", err)
+		return nil, err
 	}
+	return staffToModel(staff), nil
+}
 
-	// ユーザーロールを処理
-	roleString := "user" // デフォルト
-	if input.Role != nil {
-		roleString = input.Role.String()
+// DisableStaff is the resolver for the disableStaff field.
+func (r *mutationResolver) DisableStaff(ctx context.Context, id string) (*model.StaffUser, error) {
+	if err := r.checkStaffUpdate(ctx, id); err != nil {
+		return nil, err
 	}
 
-	// ユーザーロールをFirebase Auth custom claimsに設定
-	claims := map[string]interface{}{
-		"role": roleString,
+	staff, err := r.Auth.DisableBusinessUser(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if err := authClient.SetCustomUserClaims(ctx, userRecord.UID, claims); err != nil {
-		// 作成したユーザーを削除してからエラーを返す
-		authClient.DeleteUser(ctx, userRecord.UID)
-		return nil, fmt.Errorf("failed to set custom claims: %v", err)
+	return staffToModel(staff), nil
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.UserInput) (*model.User, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Firestoreにビジネスユーザー情報を保存
 	now := time.Now()
-	businessUserData := map[string]interface{}{
-		"business_user_id":    userRecord.UID,
+	data := map[string]interface{}{
 		"first_name":          input.FirstName,
 		"last_name":           input.LastName,
 		"first_name_katakana": input.FirstNameKatakana,
 		"last_name_katakana":  input.LastNameKatakana,
 		"email_address":       input.EmailAddress,
-		"role":                roleString,
+		"role":                "user",
 		"balance":             0.0,
 		"status":              "active",
-		"temporary_password":  tempPassword,
+		tenant.FieldTenantID:  tenantID,
 		"created_at":          now,
 		"updated_at":          now,
 	}
-
-	_, err = firestoreClient.Collection("business_users").Doc(userRecord.UID).Set(ctx, businessUserData)
-	if err != nil {
-		// 作成したユーザーを削除してからエラーを返す
-		authClient.DeleteUser(ctx, userRecord.UID)
-		return nil, fmt.Errorf("failed to save business user to Firestore: %v", err)
+	if input.Role != nil {
+		data["role"] = firestoreEnum(input.Role)
 	}
-
-	// Welcome email通知を作成（notification_watcherが自動処理）
-	if err := createWelcomeEmailNotification(ctx, firestoreClient, userRecord.UID); err != nil {
-		// 通知作成失敗はログに記録するだけ
-		fmt.Printf("Warning: Failed to create welcome email notification for %s: %v\n", input.EmailAddress, err)
+	if input.Balance != nil {
+		data["balance"] = *input.Balance
 	}
-
-	// 直接メール送信（firebase_auth_serviceを使用）
-	if err := sendWelcomeEmailDirect(ctx, input, tempPassword); err != nil {
-		fmt.Printf("Warning: Failed to send welcome email to %s: %v\n", input.EmailAddress, err)
+	if input.Status != nil {
+		data["status"] = firestoreEnum(input.Status)
 	}
-
-	// レスポンス用のUserオブジェクトを作成
-	role := model.UserRoleUser
-	if input.Role != nil {
-		switch *input.Role {
-		case model.UserRoleAdmin:
-			role = model.UserRoleAdmin
-		case model.UserRoleModerator:
-			role = model.UserRoleModerator
-		default:
-			role = model.UserRoleUser
+	if input.CustomFields != nil {
+		fields, err := customfield.List(ctx, r.FirestoreClient)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := customfield.Encode(fields, customFieldValuesFromInput(input.CustomFields))
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{}, len(encoded))
+		for key, v := range encoded {
+			if v != nil {
+				values[key] = v
+			}
 		}
+		data[customfield.FieldCustomFields] = values
+	}
+	if input.Tags != nil {
+		tags, err := userTags(input.Tags)
+		if err != nil {
+			return nil, err
+		}
+		data[customfield.FieldTags] = tags
 	}
 
-	user := &model.User{
-		UserID:            userRecord.UID,
-		FirstName:         input.FirstName,
-		LastName:          input.LastName,
-		FirstNameKatakana: input.FirstNameKatakana,
-		LastNameKatakana:  input.LastNameKatakana,
-		EmailAddress:      input.EmailAddress,
-		Role:              role,
-		Balance:           0.0,
-		Status:            model.UserStatusActive,
-		CreatedAt:         now,
-		UpdatedAt:         now,
+	// 同じ組織で登録済みのメールアドレスは CONFLICT（ユーザー一括取り込みと同じく大文字・小文字を区別しない）
+	users := r.FirestoreClient.Collection("users")
+	ref := users.NewDoc()
+	emails := []string{input.EmailAddress}
+	if key := importer.EmailKey(input.EmailAddress); key != input.EmailAddress {
+		emails = append(emails, key)
+	}
+	err = r.FirestoreClient.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		query := users.Where(tenant.FieldTenantID, "==", tenantID).Where("email_address", "in", emails)
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return apperr.Internal(err, "failed to look up existing users")
+		}
+		if len(softdelete.Active(docs)) > 0 {
+			return apperr.Conflict("email address is already registered: %s", input.EmailAddress)
+		}
+		return tx.Create(ref, data)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return user, nil
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to get user")
+	}
+	return userFromSnapshot(doc), nil
 }
 
 // UpdateUser is the resolver for the updateUser field.
 func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input model.UserUpdateInput) (*model.User, error) {
-	panic(fmt.Errorf("not implemented: UpdateUser - updateUser"))
+	updates := []firestore.Update{{Path: "updated_at", Value: time.Now()}}
+	updates = appendUpdate(updates, "first_name", input.FirstName)
+	updates = appendUpdate(updates, "last_name", input.LastName)
+	updates = appendUpdate(updates, "first_name_katakana", input.FirstNameKatakana)
+	updates = appendUpdate(updates, "last_name_katakana", input.LastNameKatakana)
+	updates = appendUpdate(updates, "email_address", input.EmailAddress)
+	updates = appendUpdate(updates, "balance", input.Balance)
+	if input.Role != nil {
+		updates = append(updates, firestore.Update{Path: "role", Value: firestoreEnum(input.Role)})
+	}
+	if input.Status != nil {
+		updates = append(updates, firestore.Update{Path: "status", Value: firestoreEnum(input.Status)})
+	}
+	if input.CustomFields != nil {
+		fields, err := customfield.List(ctx, r.FirestoreClient)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := customfield.Encode(fields, customFieldValuesFromInput(input.CustomFields))
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, customfield.Updates(encoded)...)
+	}
+	if input.Tags != nil {
+		tags, err := userTags(input.Tags)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, firestore.Update{Path: customfield.FieldTags, Value: tags})
+	}
+
+	doc, err := optimistic.Update(ctx, r.FirestoreClient, softdelete.Users, userID, input.Version, updates)
+	if err != nil {
+		return nil, err
+	}
+	return userFromSnapshot(doc), nil
 }
 
 // DeleteUser is the resolver for the deleteUser field.
 func (r *mutationResolver) DeleteUser(ctx context.Context, userID string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteUser - deleteUser"))
+	return r.softDelete(ctx, softdelete.Users, userID)
+}
+
+// RestoreUser is the resolver for the restoreUser field.
+func (r *mutationResolver) RestoreUser(ctx context.Context, userID string) (*model.User, error) {
+	return restoreDoc(ctx, r.FirestoreClient, softdelete.Users, userID, userFromSnapshot)
+}
+
+// SaveCustomField is the resolver for the saveCustomField field.
+func (r *mutationResolver) SaveCustomField(ctx context.Context, input model.CustomFieldInput) (*model.CustomField, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	f, err := customfield.Save(ctx, r.FirestoreClient, customFieldFromInput(input))
+	if err != nil {
+		return nil, err
+	}
+	return customFieldToModel(f), nil
+}
+
+// DeleteCustomField is the resolver for the deleteCustomField field.
+func (r *mutationResolver) DeleteCustomField(ctx context.Context, id string) (bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return false, err
+	}
+
+	if err := customfield.Delete(ctx, r.FirestoreClient, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TagUsers is the resolver for the tagUsers field.
+func (r *mutationResolver) TagUsers(ctx context.Context, input model.UserTagsInput) ([]*model.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	docs, err := customfield.UpdateTags(ctx, r.FirestoreClient, input.UserIds, input.Tags, false)
+	if err != nil {
+		return nil, err
+	}
+	return usersFromSnapshots(docs), nil
+}
+
+// UntagUsers is the resolver for the untagUsers field.
+func (r *mutationResolver) UntagUsers(ctx context.Context, input model.UserTagsInput) ([]*model.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	docs, err := customfield.UpdateTags(ctx, r.FirestoreClient, input.UserIds, input.Tags, true)
+	if err != nil {
+		return nil, err
+	}
+	return usersFromSnapshots(docs), nil
+}
+
+// SaveSegment is the resolver for the saveSegment field.
+func (r *mutationResolver) SaveSegment(ctx context.Context, id *string, input model.SegmentInput) (*model.Segment, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	s, err := segment.Save(ctx, r.FirestoreClient, segmentFromInput(stringValue(id), input))
+	if err != nil {
+		return nil, err
+	}
+	return segmentToModel(s), nil
+}
+
+// DeleteSegment is the resolver for the deleteSegment field.
+func (r *mutationResolver) DeleteSegment(ctx context.Context, id string) (bool, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return false, err
+	}
+
+	if err := segment.Delete(ctx, r.FirestoreClient, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EvaluateSegments is the resolver for the evaluateSegments field.
+func (r *mutationResolver) EvaluateSegments(ctx context.Context) (*model.SegmentRun, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if r.Segments == nil {
+		return nil, apperr.New(apperr.CodeInternal, "segment engine is not configured")
+	}
+
+	run, err := r.Segments.Evaluate(ctx)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to evaluate segments")
+	}
+
+	result := &model.SegmentRun{
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+		Users:         run.Users,
+		StatusChanges: run.StatusChanges,
+	}
+	if run.ID != "" {
+		result.ID = &run.ID
+	}
+	return result, nil
+}
+
+// ImportUsers is the resolver for the importUsers field.
+func (r *mutationResolver) ImportUsers(ctx context.Context, file graphql.Upload, format *model.ImportFormat) (*model.ImportJob, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		jobFormat importer.Format
+		err       error
+	)
+	if format != nil {
+		jobFormat, err = importer.ParseFormat(format.String())
+	} else {
+		jobFormat, err = importer.DetectFormat(file.Filename)
+	}
+	if err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+
+	createdBy := ""
+	if caller := authn.CallerFromContext(ctx); caller != nil {
+		createdBy = caller.UID
+	}
+
+	job, err := importer.Create(ctx, r.FirestoreClient, file.File, file.Filename, jobFormat, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// 取り込みはレスポンスを返したあとも続けるため、リクエストのキャンセルを引き継がない
+	if !job.Done() && r.Importer != nil {
+		r.Importer.Start(context.WithoutCancel(ctx), job.ID)
+	}
+	return importJobToModel(job), nil
+}
+
+// RetryImportJob is the resolver for the retryImportJob field.
+func (r *mutationResolver) RetryImportJob(ctx context.Context, id string) (*model.ImportJob, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if r.Importer == nil {
+		return nil, apperr.New(apperr.CodeInternal, "import runner is not configured")
+	}
+
+	job, err := r.Importer.Retry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.Importer.Start(context.WithoutCancel(ctx), job.ID)
+	return importJobToModel(job), nil
 }
 
 // CreateWallet is the resolver for the createWallet field.
@@ -160,12 +388,28 @@ func (r *mutationResolver) CreateWallet(ctx context.Context, input model.WalletI
 
 // UpdateWallet is the resolver for the updateWallet field.
 func (r *mutationResolver) UpdateWallet(ctx context.Context, walletAddress string, input model.WalletUpdateInput) (*model.Wallet, error) {
-	panic(fmt.Errorf("not implemented: UpdateWallet - updateWallet"))
+	updates := []firestore.Update{{Path: "updated_at", Value: time.Now()}}
+	updates = appendUpdate(updates, "balance", input.Balance)
+	updates = appendUpdate(updates, "currency", input.Currency)
+	if input.Status != nil {
+		updates = append(updates, firestore.Update{Path: "status", Value: firestoreEnum(input.Status)})
+	}
+
+	doc, err := optimistic.Update(ctx, r.FirestoreClient, softdelete.Wallets, walletAddress, input.Version, updates)
+	if err != nil {
+		return nil, err
+	}
+	return walletFromSnapshot(doc), nil
 }
 
 // DeleteWallet is the resolver for the deleteWallet field.
 func (r *mutationResolver) DeleteWallet(ctx context.Context, walletAddress string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteWallet - deleteWallet"))
+	return r.softDelete(ctx, softdelete.Wallets, walletAddress)
+}
+
+// RestoreWallet is the resolver for the restoreWallet field.
+func (r *mutationResolver) RestoreWallet(ctx context.Context, walletAddress string) (*model.Wallet, error) {
+	return restoreDoc(ctx, r.FirestoreClient, softdelete.Wallets, walletAddress, walletFromSnapshot)
 }
 
 // CreateOrder is the resolver for the createOrder field.
@@ -174,33 +418,190 @@ func (r *mutationResolver) CreateOrder(ctx context.Context, input model.OrderInp
 }
 
 // UpdateOrderStatus is the resolver for the updateOrderStatus field.
-func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status model.OrderStatus) (*model.Order, error) {
-	panic(fmt.Errorf("not implemented: UpdateOrderStatus - updateOrderStatus"))
+func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status model.OrderStatus, version int) (*model.Order, error) {
+	// status_changed_at は orderStatusChanged サブスクリプションのリスナーが監視する
+	now := time.Now()
+	doc, err := optimistic.Update(ctx, r.FirestoreClient, softdelete.Orders, id, version, []firestore.Update{
+		{Path: "status", Value: strings.ToLower(string(status))},
+		{Path: "status_changed_at", Value: now},
+		{Path: "updated_at", Value: now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	order := orderFromSnapshot(doc)
+
+	r.Events.publishOrderStatusChanged(order, now)
+	return order, nil
 }
 
 // DeleteOrder is the resolver for the deleteOrder field.
 func (r *mutationResolver) DeleteOrder(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteOrder - deleteOrder"))
+	return r.softDelete(ctx, softdelete.Orders, id)
+}
+
+// RestoreOrder is the resolver for the restoreOrder field.
+func (r *mutationResolver) RestoreOrder(ctx context.Context, id string) (*model.Order, error) {
+	return restoreDoc(ctx, r.FirestoreClient, softdelete.Orders, id, orderFromSnapshot)
 }
 
 // CreateInteraction is the resolver for the createInteraction field.
 func (r *mutationResolver) CreateInteraction(ctx context.Context, input model.InteractionInput) (*model.Interaction, error) {
-	panic(fmt.Errorf("not implemented: CreateInteraction - createInteraction"))
+	// インタラクションはユーザーと同じ組織に作成する（スコープ外のユーザーは NOT_FOUND）
+	user, err := r.loadUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	data := map[string]interface{}{
+		"user_id":    input.UserID,
+		"tenant_id":  user.TenantID,
+		"type":       strings.ToLower(string(input.Type)),
+		"subject":    input.Subject,
+		"content":    input.Content,
+		"channel":    strings.ToLower(string(input.Channel)),
+		"status":     strings.ToLower(string(model.InteractionStatusPending)),
+		"created_at": now,
+		"updated_at": now,
+	}
+	if input.ScheduledAt != nil {
+		data["scheduled_at"] = *input.ScheduledAt
+	}
+	// assigned_at は interactionAssigned サブスクリプションのリスナーが監視する
+	assigned := input.AssignedTo != nil && *input.AssignedTo != ""
+	if assigned {
+		data["assigned_to"] = *input.AssignedTo
+		data["assigned_at"] = now
+	}
+
+	ref, _, err := r.FirestoreClient.Collection("interactions").Add(ctx, data)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to create interaction")
+	}
+
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to get interaction")
+	}
+	interaction := interactionFromSnapshot(doc)
+
+	if assigned {
+		r.Events.publishInteractionAssigned(interaction, now)
+	}
+	return interaction, nil
 }
 
 // UpdateInteractionStatus is the resolver for the updateInteractionStatus field.
-func (r *mutationResolver) UpdateInteractionStatus(ctx context.Context, id string, status model.InteractionStatus) (*model.Interaction, error) {
-	panic(fmt.Errorf("not implemented: UpdateInteractionStatus - updateInteractionStatus"))
+func (r *mutationResolver) UpdateInteractionStatus(ctx context.Context, id string, status model.InteractionStatus, version int) (*model.Interaction, error) {
+	now := time.Now()
+	updates := []firestore.Update{
+		{Path: "status", Value: strings.ToLower(string(status))},
+		{Path: "updated_at", Value: now},
+	}
+	if status == model.InteractionStatusCompleted {
+		updates = append(updates, firestore.Update{Path: "completed_at", Value: now})
+	}
+
+	doc, err := optimistic.Update(ctx, r.FirestoreClient, softdelete.Interactions, id, version, updates)
+	if err != nil {
+		return nil, err
+	}
+	return interactionFromSnapshot(doc), nil
 }
 
 // CompleteInteraction is the resolver for the completeInteraction field.
-func (r *mutationResolver) CompleteInteraction(ctx context.Context, id string) (*model.Interaction, error) {
-	panic(fmt.Errorf("not implemented: CompleteInteraction - completeInteraction"))
+func (r *mutationResolver) CompleteInteraction(ctx context.Context, id string, version int) (*model.Interaction, error) {
+	return r.UpdateInteractionStatus(ctx, id, model.InteractionStatusCompleted, version)
 }
 
 // DeleteInteraction is the resolver for the deleteInteraction field.
 func (r *mutationResolver) DeleteInteraction(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteInteraction - deleteInteraction"))
+	return r.softDelete(ctx, softdelete.Interactions, id)
+}
+
+// RestoreInteraction is the resolver for the restoreInteraction field.
+func (r *mutationResolver) RestoreInteraction(ctx context.Context, id string) (*model.Interaction, error) {
+	return restoreDoc(ctx, r.FirestoreClient, softdelete.Interactions, id, interactionFromSnapshot)
+}
+
+// CreateOrganization is the resolver for the createOrganization field.
+func (r *mutationResolver) CreateOrganization(ctx context.Context, input model.OrganizationInput) (*model.Organization, error) {
+	if err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	org := &tenant.Organization{ID: strings.TrimSpace(input.ID), Name: strings.TrimSpace(input.Name)}
+	applySettingsInput(&org.Settings, input.Settings)
+	org, err := tenant.Create(ctx, r.FirestoreClient, org)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新しい組織にも HOT / COLD の既定のセグメントを用意する
+	if err := segment.EnsureDefaults(tenant.WithScope(ctx, tenant.Scope{TenantID: org.ID}), r.FirestoreClient); err != nil {
+		return nil, apperr.Internal(err, "failed to create default segments")
+	}
+	return organizationToModel(org), nil
+}
+
+// UpdateOrganization is the resolver for the updateOrganization field.
+func (r *mutationResolver) UpdateOrganization(ctx context.Context, id *string, name *string, settings *model.OrganizationSettingsInput) (*model.Organization, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	orgID := stringValue(id)
+	if orgID == "" {
+		var err error
+		if orgID, err = tenant.ID(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	org, err := tenant.Update(ctx, r.FirestoreClient, orgID, func(org *tenant.Organization) {
+		setString(&org.Name, name)
+		applySettingsInput(&org.Settings, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return organizationToModel(org), nil
+}
+
+// ExportUsers is the resolver for the exportUsers field.
+func (r *mutationResolver) ExportUsers(ctx context.Context, format model.ExportFormat, search *string, status *model.UserStatus) (*model.ExportResult, error) {
+	return r.runExport(ctx, export.Users, format, export.Filter{
+		Search: stringValue(search),
+		Status: firestoreEnum(status),
+	})
+}
+
+// ExportWallets is the resolver for the exportWallets field.
+func (r *mutationResolver) ExportWallets(ctx context.Context, format model.ExportFormat, userID *string, status *model.WalletStatus) (*model.ExportResult, error) {
+	return r.runExport(ctx, export.Wallets, format, export.Filter{
+		UserID: stringValue(userID),
+		Status: firestoreEnum(status),
+	})
+}
+
+// ExportOrders is the resolver for the exportOrders field.
+func (r *mutationResolver) ExportOrders(ctx context.Context, format model.ExportFormat, userID *string, status *model.OrderStatus, dateFrom *time.Time, dateTo *time.Time) (*model.ExportResult, error) {
+	return r.runExport(ctx, export.Orders, format, export.Filter{
+		UserID:   stringValue(userID),
+		Status:   firestoreEnum(status),
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+}
+
+// ExportInteractions is the resolver for the exportInteractions field.
+func (r *mutationResolver) ExportInteractions(ctx context.Context, format model.ExportFormat, userID *string, typeArg *model.InteractionType, status *model.InteractionStatus) (*model.ExportResult, error) {
+	return r.runExport(ctx, export.Interactions, format, export.Filter{
+		UserID: stringValue(userID),
+		Type:   firestoreEnum(typeArg),
+		Status: firestoreEnum(status),
+	})
 }
 
 // GetAvatarUploadURL is the resolver for the getAvatarUploadUrl field.
@@ -214,13 +615,13 @@ func (r *mutationResolver) GetAvatarUploadURL(ctx context.Context, filename stri
 	// サービスアカウント情報を読み取り
 	serviceAccount, err := readServiceAccountKey(credentialsPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read service account: %v", err)
+		return nil, apperr.Internal(err, "failed to read service account")
 	}
 
 	// Firebase Storage クライアントを初期化
 	client, err := storage.NewClient(ctx, option.WithCredentialsFile(credentialsPath))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create storage client: %v", err)
+		return nil, apperr.Internal(err, "failed to create storage client")
 	}
 	defer client.Close()
 
@@ -277,7 +678,7 @@ func (r *mutationResolver) GetAvatarUploadURL(ctx context.Context, filename stri
 		PrivateKey:     []byte(serviceAccount.PrivateKey),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate upload URL: %v", err)
+		return nil, apperr.Internal(err, "failed to generate upload URL")
 	}
 
 	// ダウンロード用URLを生成
@@ -300,21 +701,74 @@ func (r *mutationResolver) GetFileUploadURL(ctx context.Context, filename string
 }
 
 // User is the resolver for the user field.
-func (r *queryResolver) User(ctx context.Context, userID string) (*model.User, error) {
-	panic(fmt.Errorf("not implemented: User - user"))
+func (r *orderResolver) User(ctx context.Context, obj *model.Order) (*model.User, error) {
+	return r.loadUser(ctx, obj.UserID)
 }
 
-// Users is the resolver for the users field.
-func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationInput, search *string, status *model.UserStatus) (*model.UserConnection, error) {
-	// Firestoreクライアントを初期化
-	client, err := getFirestoreClient(ctx)
+// Items is the resolver for the items field.
+func (r *orderResolver) Items(ctx context.Context, obj *model.Order) ([]*model.OrderItem, error) {
+	return r.loaders(ctx).OrderItemsByOrderID.Load(ctx, obj.ID)
+}
+
+// Order is the resolver for the order field.
+func (r *orderItemResolver) Order(ctx context.Context, obj *model.OrderItem) (*model.Order, error) {
+	order, err := r.loaders(ctx).OrderByID.Load(ctx, obj.OrderID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Firestore client: %v", err)
+		return nil, err
+	}
+	if order == nil {
+		return nil, apperr.NotFound("order not found: %s", obj.OrderID)
+	}
+	return order, nil
+}
+
+// ListStaff is the resolver for the listStaff field.
+func (r *queryResolver) ListStaff(ctx context.Context, role *model.StaffRole, includeDisabled *bool) ([]*model.StaffUser, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := services.BusinessUserFilter{IncludeDisabled: includeDisabled != nil && *includeDisabled}
+	if role != nil {
+		filter.Role = staffRoleClaim(*role)
+	}
+	staff, err := r.Auth.ListBusinessUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.StaffUser, len(staff))
+	for i, s := range staff {
+		result[i] = staffToModel(s)
+	}
+	return result, nil
+}
+
+// Staff is the resolver for the staff field.
+func (r *queryResolver) Staff(ctx context.Context, id string) (*model.StaffUser, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
 	}
-	defer client.Close()
 
+	staff, err := r.Auth.GetBusinessUser(ctx, id)
+	if apperr.Is(err, apperr.CodeNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return staffToModel(staff), nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, userID string) (*model.User, error) {
+	panic(fmt.Errorf("not implemented: User - user"))
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationInput, search *string, status *model.UserStatus, segment *string, tags []string, customFields []*model.CustomFieldFilterInput, customFieldSort *model.CustomFieldSortInput) (*model.UserConnection, error) {
 	// usersコレクションから取得
-	var query firestore.Query = client.Collection("users").Query
+	query := tenant.Query(ctx, r.FirestoreClient.Collection("users").Query)
 
 	// ステータスでフィルタリング（指定されている場合）
 	if status != nil {
@@ -336,6 +790,11 @@ func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationI
 		}
 	}
 
+	// セグメントでフィルタリング（指定されている場合）
+	if segment != nil && *segment != "" {
+		query = query.Where("segments", "array-contains", *segment)
+	}
+
 	// 検索文字列でフィルタリング（指定されている場合）
 	if search != nil && *search != "" {
 		// Firestoreでは部分マッチ検索が複雑なので、簡単な実装として前方一致を使用
@@ -348,97 +807,46 @@ func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationI
 		limit = *pagination.Limit
 	}
 
-	query = query.Limit(limit)
-
-	// データを取得
-	docs, err := query.Documents(ctx).GetAll()
+	// データを取得（論理削除済みは除く）
+	// タグ・カスタム項目の条件は、クエリで絞り込めるものを絞り込んだあと、
+	// maxCustomFieldScan 件までを読み取って絞り込み・並べ替えをする
+	custom := customFieldQuery(tags, customFields, customFieldSort)
+	var fields []*customfield.Field
+	fetch := limit
+	if !custom.IsEmpty() {
+		var err error
+		if fields, err = customfield.List(ctx, r.FirestoreClient); err != nil {
+			return nil, err
+		}
+		hasSegment := segment != nil && *segment != ""
+		hasSearch := search != nil && *search != ""
+		query = custom.Narrow(fields, query, hasSegment, !hasSearch)
+		fetch = maxCustomFieldScan + 1
+	}
+	docs, err := softdelete.ListActive(ctx, query, fetch)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get users from Firestore: %v", err)
+		return nil, apperr.Internal(err, "failed to get users from Firestore")
 	}
-
-	// Firestoreのデータを model.User に変換
-	users := make([]*model.User, 0, len(docs))
-	for _, doc := range docs {
-		data := doc.Data()
-
-		// time.Time型の処理
-		var createdAt, updatedAt time.Time
-		if ct, ok := data["created_at"].(time.Time); ok {
-			createdAt = ct
-		} else if ctStr, ok := data["created_at"].(string); ok {
-			// 文字列の場合はパース
-			if parsed, err := time.Parse(time.RFC3339, ctStr); err == nil {
-				createdAt = parsed
-			} else {
-				createdAt = time.Now() // デフォルト値
-			}
-		} else {
-			createdAt = time.Now() // デフォルト値
+	total, hasNext := len(docs), len(docs) >= limit
+	if !custom.IsEmpty() {
+		if len(docs) > maxCustomFieldScan {
+			return nil, apperr.Validation("more than %d users match; narrow the conditions with status, segment, search or custom field equality", maxCustomFieldScan)
 		}
-
-		if ut, ok := data["updated_at"].(time.Time); ok {
-			updatedAt = ut
-		} else if utStr, ok := data["updated_at"].(string); ok {
-			// 文字列の場合はパース
-			if parsed, err := time.Parse(time.RFC3339, utStr); err == nil {
-				updatedAt = parsed
-			} else {
-				updatedAt = time.Now() // デフォルト値
-			}
-		} else {
-			updatedAt = time.Now() // デフォルト値
+		if docs, err = custom.Apply(fields, docs); err != nil {
+			return nil, apperr.Validation("%v", err)
 		}
-
-		// ロールの変換
-		role := model.UserRoleUser // デフォルト
-		if roleStr, ok := data["role"].(string); ok {
-			switch roleStr {
-			case "admin":
-				role = model.UserRoleAdmin
-			case "moderator":
-				role = model.UserRoleModerator
-			default:
-				role = model.UserRoleUser
-			}
-		}
-
-		// ステータスの変換
-		userStatus := model.UserStatusActive // デフォルト
-		if statusStr, ok := data["status"].(string); ok {
-			switch statusStr {
-			case "inactive":
-				userStatus = model.UserStatusInactive
-			case "hot":
-				userStatus = model.UserStatusHot
-			case "cold":
-				userStatus = model.UserStatusCold
-			case "pending":
-				userStatus = model.UserStatusPending
-			default:
-				userStatus = model.UserStatusActive
-			}
-		}
-
-		// balanceの取得（デフォルト値を設定）
-		balance := 0.0
-		if bal, ok := data["balance"].(float64); ok {
-			balance = bal
-		}
-
-		user := &model.User{
-			UserID:            doc.Ref.ID,
-			FirstName:         getStringFromData(data, "first_name"),
-			LastName:          getStringFromData(data, "last_name"),
-			FirstNameKatakana: getStringFromData(data, "first_name_katakana"),
-			LastNameKatakana:  getStringFromData(data, "last_name_katakana"),
-			EmailAddress:      getStringFromData(data, "email_address"),
-			Role:              role,
-			Balance:           balance,
-			Status:            userStatus,
-			CreatedAt:         createdAt,
-			UpdatedAt:         updatedAt,
+		total, hasNext = len(docs), len(docs) > limit
+		if hasNext {
+			docs = docs[:limit]
 		}
+	}
 
+	// Firestoreのデータを model.User に変換
+	users := make([]*model.User, 0, len(docs))
+	userLoader := r.loaders(ctx).UserByID
+	for _, doc := range docs {
+		user := userFromSnapshot(doc)
+		userLoader.Prime(user.UserID, user)
 		users = append(users, user)
 	}
 
@@ -446,9 +854,9 @@ func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationI
 	pageInfo := &model.PageInfo{
 		Page:    1,
 		Limit:   limit,
-		Total:   len(users),
+		Total:   total,
 		Pages:   1,
-		HasNext: len(users) >= limit,
+		HasNext: hasNext,
 		HasPrev: false,
 	}
 
@@ -458,6 +866,20 @@ func (r *queryResolver) Users(ctx context.Context, pagination *model.PaginationI
 	}, nil
 }
 
+// CustomFields is the resolver for the customFields field.
+func (r *queryResolver) CustomFields(ctx context.Context) ([]*model.CustomField, error) {
+	fields, err := customfield.List(ctx, r.FirestoreClient)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.CustomField, len(fields))
+	for i, f := range fields {
+		result[i] = customFieldToModel(f)
+	}
+	return result, nil
+}
+
 // Wallet is the resolver for the wallet field.
 func (r *queryResolver) Wallet(ctx context.Context, walletAddress string) (*model.Wallet, error) {
 	panic(fmt.Errorf("not implemented: Wallet - wallet"))
@@ -465,15 +887,8 @@ func (r *queryResolver) Wallet(ctx context.Context, walletAddress string) (*mode
 
 // Wallets is the resolver for the wallets field.
 func (r *queryResolver) Wallets(ctx context.Context, pagination *model.PaginationInput, userID *string, status *model.WalletStatus) (*model.WalletConnection, error) {
-	// Firestoreクライアントを初期化
-	client, err := getFirestoreClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get Firestore client: %v", err)
-	}
-	defer client.Close()
-
 	// walletsコレクションから取得
-	var query firestore.Query = client.Collection("wallets").Query
+	query := tenant.Query(ctx, r.FirestoreClient.Collection("wallets").Query)
 
 	// ユーザーIDでフィルタリング（指定されている場合）
 	if userID != nil && *userID != "" {
@@ -502,82 +917,16 @@ func (r *queryResolver) Wallets(ctx context.Context, pagination *model.Paginatio
 		limit = *pagination.Limit
 	}
 
-	query = query.Limit(limit)
-
-	// データを取得
-	docs, err := query.Documents(ctx).GetAll()
+	// データを取得（論理削除済みは除く）
+	docs, err := softdelete.ListActive(ctx, query, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get wallets from Firestore: %v", err)
+		return nil, apperr.Internal(err, "failed to get wallets from Firestore")
 	}
 
 	// Firestoreのデータを model.Wallet に変換
 	wallets := make([]*model.Wallet, 0, len(docs))
 	for _, doc := range docs {
-		data := doc.Data()
-
-		// time.Time型の処理
-		var createdAt, updatedAt time.Time
-		if ct, ok := data["created_at"].(time.Time); ok {
-			createdAt = ct
-		} else if ctStr, ok := data["created_at"].(string); ok {
-			// 文字列の場合はパース
-			if parsed, err := time.Parse(time.RFC3339, ctStr); err == nil {
-				createdAt = parsed
-			} else {
-				createdAt = time.Now() // デフォルト値
-			}
-		} else {
-			createdAt = time.Now() // デフォルト値
-		}
-
-		if ut, ok := data["updated_at"].(time.Time); ok {
-			updatedAt = ut
-		} else if utStr, ok := data["updated_at"].(string); ok {
-			// 文字列の場合はパース
-			if parsed, err := time.Parse(time.RFC3339, utStr); err == nil {
-				updatedAt = parsed
-			} else {
-				updatedAt = time.Now() // デフォルト値
-			}
-		} else {
-			updatedAt = time.Now() // デフォルト値
-		}
-
-		// ステータスの変換
-		walletStatus := model.WalletStatusActive // デフォルト
-		if statusStr, ok := data["status"].(string); ok {
-			switch statusStr {
-			case "inactive":
-				walletStatus = model.WalletStatusInactive
-			case "frozen":
-				walletStatus = model.WalletStatusFrozen
-			default:
-				walletStatus = model.WalletStatusActive
-			}
-		}
-
-		// balanceの取得（デフォルト値を設定）
-		balance := 0.0
-		if bal, ok := data["balance"].(float64); ok {
-			balance = bal
-		}
-
-		wallet := &model.Wallet{
-			WalletAddress: getStringFromData(data, "wallet_address"),
-			UserID:        getStringFromData(data, "user_id"),
-			Balance:       balance,
-			Currency:      getStringFromData(data, "currency"),
-			Status:        walletStatus,
-			CreatedAt:     createdAt,
-			UpdatedAt:     updatedAt,
-		}
-
-		// wallet_addressが空の場合は、document IDを使用
-		if wallet.WalletAddress == "" {
-			wallet.WalletAddress = doc.Ref.ID
-		}
-
-		wallets = append(wallets, wallet)
+		wallets = append(wallets, walletFromSnapshot(doc))
 	}
 
 	// ページング情報を作成
@@ -616,153 +965,221 @@ func (r *queryResolver) Interactions(ctx context.Context, pagination *model.Pagi
 	panic(fmt.Errorf("not implemented: Interactions - interactions"))
 }
 
-// Dashboard is the resolver for the dashboard field.
-func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardData, error) {
-	panic(fmt.Errorf("not implemented: Dashboard - dashboard"))
-}
-
-// UserStats is the resolver for the userStats field.
-func (r *queryResolver) UserStats(ctx context.Context) (*model.UserStats, error) {
-	panic(fmt.Errorf("not implemented: UserStats - userStats"))
-}
+// Segments is the resolver for the segments field.
+func (r *queryResolver) Segments(ctx context.Context) ([]*model.Segment, error) {
+	segments, err := segment.List(ctx, r.FirestoreClient)
+	if err != nil {
+		return nil, err
+	}
 
-// WalletStats is the resolver for the walletStats field.
-func (r *queryResolver) WalletStats(ctx context.Context) (*model.WalletStats, error) {
-	panic(fmt.Errorf("not implemented: WalletStats - walletStats"))
+	result := make([]*model.Segment, len(segments))
+	for i, s := range segments {
+		result[i] = segmentToModel(s)
+	}
+	return result, nil
 }
 
-// OrderStats is the resolver for the orderStats field.
-func (r *queryResolver) OrderStats(ctx context.Context) (*model.OrderStats, error) {
-	panic(fmt.Errorf("not implemented: OrderStats - orderStats"))
+// Segment is the resolver for the segment field.
+func (r *queryResolver) Segment(ctx context.Context, id string) (*model.Segment, error) {
+	s, err := segment.Get(ctx, r.FirestoreClient, id)
+	if apperr.Is(err, apperr.CodeNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return segmentToModel(s), nil
 }
 
-// Health is the resolver for the health field.
-func (r *queryResolver) Health(ctx context.Context) (string, error) {
-	return "GraphQL server is healthy!", nil
+// ImportJob is the resolver for the importJob field.
+func (r *queryResolver) ImportJob(ctx context.Context, id string) (*model.ImportJob, error) {
+	job, err := importer.Get(ctx, r.FirestoreClient, id)
+	if apperr.Is(err, apperr.CodeNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return importJobToModel(job), nil
 }
 
-// Mutation returns generated.MutationResolver implementation.
-func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+// ImportJobs is the resolver for the importJobs field.
+func (r *queryResolver) ImportJobs(ctx context.Context, limit *int) ([]*model.ImportJob, error) {
+	n := 20
+	if limit != nil && *limit > 0 {
+		n = min(*limit, 100)
+	}
 
-// Query returns generated.QueryResolver implementation.
-func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+	jobs, err := importer.List(ctx, r.FirestoreClient, n)
+	if err != nil {
+		return nil, err
+	}
 
-type mutationResolver struct{ *Resolver }
-type queryResolver struct{ *Resolver }
+	result := make([]*model.ImportJob, len(jobs))
+	for i, job := range jobs {
+		result[i] = importJobToModel(job)
+	}
+	return result, nil
+}
 
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
+// AuditLogs is the resolver for the auditLogs field.
+func (r *queryResolver) AuditLogs(ctx context.Context, actorUID *string, entityType *string, entityID *string, dateFrom *time.Time, dateTo *time.Time, limit *int) ([]*model.AuditLog, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
 
-type ServiceAccountKey struct {
-	ClientEmail string `json:"client_email"`
-	PrivateKey  string `json:"private_key"`
-}
+	q := audit.Query{
+		ActorUID:   stringValue(actorUID),
+		EntityType: stringValue(entityType),
+		EntityID:   stringValue(entityID),
+		From:       dateFrom,
+		To:         dateTo,
+	}
+	if limit != nil {
+		q.Limit = *limit
+	}
 
-func readServiceAccountKey(filePath string) (*ServiceAccountKey, error) {
-	data, err := os.ReadFile(filePath)
+	entries, err := audit.List(ctx, r.FirestoreClient, q)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read service account file: %v", err)
+		return nil, err
 	}
 
-	var key ServiceAccountKey
-	if err := json.Unmarshal(data, &key); err != nil {
-		return nil, fmt.Errorf("failed to parse service account JSON: %v", err)
+	result := make([]*model.AuditLog, len(entries))
+	for i, e := range entries {
+		result[i] = auditLogToModel(e)
 	}
-
-	return &key, nil
+	return result, nil
 }
 
-// Firestoreクライアントを初期化
-func getFirestoreClient(ctx context.Context) (*firestore.Client, error) {
-	// 環境変数からサービスアカウントファイルのパスを取得
-	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-	if credentialsPath == "" {
-		credentialsPath = "./narratives-test-service-account.json" // デフォルト値
+// Organization is the resolver for the organization field.
+func (r *queryResolver) Organization(ctx context.Context, id *string) (*model.Organization, error) {
+	orgID := stringValue(id)
+	if orgID == "" {
+		var err error
+		if orgID, err = tenant.ID(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	opt := option.WithCredentialsFile(credentialsPath)
-
-	// Firebase App を初期化
-	app, err := firebase.NewApp(ctx, nil, opt)
+	org, err := tenant.Get(ctx, r.FirestoreClient, orgID)
+	if apperr.Is(err, apperr.CodeNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Firebase App: %v", err)
+		return nil, err
+	}
+	return organizationToModel(org), nil
+}
+
+// Organizations is the resolver for the organizations field.
+func (r *queryResolver) Organizations(ctx context.Context) ([]*model.Organization, error) {
+	if err := requireSuperAdmin(ctx); err != nil {
+		return nil, err
 	}
 
-	// Firestore クライアントを取得
-	client, err := app.Firestore(ctx)
+	orgs, err := tenant.List(ctx, r.FirestoreClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Firestore client: %v", err)
+		return nil, err
 	}
 
-	return client, nil
+	result := make([]*model.Organization, len(orgs))
+	for i, org := range orgs {
+		result[i] = organizationToModel(org)
+	}
+	return result, nil
 }
 
-// ヘルパー関数：Firestoreのデータから安全に文字列を取得
-func getStringFromData(data map[string]interface{}, key string) string {
-	if val, ok := data[key].(string); ok {
-		return val
-	}
-	return ""
+// Dashboard is the resolver for the dashboard field.
+func (r *queryResolver) Dashboard(ctx context.Context) (*model.DashboardData, error) {
+	panic(fmt.Errorf("not implemented: Dashboard - dashboard"))
 }
 
-// Firebase Appを取得するヘルパー関数
-func getFirebaseApp(ctx context.Context) (*firebase.App, error) {
-	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-	if credentialsPath == "" {
-		credentialsPath = "./narratives-test-service-account.json" // デフォルト値
-	}
-	opt := option.WithCredentialsFile(credentialsPath)
-	return firebase.NewApp(ctx, nil, opt)
+// UserStats is the resolver for the userStats field.
+func (r *queryResolver) UserStats(ctx context.Context) (*model.UserStats, error) {
+	panic(fmt.Errorf("not implemented: UserStats - userStats"))
 }
 
-// 一時パスワードを生成するヘルパー関数
-func generateTemporaryPassword() (string, error) {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	const length = 12
+// WalletStats is the resolver for the walletStats field.
+func (r *queryResolver) WalletStats(ctx context.Context) (*model.WalletStats, error) {
+	panic(fmt.Errorf("not implemented: WalletStats - walletStats"))
+}
 
-	password := make([]byte, length)
-	for i := range password {
-		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
-		if err != nil {
-			return "", err
-		}
-		password[i] = charset[n.Int64()]
-	}
+// OrderStats is the resolver for the orderStats field.
+func (r *queryResolver) OrderStats(ctx context.Context) (*model.OrderStats, error) {
+	panic(fmt.Errorf("not implemented: OrderStats - orderStats"))
+}
 
-	return string(password), nil
+// Health is the resolver for the health field.
+func (r *queryResolver) Health(ctx context.Context) (string, error) {
+	return "GraphQL server is healthy!", nil
 }
 
-// 招待メールを送信するヘルパー関数
-func sendWelcomeEmailDirect(ctx context.Context, input model.UserInput, tempPassword string) error {
-	// メール送信はフロントエンドのauthenticationEmailService.tsで処理される
-	// Firebase Auth連携も必要に応じてフロントエンドで実装
-	
-	return nil
+// OrderStatusChanged is the resolver for the orderStatusChanged field.
+func (r *subscriptionResolver) OrderStatusChanged(ctx context.Context, orderID *string, userID *string) (<-chan *model.Order, error) {
+	return r.Events.OrderStatusChanged.Subscribe(ctx, func(order *model.Order) bool {
+		return tenant.Contains(ctx, order.TenantID) && matches(orderID, order.ID) && matches(userID, order.UserID)
+	}), nil
 }
 
-// createWelcomeEmailNotification welcome_email通知をFirestoreに作成
-func createWelcomeEmailNotification(ctx context.Context, firestoreClient *firestore.Client, userID string) error {
-	// 通知データを作成
-	now := time.Now()
-	notificationData := map[string]interface{}{
-		"notification_id":   fmt.Sprintf("welcome_%s_%d", userID, now.Unix()),
-		"business_user_id":  userID,
-		"notification_type": "welcome_email",
-		"processed":         false,
-		"created_at":        now,
-		"updated_at":        now,
-	}
+// InteractionAssigned is the resolver for the interactionAssigned field.
+func (r *subscriptionResolver) InteractionAssigned(ctx context.Context, assignedTo *string) (<-chan *model.Interaction, error) {
+	return r.Events.InteractionAssigned.Subscribe(ctx, func(interaction *model.Interaction) bool {
+		return tenant.Contains(ctx, interaction.TenantID) && interaction.AssignedTo != nil && matches(assignedTo, *interaction.AssignedTo)
+	}), nil
+}
 
-	// notificationsコレクションに追加
-	_, _, err := firestoreClient.Collection("notifications").Add(ctx, notificationData)
-	if err != nil {
-		return fmt.Errorf("failed to create welcome email notification: %v", err)
-	}
+// NotificationCreated is the resolver for the notificationCreated field.
+func (r *subscriptionResolver) NotificationCreated(ctx context.Context, businessUserID *string) (<-chan *model.Notification, error) {
+	return r.Events.NotificationCreated.Subscribe(ctx, func(notification *model.Notification) bool {
+		if !tenant.Contains(ctx, notification.TenantID) {
+			return false
+		}
+		if businessUserID == nil {
+			return true
+		}
+		return notification.BusinessUserID != nil && *notification.BusinessUserID == *businessUserID
+	}), nil
+}
+
+// Wallets is the resolver for the wallets field.
+func (r *userResolver) Wallets(ctx context.Context, obj *model.User) ([]*model.Wallet, error) {
+	return r.loaders(ctx).WalletsByUserID.Load(ctx, obj.UserID)
+}
 
-	fmt.Printf("Welcome email通知を作成しました: UserID=%s\n", userID)
-	return nil
+// User is the resolver for the user field.
+func (r *walletResolver) User(ctx context.Context, obj *model.Wallet) (*model.User, error) {
+	return r.loadUser(ctx, obj.UserID)
 }
+
+// Interaction returns generated.InteractionResolver implementation.
+func (r *Resolver) Interaction() generated.InteractionResolver { return &interactionResolver{r} }
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+// Order returns generated.OrderResolver implementation.
+func (r *Resolver) Order() generated.OrderResolver { return &orderResolver{r} }
+
+// OrderItem returns generated.OrderItemResolver implementation.
+func (r *Resolver) OrderItem() generated.OrderItemResolver { return &orderItemResolver{r} }
+
+// Query returns generated.QueryResolver implementation.
+func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
+
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns generated.UserResolver implementation.
+func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
+
+// Wallet returns generated.WalletResolver implementation.
+func (r *Resolver) Wallet() generated.WalletResolver { return &walletResolver{r} }
+
+type interactionResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
+type orderItemResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/softdelete"
+)
+
+// softDelete 呼び出し元を deleted_by に記録して論理削除する（スタッフだけが削除できる）
+func (r *Resolver) softDelete(ctx context.Context, kind *softdelete.Kind, id string) (bool, error) {
+	caller, err := requireStaff(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := softdelete.Delete(ctx, r.FirestoreClient, kind, id, caller.UID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restoreDoc 論理削除を取り消し、復元したドキュメントを変換して返す（スタッフだけが復元できる）
+func restoreDoc[T any](ctx context.Context, client *firestore.Client, kind *softdelete.Kind, id string, convert func(*firestore.DocumentSnapshot) T) (T, error) {
+	var zero T
+	if _, err := requireStaff(ctx); err != nil {
+		return zero, err
+	}
+	if err := softdelete.Restore(ctx, client, kind, id); err != nil {
+		return zero, err
+	}
+	doc, err := client.Collection(kind.Collection).Doc(id).Get(ctx)
+	if err != nil {
+		return zero, apperr.Internal(err, "failed to get %s", kind.Name)
+	}
+	return convert(doc), nil
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/services"
+)
+
+// staffToModel services.BusinessUserData を model.StaffUser に変換
+func staffToModel(u *services.BusinessUserData) *model.StaffUser {
+	return &model.StaffUser{
+		ID:                u.BusinessUserID,
+		TenantID:          u.TenantID,
+		FirstName:         u.FirstName,
+		LastName:          u.LastName,
+		FirstNameKatakana: u.FirstNameKatakana,
+		LastNameKatakana:  u.LastNameKatakana,
+		EmailAddress:      u.EmailAddress,
+		Role:              staffRoleFromClaim(u.Role),
+		Disabled:          u.Disabled,
+		DisabledAt:        u.DisabledAt,
+		CreatedAt:         u.CreatedAt,
+		UpdatedAt:         u.UpdatedAt,
+	}
+}
+
+// staffRoleClaim StaffRole をカスタムクレーム role の値（小文字）に変換
+func staffRoleClaim(role model.StaffRole) string {
+	return strings.ToLower(role.String())
+}
+
+// staffRoleFromClaim カスタムクレーム role を StaffRole に変換（不明な値は USER）
+//
+// 以前の createUser は ADMIN のように大文字で保存していたため、大文字・小文字は区別しない。
+func staffRoleFromClaim(role string) model.StaffRole {
+	r := model.StaffRole(strings.ToUpper(role))
+	if !r.IsValid() {
+		return model.StaffRoleUser
+	}
+	return r
+}
+
+// requireStaffManager 呼び出し元がスタッフを管理できなければエラーを返す
+//
+// roles（付与するロール・変更前のロール）に SUPER_ADMIN が含まれる場合はスーパー管理者に限る。
+func requireStaffManager(ctx context.Context, roles ...model.StaffRole) error {
+	for _, role := range roles {
+		if role == model.StaffRoleSuperAdmin {
+			return requireSuperAdmin(ctx)
+		}
+	}
+	return requireAdmin(ctx)
+}
+
+// requireOtherStaff 呼び出し元自身のロール変更・無効化を拒否する（管理者が締め出されないようにする）
+func requireOtherStaff(ctx context.Context, uid string) error {
+	if caller := authn.CallerFromContext(ctx); caller != nil && caller.UID == uid {
+		return apperr.Validation("cannot change your own account")
+	}
+	return nil
+}
+
+// checkStaffUpdate 呼び出し元がスタッフのロール変更・無効化をできるか確認する
+//
+// 管理者だけが変更でき、変更前か変更後のロールが SUPER_ADMIN の場合はスーパー管理者に限る。
+// スコープ外の組織のスタッフは NOT_FOUND になる。
+func (r *Resolver) checkStaffUpdate(ctx context.Context, id string, newRole ...model.StaffRole) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	if err := requireOtherStaff(ctx, id); err != nil {
+		return err
+	}
+
+	staff, err := r.Auth.GetBusinessUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	return requireStaffManager(ctx, append(newRole, staffRoleFromClaim(staff.Role))...)
+}
@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+)
+
+// Firestore のコレクション
+const (
+	jobsCollection  = "import_jobs"
+	rowsCollection  = "rows" // import_jobs/{id}/rows に検証済みの行を保存する
+	usersCollection = "users"
+)
+
+const (
+	// MaxRows 1ファイルで取り込めるデータ行の上限
+	MaxRows = 10000
+	// maxStoredErrors ジョブに保存する行エラーの上限（件数は FailedCount / SkippedCount に残る）
+	maxStoredErrors = 500
+	// firestoreInLimit Firestore の "in" クエリに指定できる値の上限
+	firestoreInLimit = 30
+)
+
+// Status ジョブの状態
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Job ユーザー取り込みジョブ（import_jobs ドキュメント）
+//
+// ファイルは作成時に検証し、取り込む行を import_jobs/{id}/rows に保存する。
+// Runner は Cursor（処理済みの行ドキュメントID）から先を処理するため、
+// サーバーの再起動や失敗のあとも途中から再開できる。
+type Job struct {
+	ID        string `firestore:"-"`
+	FileName  string `firestore:"file_name"`
+	Format    Format `firestore:"format"`
+	Status    Status `firestore:"status"`
+	CreatedBy string `firestore:"created_by,omitempty"`
+	// TenantID 取り込んだユーザーを登録する組織（作成時の呼び出し元のスコープ）
+	TenantID string `firestore:"tenant_id"`
+
+	// TotalRows ファイルのデータ行数。ProcessedRows は検証エラーの行を含む処理済みの行数
+	TotalRows     int `firestore:"total_rows"`
+	ProcessedRows int `firestore:"processed_rows"`
+	CreatedCount  int `firestore:"created_count"`
+	SkippedCount  int `firestore:"skipped_count"` // 登録済みの email_address
+	FailedCount   int `firestore:"failed_count"`  // 検証エラー・ファイル内の重複
+
+	Errors []RowError `firestore:"errors"`
+	Error  string     `firestore:"error,omitempty"` // ジョブ全体の失敗理由
+
+	Cursor         string    `firestore:"cursor"`
+	Owner          string    `firestore:"owner,omitempty"`
+	LeaseExpiresAt time.Time `firestore:"lease_expires_at"`
+
+	CreatedAt  time.Time  `firestore:"created_at"`
+	UpdatedAt  time.Time  `firestore:"updated_at"`
+	FinishedAt *time.Time `firestore:"finished_at"`
+}
+
+// Done ジョブが終了しているか
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+// addErrors 行エラーを保存上限まで追加する
+func (j *Job) addErrors(errs ...RowError) {
+	room := maxStoredErrors - len(j.Errors)
+	if room <= 0 {
+		return
+	}
+	if len(errs) > room {
+		errs = errs[:room]
+	}
+	j.Errors = append(j.Errors, errs...)
+}
+
+// stagedRow 取り込み待ちの行（import_jobs/{id}/rows ドキュメント）
+type stagedRow struct {
+	Line              int     `firestore:"line"`
+	FirstName         string  `firestore:"first_name"`
+	LastName          string  `firestore:"last_name"`
+	FirstNameKatakana string  `firestore:"first_name_katakana"`
+	LastNameKatakana  string  `firestore:"last_name_katakana"`
+	EmailAddress      string  `firestore:"email_address"`
+	Role              string  `firestore:"role"`
+	Balance           float64 `firestore:"balance"`
+	Status            string  `firestore:"status"`
+}
+
+func newStagedRow(row Row) stagedRow {
+	s := stagedRow{
+		Line:              row.Line,
+		FirstName:         row.Input.FirstName,
+		LastName:          row.Input.LastName,
+		FirstNameKatakana: row.Input.FirstNameKatakana,
+		LastNameKatakana:  row.Input.LastNameKatakana,
+		EmailAddress:      row.Input.EmailAddress,
+		Role:              "user",
+		Status:            "active",
+	}
+	// Firestore には小文字で保存する（graph/convert.go の変換に合わせる）
+	if row.Input.Role != nil {
+		s.Role = strings.ToLower(row.Input.Role.String())
+	}
+	if row.Input.Status != nil {
+		s.Status = strings.ToLower(row.Input.Status.String())
+	}
+	if row.Input.Balance != nil {
+		s.Balance = *row.Input.Balance
+	}
+	return s
+}
+
+// rowDocID 行番号順に並ぶドキュメントID
+func rowDocID(line int) string {
+	return fmt.Sprintf("%08d", line)
+}
+
+// Create ファイルを検証して取り込みジョブを作成する（取り込みは Runner で行う）
+//
+// ファイル全体が読めない場合や必須の列がない場合は VALIDATION エラーを返す。
+// 行ごとのエラーはジョブの Errors に記録し、その行は取り込まない。ユーザーはコンテキストの組織に登録する。
+func Create(ctx context.Context, client *firestore.Client, r io.Reader, fileName string, format Format, createdBy string) (*Job, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	header, records, err := ReadRecords(r, format)
+	if err != nil {
+		return nil, apperr.Validation("%s: %v", fileName, err)
+	}
+	if len(records) > MaxRows {
+		return nil, apperr.Validation("%s: too many rows (%d, max %d)", fileName, len(records), MaxRows)
+	}
+	rows, rowErrs, err := ParseUsers(header, records)
+	if err != nil {
+		return nil, apperr.Validation("%s: %v", fileName, err)
+	}
+
+	now := time.Now()
+	jobRef := client.Collection(jobsCollection).NewDoc()
+	job := &Job{
+		ID:            jobRef.ID,
+		FileName:      fileName,
+		Format:        format,
+		Status:        StatusPending,
+		CreatedBy:     createdBy,
+		TenantID:      tenantID,
+		TotalRows:     len(records),
+		ProcessedRows: len(rowErrs),
+		FailedCount:   len(rowErrs),
+		Errors:        []RowError{},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	job.addErrors(rowErrs...)
+
+	// 行を先に保存し、ジョブは最後に作成する（ジョブが見えていれば行はすべて揃っている）
+	bw := client.BulkWriter(ctx)
+	rowJobs := make([]*firestore.BulkWriterJob, 0, len(rows))
+	for _, row := range rows {
+		j, err := bw.Create(jobRef.Collection(rowsCollection).Doc(rowDocID(row.Line)), newStagedRow(row))
+		if err != nil {
+			bw.End()
+			return nil, apperr.Internal(err, "failed to stage import rows")
+		}
+		rowJobs = append(rowJobs, j)
+	}
+	bw.End()
+	for _, j := range rowJobs {
+		if _, err := j.Results(); err != nil {
+			return nil, apperr.Internal(err, "failed to stage import rows")
+		}
+	}
+
+	if len(rows) == 0 {
+		job.Status = StatusCompleted
+		job.FinishedAt = &now
+	}
+	if _, err := jobRef.Create(ctx, job); err != nil {
+		return nil, apperr.Internal(err, "failed to create import job")
+	}
+	return job, nil
+}
+
+// Get 取り込みジョブを取得する（スコープ外の組織のジョブは NOT_FOUND）
+func Get(ctx context.Context, client *firestore.Client, id string) (*Job, error) {
+	doc, err := client.Collection(jobsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("import job not found: %s", id)
+		}
+		return nil, apperr.Internal(err, "failed to get import job")
+	}
+	job, err := jobFromSnapshot(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.Contains(ctx, job.TenantID) {
+		return nil, apperr.NotFound("import job not found: %s", id)
+	}
+	return job, nil
+}
+
+// List スコープの組織の取り込みジョブを新しい順に取得する
+func List(ctx context.Context, client *firestore.Client, limit int) ([]*Job, error) {
+	docs, err := tenant.Query(ctx, client.Collection(jobsCollection).Query).
+		OrderBy("created_at", firestore.Desc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to list import jobs")
+	}
+
+	jobs := make([]*Job, 0, len(docs))
+	for _, doc := range docs {
+		job, err := jobFromSnapshot(doc)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func jobFromSnapshot(doc *firestore.DocumentSnapshot) (*Job, error) {
+	var job Job
+	if err := doc.DataTo(&job); err != nil {
+		return nil, apperr.Internal(err, "failed to decode import job %s", doc.Ref.ID)
+	}
+	job.ID = doc.Ref.ID
+	return &job, nil
+}
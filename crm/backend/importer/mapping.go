@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/validate"
+)
+
+// column UserInput のフィールドと、ヘッダーとして受け付ける列名
+type column struct {
+	field    string
+	required bool
+	aliases  []string
+}
+
+// columns 取り込み対象の列。ヘッダーは normalizeHeader で正規化して比較する
+var columns = []column{
+	{field: "last_name", required: true, aliases: []string{"lastname", "姓"}},
+	{field: "first_name", required: true, aliases: []string{"firstname", "名"}},
+	{field: "last_name_katakana", required: true, aliases: []string{"lastnamekatakana", "姓(カナ)", "セイ"}},
+	{field: "first_name_katakana", required: true, aliases: []string{"firstnamekatakana", "名(カナ)", "メイ"}},
+	{field: "email_address", required: true, aliases: []string{"email", "e_mail", "mail", "メールアドレス"}},
+	{field: "role", aliases: []string{"ロール"}},
+	{field: "balance", aliases: []string{"残高"}},
+	{field: "status", aliases: []string{"ステータス"}},
+}
+
+// Row 検証済みの取り込み行
+type Row struct {
+	Line  int
+	Input model.UserInput
+}
+
+// RowError 行ごとのエラー
+type RowError struct {
+	Line    int    `firestore:"line"`
+	Field   string `firestore:"field,omitempty"`
+	Message string `firestore:"message"`
+}
+
+// MapColumns ヘッダーから UserInput のフィールドごとの列番号を求める
+//
+// 未知の列は無視する。必須の列が足りない場合や、同じフィールドの列が複数ある場合はエラー。
+func MapColumns(header []string) (map[string]int, error) {
+	byName := make(map[string]string)
+	for _, c := range columns {
+		byName[c.field] = c.field
+		for _, alias := range c.aliases {
+			byName[normalizeHeader(alias)] = c.field
+		}
+	}
+
+	index := make(map[string]int)
+	for i, h := range header {
+		field, ok := byName[normalizeHeader(h)]
+		if !ok {
+			continue
+		}
+		if _, dup := index[field]; dup {
+			return nil, fmt.Errorf("duplicate column for %s: %q", field, h)
+		}
+		index[field] = i
+	}
+
+	var missing []string
+	for _, c := range columns {
+		if _, ok := index[c.field]; c.required && !ok {
+			missing = append(missing, c.field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+// normalizeHeader 列名を比較用に正規化する（"First Name" / "first-name" → "first_name"、全角括弧 → 半角）
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "_", "-", "_", "（", "(", "）", ")").Replace(h)
+}
+
+// ParseUsers データ行を UserInput に変換して検証する
+//
+// 検証エラーの行と、ファイル内で email_address が重複する行（2件目以降）は rows に含めず errs に返す。
+// email_address は大文字小文字を区別せずに比較する。
+func ParseUsers(header []string, records []Record) (rows []Row, errs []RowError, err error) {
+	index, err := MapColumns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]int) // 正規化した email_address → 最初に出現した行番号
+	for _, rec := range records {
+		input, rowErrs := parseUser(rec, index)
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+
+		key := EmailKey(input.EmailAddress)
+		if first, dup := seen[key]; dup {
+			errs = append(errs, RowError{
+				Line:    rec.Line,
+				Field:   "email_address",
+				Message: fmt.Sprintf("duplicate email_address in file (first seen on line %d)", first),
+			})
+			continue
+		}
+		seen[key] = rec.Line
+
+		rows = append(rows, Row{Line: rec.Line, Input: input})
+	}
+	return rows, errs, nil
+}
+
+func parseUser(rec Record, index map[string]int) (model.UserInput, []RowError) {
+	value := func(field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(rec.Values) {
+			return ""
+		}
+		return strings.TrimSpace(rec.Values[i])
+	}
+
+	input := model.UserInput{
+		FirstName:         value("first_name"),
+		LastName:          value("last_name"),
+		FirstNameKatakana: value("first_name_katakana"),
+		LastNameKatakana:  value("last_name_katakana"),
+		EmailAddress:      value("email_address"),
+	}
+
+	var errs []RowError
+	if s := value("role"); s != "" {
+		role := model.UserRole(strings.ToUpper(s))
+		if role.IsValid() {
+			input.Role = &role
+		} else {
+			errs = append(errs, RowError{Line: rec.Line, Field: "role", Message: fmt.Sprintf("invalid role: %q", s)})
+		}
+	}
+	if s := value("status"); s != "" {
+		status := model.UserStatus(strings.ToUpper(s))
+		if status.IsValid() {
+			input.Status = &status
+		} else {
+			errs = append(errs, RowError{Line: rec.Line, Field: "status", Message: fmt.Sprintf("invalid status: %q", s)})
+		}
+	}
+	if s := value("balance"); s != "" {
+		balance, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+		if err == nil {
+			input.Balance = &balance
+		} else {
+			errs = append(errs, RowError{Line: rec.Line, Field: "balance", Message: fmt.Sprintf("invalid number: %q", s)})
+		}
+	}
+
+	for _, fe := range validate.Struct("", input) {
+		errs = append(errs, RowError{Line: rec.Line, Field: fe.Field, Message: fe.Message})
+	}
+	return input, errs
+}
+
+// EmailKey 重複判定に使う email_address の正規化
+func EmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
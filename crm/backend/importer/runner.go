@@ -0,0 +1,380 @@
+package importer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+)
+
+// デフォルトの設定
+const (
+	DefaultChunkSize = 50
+	DefaultLease     = 2 * time.Minute
+)
+
+// ErrJobLocked 別の Runner（他のインスタンス）がジョブを処理中
+var ErrJobLocked = errors.New("import job is being processed by another runner")
+
+// Runner 取り込みジョブを実行する
+//
+// ジョブは ChunkSize 行ずつトランザクションで処理し、ユーザーの作成と
+// ジョブの進捗（Cursor・件数）を同時に更新する。途中で止まっても
+// 処理済みの行が二重に登録されることはない。
+//
+// 複数のインスタンスが同じジョブを処理しないよう、ジョブには Owner と
+// LeaseExpiresAt を記録する。リースはチャンクごとに延長され、
+// 処理していたインスタンスが停止した場合は期限切れのあと別の Runner が引き継ぐ。
+type Runner struct {
+	client *firestore.Client
+	owner  string
+
+	ChunkSize int
+	Lease     time.Duration
+
+	// OnProgress チャンクを処理するたびに呼ばれる（CLI の進捗表示など）
+	OnProgress func(*Job)
+}
+
+// NewRunner Runner のコンストラクタ
+func NewRunner(client *firestore.Client) *Runner {
+	return &Runner{
+		client:    client,
+		owner:     newOwnerID(),
+		ChunkSize: DefaultChunkSize,
+		Lease:     DefaultLease,
+	}
+}
+
+// newOwnerID リースの所有者ID（ホスト名とランダムな接尾辞）
+func newOwnerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%s", host, hex.EncodeToString(b))
+}
+
+// Start ジョブをバックグラウンドで実行する
+//
+// 別の Runner がリースを保持している場合は期限切れを待ってから引き継ぐ
+// （その間にジョブが終了すれば何もしない）。
+func (r *Runner) Start(ctx context.Context, id string) {
+	go func() {
+		for {
+			job, err := r.Run(ctx, id)
+			if errors.Is(err, ErrJobLocked) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(job.LeaseExpiresAt) + time.Second):
+					continue
+				}
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "import job stopped", slog.String("job_id", id), slog.Any("error", err))
+				return
+			}
+			slog.InfoContext(ctx, "import job finished",
+				slog.String("job_id", id),
+				slog.String("status", string(job.Status)),
+				slog.Int("created", job.CreatedCount),
+				slog.Int("skipped", job.SkippedCount),
+				slog.Int("failed", job.FailedCount))
+			return
+		}
+	}()
+}
+
+// ResumePending 未完了（pending / running）のジョブをすべてバックグラウンドで再開する。サーバーの起動時に呼ぶ
+func (r *Runner) ResumePending(ctx context.Context) error {
+	docs, err := r.client.Collection(jobsCollection).
+		Where("status", "in", []string{string(StatusPending), string(StatusRunning)}).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list unfinished import jobs: %w", err)
+	}
+	for _, doc := range docs {
+		slog.InfoContext(ctx, "resuming import job", slog.String("job_id", doc.Ref.ID))
+		r.Start(ctx, doc.Ref.ID)
+	}
+	return nil
+}
+
+// Retry 失敗したジョブを Cursor の位置から再開できる状態に戻す
+func (r *Runner) Retry(ctx context.Context, id string) (*Job, error) {
+	var job *Job
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		job, err = r.getJob(tx, id)
+		if err != nil {
+			return err
+		}
+		if !tenant.Contains(ctx, job.TenantID) {
+			return apperr.NotFound("import job not found: %s", id)
+		}
+		switch job.Status {
+		case StatusCompleted:
+			return apperr.Conflict("import job is already completed: %s", id)
+		case StatusFailed:
+			job.Status = StatusPending
+			job.Error = ""
+			job.FinishedAt = nil
+			job.UpdatedAt = time.Now()
+			return tx.Set(r.jobRef(id), job)
+		}
+		return nil // 実行中・待機中はそのまま
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Run ジョブを最後まで実行する（ctx が終了するまでブロック）
+//
+// ctx が終了した場合、ジョブは実行中のまま残り、リースの期限切れ後に再開できる。
+// 処理中のエラーではジョブを失敗にし、Retry で再開できる。
+func (r *Runner) Run(ctx context.Context, id string) (*Job, error) {
+	job, err := r.claim(ctx, id)
+	if err != nil || job.Done() {
+		return job, err
+	}
+
+	for {
+		var done bool
+		job, done, err = r.processChunk(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrJobLocked) || ctx.Err() != nil {
+				return job, err
+			}
+			return r.fail(id, err)
+		}
+		if r.OnProgress != nil {
+			r.OnProgress(job)
+		}
+		if done {
+			r.cleanup(ctx, id)
+			return job, nil
+		}
+	}
+}
+
+// claim ジョブのリースを取得して実行中にする
+func (r *Runner) claim(ctx context.Context, id string) (*Job, error) {
+	var job *Job
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		job, err = r.getJob(tx, id)
+		if err != nil || job.Done() {
+			return err
+		}
+
+		now := time.Now()
+		if job.Owner != "" && job.Owner != r.owner && now.Before(job.LeaseExpiresAt) {
+			return ErrJobLocked
+		}
+		job.Status = StatusRunning
+		job.Owner = r.owner
+		job.LeaseExpiresAt = now.Add(r.Lease)
+		job.UpdatedAt = now
+		return tx.Set(r.jobRef(id), job)
+	})
+	return job, err
+}
+
+// processChunk Cursor の次から ChunkSize 行を取り込む。残りの行がなければジョブを完了にする
+func (r *Runner) processChunk(ctx context.Context, id string) (*Job, bool, error) {
+	var (
+		job  *Job
+		done bool
+	)
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		job, err = r.getJob(tx, id)
+		if err != nil {
+			return err
+		}
+		if job.Owner != r.owner {
+			return ErrJobLocked
+		}
+
+		query := r.jobRef(id).Collection(rowsCollection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(r.ChunkSize)
+		if job.Cursor != "" {
+			query = query.StartAfter(job.Cursor)
+		}
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return fmt.Errorf("failed to read import rows: %w", err)
+		}
+
+		now := time.Now()
+		job.UpdatedAt = now
+		job.LeaseExpiresAt = now.Add(r.Lease)
+
+		done = len(docs) == 0
+		if done {
+			job.Status = StatusCompleted
+			job.Owner = ""
+			job.FinishedAt = &now
+			return tx.Set(r.jobRef(id), job)
+		}
+
+		rows := make([]stagedRow, len(docs))
+		for i, doc := range docs {
+			if err := doc.DataTo(&rows[i]); err != nil {
+				return fmt.Errorf("failed to decode import row %s: %w", doc.Ref.ID, err)
+			}
+		}
+
+		tenantID := job.TenantID
+		if tenantID == "" {
+			tenantID = tenant.DefaultID
+		}
+		existing, err := r.existingEmails(tx, tenantID, rows)
+		if err != nil {
+			return err
+		}
+
+		users := r.client.Collection(usersCollection)
+		for _, row := range rows {
+			key := EmailKey(row.EmailAddress)
+			if existing[key] {
+				job.SkippedCount++
+				job.addErrors(RowError{Line: row.Line, Field: "email_address", Message: "email_address is already registered"})
+				continue
+			}
+			existing[key] = true
+
+			err := tx.Create(users.NewDoc(), map[string]interface{}{
+				"first_name":          row.FirstName,
+				"last_name":           row.LastName,
+				"first_name_katakana": row.FirstNameKatakana,
+				"last_name_katakana":  row.LastNameKatakana,
+				"email_address":       row.EmailAddress,
+				"role":                row.Role,
+				"balance":             row.Balance,
+				"status":              row.Status,
+				"import_job_id":       id,
+				tenant.FieldTenantID:  tenantID,
+				"created_at":          now,
+				"updated_at":          now,
+			})
+			if err != nil {
+				return err
+			}
+			job.CreatedCount++
+		}
+
+		job.ProcessedRows += len(rows)
+		job.Cursor = docs[len(docs)-1].Ref.ID
+		return tx.Set(r.jobRef(id), job)
+	})
+	return job, done, err
+}
+
+// existingEmails rows の email_address のうち、組織の users に登録済みのもの（EmailKey で正規化）
+//
+// 既存データの大文字小文字の違いを拾うため、入力値と小文字の両方で検索する。
+// 他の組織に同じメールアドレスの顧客がいても取り込む。
+func (r *Runner) existingEmails(tx *firestore.Transaction, tenantID string, rows []stagedRow) (map[string]bool, error) {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, email := range []string{row.EmailAddress, EmailKey(row.EmailAddress)} {
+			if !seen[email] {
+				seen[email] = true
+				emails = append(emails, email)
+			}
+		}
+	}
+
+	existing := make(map[string]bool)
+	users := r.client.Collection(usersCollection).Where(tenant.FieldTenantID, "==", tenantID)
+	for start := 0; start < len(emails); start += firestoreInLimit {
+		end := min(start+firestoreInLimit, len(emails))
+		docs, err := tx.Documents(users.Where("email_address", "in", emails[start:end])).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up existing users: %w", err)
+		}
+		for _, doc := range docs {
+			if email, ok := doc.Data()["email_address"].(string); ok {
+				existing[EmailKey(email)] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+// fail ジョブを失敗にする（リースを保持している場合のみ）
+func (r *Runner) fail(id string, cause error) (*Job, error) {
+	// 呼び出し元の ctx が終了していても失敗を記録する
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var job *Job
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var err error
+		job, err = r.getJob(tx, id)
+		if err != nil {
+			return err
+		}
+		if job.Owner != r.owner {
+			return ErrJobLocked
+		}
+		now := time.Now()
+		job.Status = StatusFailed
+		job.Error = cause.Error()
+		job.Owner = ""
+		job.UpdatedAt = now
+		job.FinishedAt = &now
+		return tx.Set(r.jobRef(id), job)
+	})
+	if err != nil {
+		slog.Error("failed to mark import job as failed", slog.String("job_id", id), slog.Any("error", err))
+	}
+	return job, cause
+}
+
+// cleanup 完了したジョブの取り込み待ちの行を削除する（失敗してもジョブには影響しない）
+func (r *Runner) cleanup(ctx context.Context, id string) {
+	refs, err := r.jobRef(id).Collection(rowsCollection).DocumentRefs(ctx).GetAll()
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list staged import rows", slog.String("job_id", id), slog.Any("error", err))
+		return
+	}
+
+	bw := r.client.BulkWriter(ctx)
+	for _, ref := range refs {
+		if _, err := bw.Delete(ref); err != nil {
+			slog.WarnContext(ctx, "failed to delete staged import rows", slog.String("job_id", id), slog.Any("error", err))
+			break
+		}
+	}
+	bw.End()
+}
+
+func (r *Runner) jobRef(id string) *firestore.DocumentRef {
+	return r.client.Collection(jobsCollection).Doc(id)
+}
+
+func (r *Runner) getJob(tx *firestore.Transaction, id string) (*Job, error) {
+	doc, err := tx.Get(r.jobRef(id))
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("import job not found: %s", id)
+		}
+		return nil, apperr.Internal(err, "failed to get import job")
+	}
+	return jobFromSnapshot(doc)
+}
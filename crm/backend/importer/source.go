@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format 取り込むファイルの形式
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// utf8BOM Excel で保存した CSV の先頭に付くバイトオーダーマーク
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ParseFormat 形式名（大文字小文字を区別しない）を Format に変換
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatCSV, FormatXLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported import format: %q", s)
+}
+
+// DetectFormat ファイル名の拡張子から形式を判定
+func DetectFormat(fileName string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot detect import format of %q", fileName)
+	}
+	return ParseFormat(ext)
+}
+
+// Record ファイルの1行
+type Record struct {
+	Line   int // ファイル上の行番号（1始まり、ヘッダーは1行目）
+	Values []string
+}
+
+// ReadRecords ファイルを読み込み、ヘッダー行とデータ行を返す
+//
+// XLSX は最初のシートを対象にする。すべて空欄の行は読み飛ばす。
+func ReadRecords(r io.Reader, format Format) (header []string, records []Record, err error) {
+	var rows []Record
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatXLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported import format: %q", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for len(rows) > 0 && isBlank(rows[0].Values) {
+		rows = rows[1:]
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("file is empty")
+	}
+
+	for _, row := range rows[1:] {
+		if !isBlank(row.Values) {
+			records = append(records, row)
+		}
+	}
+	return rows[0].Values, records, nil
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	reader.FieldsPerRecord = -1 // 列数の違いは行ごとのエラーとして扱う
+	reader.TrimLeadingSpace = true
+
+	var rows []Record
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, Record{Line: line, Values: values})
+	}
+}
+
+func readXLSX(r io.Reader) ([]Record, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("XLSX has no sheets")
+	}
+
+	values, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX sheet %q: %w", sheets[0], err)
+	}
+
+	rows := make([]Record, len(values))
+	for i, v := range values {
+		rows[i] = Record{Line: i + 1, Values: v}
+	}
+	return rows, nil
+}
+
+func isBlank(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
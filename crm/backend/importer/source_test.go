@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "csv", want: FormatCSV},
+		{in: " XLSX ", want: FormatXLSX},
+		{in: "xls", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q (wantErr %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     Format
+		wantErr  bool
+	}{
+		{fileName: "users.csv", want: FormatCSV},
+		{fileName: "顧客一覧.XLSX", want: FormatXLSX},
+		{fileName: "archive.tar.csv", want: FormatCSV},
+		{fileName: "users.json", wantErr: true},
+		{fileName: "users", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.fileName)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, %v, want %q (wantErr %v)", tt.fileName, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadRecordsCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantHeader  []string
+		wantRecords []Record
+		wantErr     bool
+	}{
+		{
+			name:       "header and rows",
+			data:       "last_name,first_name\n山田,太郎\n佐藤,花子\n",
+			wantHeader: []string{"last_name", "first_name"},
+			wantRecords: []Record{
+				{Line: 2, Values: []string{"山田", "太郎"}},
+				{Line: 3, Values: []string{"佐藤", "花子"}},
+			},
+		},
+		{
+			name:        "byte order mark is removed",
+			data:        "\xEF\xBB\xBFlast_name,first_name\n山田,太郎\n",
+			wantHeader:  []string{"last_name", "first_name"},
+			wantRecords: []Record{{Line: 2, Values: []string{"山田", "太郎"}}},
+		},
+		{
+			name:       "blank rows are skipped and keep line numbers",
+			data:       "last_name,first_name\n山田,太郎\n,\n \n佐藤,花子\n",
+			wantHeader: []string{"last_name", "first_name"},
+			wantRecords: []Record{
+				{Line: 2, Values: []string{"山田", "太郎"}},
+				{Line: 5, Values: []string{"佐藤", "花子"}},
+			},
+		},
+		{
+			name:        "blank rows before the header",
+			data:        ",\nlast_name,first_name\n山田,太郎\n",
+			wantHeader:  []string{"last_name", "first_name"},
+			wantRecords: []Record{{Line: 3, Values: []string{"山田", "太郎"}}},
+		},
+		{
+			name:        "rows with a different number of columns",
+			data:        "last_name,first_name\n山田\n",
+			wantHeader:  []string{"last_name", "first_name"},
+			wantRecords: []Record{{Line: 2, Values: []string{"山田"}}},
+		},
+		{
+			name:       "header only",
+			data:       "last_name,first_name\n",
+			wantHeader: []string{"last_name", "first_name"},
+		},
+		{
+			name:    "empty file",
+			data:    "\xEF\xBB\xBF,\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed quotes",
+			data:    "last_name\n\"山田\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, records, err := ReadRecords(strings.NewReader(tt.data), FormatCSV)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(header, tt.wantHeader) {
+				t.Errorf("header = %q, want %q", header, tt.wantHeader)
+			}
+			if !reflect.DeepEqual(records, tt.wantRecords) {
+				t.Errorf("records = %v, want %v", records, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestReadRecordsXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	rows := [][]interface{}{
+		{"last_name", "first_name", "balance"},
+		{"山田", "太郎", 1200},
+		{nil, nil, nil},
+		{"佐藤", "花子"},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 2枚目以降のシートは読まない
+	f.NewSheet("Sheet2")
+	f.SetCellValue("Sheet2", "A1", "ignored")
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, records, err := ReadRecords(bytes.NewReader(buf.Bytes()), FormatXLSX)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if want := []string{"last_name", "first_name", "balance"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+	want := []Record{
+		{Line: 2, Values: []string{"山田", "太郎", "1200"}},
+		{Line: 4, Values: []string{"佐藤", "花子"}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %v, want %v", records, want)
+	}
+
+	if _, _, err := ReadRecords(strings.NewReader("not a zip"), FormatXLSX); err == nil {
+		t.Error("ReadRecords() accepted a file that is not XLSX")
+	}
+}
+
+func TestReadRecordsUnsupportedFormat(t *testing.T) {
+	if _, _, err := ReadRecords(strings.NewReader("a\n"), Format("json")); err == nil {
+		t.Error("ReadRecords() accepted an unsupported format")
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go/v4"
@@ -15,12 +16,34 @@ import (
 	"github.com/joho/godotenv"
 	"google.golang.org/api/option"
 
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/audit"
+	"narratives-crm-backend/authn"
+	"narratives-crm-backend/export"
 	"narratives-crm-backend/graph"
 	"narratives-crm-backend/graph/generated"
+	"narratives-crm-backend/importer"
+	"narratives-crm-backend/metrics"
+	"narratives-crm-backend/segment"
 	"narratives-crm-backend/services"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+	"narratives-crm-backend/tracing"
+	"narratives-crm-backend/validate"
+	"narratives-shared/cors"
+	"narratives-shared/health"
+	"narratives-shared/logging"
+	"narratives-shared/persisted"
+	"narratives-shared/querylimit"
+	"narratives-shared/ratelimit"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 var (
@@ -42,29 +65,23 @@ func initFirebase() error {
 		ProjectID: projectID,
 	}
 
+	// Firestore呼び出しをメトリクスとトレースに記録する
+	opts := append(metrics.FirestoreClientOptions(), tracing.FirestoreClientOptions()...)
+
 	// サービスアカウントキーのパス
 	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
-	var opt option.ClientOption
 
 	if credentialsPath != "" {
 		// 明示的に指定されたサービスアカウントキーを使用
-		opt = option.WithCredentialsFile(credentialsPath)
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
 	} else {
 		// Cloud Runのデフォルト認証情報を使用（これによりCloud Runで自動的に認証される）
 		// option.WithCredentials() を省略するとデフォルト認証情報が使用される
-		log.Println("Using default credentials for Firebase")
+		slog.Info("using default credentials for Firebase")
 	}
 
 	// Firebase Admin SDK を初期化
-	var app *firebase.App
-	var err error
-
-	if opt != nil {
-		app, err = firebase.NewApp(ctx, config, opt)
-	} else {
-		app, err = firebase.NewApp(ctx, config)
-	}
-
+	app, err := firebase.NewApp(ctx, config, opts...)
 	if err != nil {
 		return fmt.Errorf("error initializing firebase app: %v", err)
 	}
@@ -84,19 +101,30 @@ func initFirebase() error {
 	firebaseApp = app
 	authClient = client
 
-	log.Println("Firebase Admin SDK initialized successfully")
+	slog.Info("Firebase Admin SDK initialized", slog.String("project_id", projectID))
 	return nil
 }
 
 func main() {
 	// 環境変数を読み込み
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+	envErr := godotenv.Load()
+
+	// 構造化ロガーを初期化
+	logging.Setup(logging.ConfigFromEnv("narratives-crm"))
+	if envErr != nil {
+		slog.Warn(".env file not found, using system environment variables")
+	}
+
+	// OpenTelemetry トレーシングを初期化
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.ConfigFromEnv("narratives-crm"))
+	if err != nil {
+		slog.Error("tracing initialization failed", slog.Any("error", err))
+		shutdownTracing = func(context.Context) error { return nil }
 	}
 
 	// Firebase を初期化
 	if err := initFirebase(); err != nil {
-		log.Printf("Firebase initialization error: %v", err)
+		slog.Error("Firebase initialization failed", slog.Any("error", err))
 	}
 
 	// Firebase認証サービスを初期化
@@ -124,32 +152,21 @@ func main() {
 	host := os.Getenv("HOST")
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK")
 	})
 
-	// Environment info endpoint
-	http.HandleFunc("/env", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Liveness / Readiness プローブ
+	readiness := health.NewChecker(health.TimeoutFromEnv())
+	readiness.Register("firestore", health.FirestoreCheck(firestoreClient))
+	readiness.Register("firebase_auth", health.FirebaseAuthCheck(authClient))
+	readiness.Register("notification_watcher", notificationWatcher.HealthCheck)
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	http.Handle("/livez", health.LiveHandler())
+	http.Handle("/readyz", readiness.ReadyHandler())
 
+	// Environment info endpoint
+	http.HandleFunc("/env", func(w http.ResponseWriter, r *http.Request) {
 		env := map[string]string{
 			"environment": os.Getenv("GO_ENV"),
 			"project":     os.Getenv("GOOGLE_CLOUD_PROJECT"),
@@ -169,16 +186,6 @@ func main() {
 
 	// Firebase 接続テスト用エンドポイント
 	http.HandleFunc("/firebase/test", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		if firebaseApp == nil {
 			http.Error(w, "Firebase not initialized", http.StatusInternalServerError)
 			return
@@ -196,18 +203,8 @@ func main() {
 
 	// メール送信テスト用エンドポイント
 	http.HandleFunc("/email/test", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		// メールサービスステータス
-		log.Println("メールサービスは正常に動作しています")
+		slog.InfoContext(r.Context(), "email service status requested")
 
 		response := map[string]interface{}{
 			"status":  "success",
@@ -220,16 +217,6 @@ func main() {
 
 	// 特定の通知を手動で処理するエンドポイント
 	http.HandleFunc("/notification/process", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		if r.Method != "POST" {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -242,7 +229,8 @@ func main() {
 		}
 
 		// 通知処理機能は現在無効化されています
-		log.Printf("通知処理要求: %s (現在は監視のみ)", notificationID)
+		slog.InfoContext(r.Context(), "notification process requested (watch only)",
+			slog.String("notification_id", notificationID))
 
 		response := map[string]interface{}{
 			"status":  "info",
@@ -253,67 +241,205 @@ func main() {
 		json.NewEncoder(w).Encode(response)
 	})
 
+	// CORS ポリシーは全ルートに共通で適用する
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowedHeaders = append(corsConfig.AllowedHeaders, tenant.HeaderTenantID)
+	corsConfig.AllowedOrigins = append(corsConfig.AllowedOrigins,
+		"https://narratives-crm-site.web.app",
+		"https://narratives-crm-site.firebaseapp.com",
+		"https://narratives-crm.web.app",
+		"https://narratives-crm.firebaseapp.com",
+	)
+	corsConfig = cors.ConfigFromEnv(corsConfig)
+	corsHandler := cors.Middleware(corsConfig)
+
+	// サブスクリプションのイベント（ミューテーションと Firestore の変更リスナーから配信）
+	events := graph.NewEvents()
+	if firestoreClient != nil {
+		go events.Listen(ctx, firestoreClient)
+	}
+
+	// ユーザー一括取り込みジョブ（再起動で中断したジョブは途中から再開する）
+	var importRunner *importer.Runner
+	if firestoreClient != nil {
+		importRunner = importer.NewRunner(firestoreClient)
+		if err := importRunner.ResumePending(ctx); err != nil {
+			slog.Error("failed to resume import jobs", slog.Any("error", err))
+		}
+	}
+
+	// エクスポート（EXPORT_DIR が未指定の場合は Cloud Storage に保存して署名付きURLを返す）
+	var exporter *export.Exporter
+	if firestoreClient != nil {
+		if dest, err := export.DestinationFromEnv(ctx); err != nil {
+			slog.Error("export destination initialization failed", slog.Any("error", err))
+		} else {
+			exporter = &export.Exporter{Client: firestoreClient, Destination: dest}
+		}
+	}
+
+	// セグメント（RFM から HOT / COLD を定期的に更新）
+	var segments *segment.Engine
+	if firestoreClient != nil {
+		segmentConfig, err := segment.ConfigFromEnv()
+		if err != nil {
+			slog.Error("invalid segment configuration, using defaults", slog.Any("error", err))
+		}
+		segments = &segment.Engine{Client: firestoreClient, Config: segmentConfig}
+		if err := segment.EnsureDefaults(ctx, firestoreClient); err != nil {
+			slog.Error("failed to create default segments", slog.Any("error", err))
+		}
+		go segments.Schedule(ctx)
+	}
+
+	// 論理削除の保存期間を過ぎたドキュメントの完全削除
+	if firestoreClient != nil {
+		purgeConfig, err := softdelete.ConfigFromEnv()
+		if err != nil {
+			slog.Error("invalid purge configuration, using defaults", slog.Any("error", err))
+		}
+		purger := &softdelete.Purger{Client: firestoreClient, Config: purgeConfig}
+		go purger.Schedule(ctx)
+	}
+
 	// GraphQL設定
 	resolver := &graph.Resolver{
 		FirebaseApp:     firebaseApp,
 		AuthClient:      authClient,
 		FirestoreClient: firestoreClient,
+		Auth:            firebaseAuthService,
+		Events:          events,
+		Importer:        importRunner,
+		Exporter:        exporter,
+		Segments:        segments,
 	}
 
-	config := generated.Config{Resolvers: resolver}
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(config))
+	config := generated.Config{Resolvers: resolver, Complexity: graph.Complexity()}
+	srv := handler.New(generated.NewExecutableSchema(config))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return corsConfig.AllowsOrigin(r.Header.Get("Origin"))
+			},
+		},
+		InitFunc: authn.WebsocketInitFunc(authClient),
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+
+	// エラーは extensions.code 付きで返し、本番環境では内部エラーの詳細を隠す
+	srv.SetErrorPresenter(apperr.ErrorPresenter(os.Getenv("GO_ENV") == "production"))
+	srv.SetRecoverFunc(apperr.RecoverFunc)
+
+	// 呼び出し元の組織（スーパー管理者は X-Tenant-ID ヘッダーの組織）にスコープを限定する
+	srv.Use(tenant.GraphQLExtension{})
+
+	// 入力型の validate タグ（スキーマの @goTag）に従って引数を検証
+	srv.Use(validate.GraphQLExtension{})
 
-	// GraphQLエンドポイント
-	http.Handle("/graphql", corsMiddleware(srv))
+	// ミューテーションごとに実行者・引数・変更前後の差分を audit_logs に記録
+	srv.Use(audit.GraphQLExtension{Client: firestoreClient, Entities: graph.AuditEntities})
+
+	// 永続化クエリ（APQ）。PERSISTED_QUERIES_ALLOWLIST=true の場合はマニフェストのクエリのみ実行
+	persistedConfig, err := persisted.ConfigFromEnv()
+	if err != nil {
+		slog.Error("invalid persisted query configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	persistedExt, manifest, err := persisted.Extension(persistedConfig)
+	if err != nil {
+		slog.Error("failed to load persisted query manifest", slog.Any("error", err))
+		os.Exit(1)
+	}
+	srv.Use(persistedExt)
+	slog.Info("persisted queries configured",
+		slog.Int("manifest_queries", manifest.Len()), slog.Bool("allow_list", persistedConfig.AllowList))
+
+	// クエリの複雑度・深さの上限（User.wallets ⇄ Wallet.user などの循環対策）
+	queryLimits, err := querylimit.ConfigFromEnv()
+	if err != nil {
+		slog.Error("invalid GraphQL query limit configuration, using defaults", slog.Any("error", err))
+	}
+	for _, ext := range querylimit.Extensions(queryLimits) {
+		srv.Use(ext)
+	}
+	srv.Use(metrics.GraphQLExtension{})
+	srv.Use(tracing.GraphQLExtension{})
+
+	// レート制限（認証済みならUID、未認証ならIPアドレス単位）
+	rateLimitConfig, err := ratelimit.ConfigFromEnv(ratelimit.Config{
+		Routes: map[string]ratelimit.Rule{
+			"/graphql":              {Rate: 300.0 / 60, Burst: 60},
+			"/api/auth/delete-user": {Rate: 10.0 / 60, Burst: 5},
+		},
+		Operations: map[string]ratelimit.Rule{
+			"createUser":         {Rate: 10.0 / 60, Burst: 5},
+			"getAvatarUploadUrl": {Rate: 20.0 / 60, Burst: 10},
+			"importUsers":        {Rate: 5.0 / 60, Burst: 2},
+			"exportUsers":        {Rate: 10.0 / 60, Burst: 3},
+			"exportWallets":      {Rate: 10.0 / 60, Burst: 3},
+			"exportOrders":       {Rate: 10.0 / 60, Burst: 3},
+			"exportInteractions": {Rate: 10.0 / 60, Burst: 3},
+			"evaluateSegments":   {Rate: 2.0 / 60, Burst: 1},
+		},
+	})
+	if err != nil {
+		slog.Error("invalid rate limit configuration, using defaults", slog.Any("error", err))
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rateLimitConfig, func(r *http.Request) string {
+		if caller := authn.CallerFromContext(r.Context()); caller != nil {
+			return "uid:" + caller.UID
+		}
+		return ratelimit.ClientIP(r)
+	})
+	srv.Use(ratelimit.GraphQLExtension{Limiter: limiter})
+
+	// GraphQLエンドポイント（リレーションはリクエスト単位の DataLoader でまとめて取得）
+	http.Handle("/graphql", graph.LoaderMiddleware(firestoreClient)(srv))
+
+	// Prometheusメトリクス
+	http.Handle("/metrics", metrics.Handler())
 
 	// GraphQL Playground (開発環境用)
 	if os.Getenv("GO_ENV") != "production" {
 		http.Handle("/playground", playground.Handler("GraphQL playground", "/graphql"))
-		log.Println("GraphQL Playground available at http://localhost:" + port + "/playground")
+		slog.Info("GraphQL Playground available", slog.String("url", "http://localhost:"+port+"/playground"))
 	}
 
 	// Firebase Authenticationからユーザーを削除するAPI
 	http.HandleFunc("/api/auth/delete-user", func(w http.ResponseWriter, r *http.Request) {
-		// CORS ヘッダーを設定
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
 		if r.Method != "POST" {
-			log.Printf("DELETE USER API: メソッドが許可されていません: %s", r.Method)
+			slog.WarnContext(r.Context(), "delete user: method not allowed", slog.String("method", r.Method))
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		log.Printf("DELETE USER API: リクエストを受信しました")
-
 		// リクエストボディを解析
 		var requestData struct {
 			Email string `json:"email"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-			log.Printf("DELETE USER API: リクエストボディの解析に失敗: %v", err)
+			slog.WarnContext(r.Context(), "delete user: invalid request body", slog.Any("error", err))
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
 
-		log.Printf("DELETE USER API: ビジネスユーザー削除リクエスト - メール: %s", requestData.Email)
-
 		if requestData.Email == "" {
-			log.Printf("DELETE USER API: メールアドレスが空です")
+			slog.WarnContext(r.Context(), "delete user: email is empty")
 			http.Error(w, "Email is required", http.StatusBadRequest)
 			return
 		}
 
 		// Firebase認証からビジネスユーザーを削除
-		if err := firebaseAuthService.DeleteUserByEmail(ctx, requestData.Email); err != nil {
-			log.Printf("DELETE USER API: Firebase認証からのビジネスユーザー削除に失敗: %v", err)
+		if err := firebaseAuthService.DeleteUserByEmail(r.Context(), requestData.Email); err != nil {
+			slog.ErrorContext(r.Context(), "delete user: failed to delete business user from Firebase Auth",
+				slog.String("email", requestData.Email), slog.Any("error", err))
 			response := map[string]interface{}{
 				"success": false,
 				"message": fmt.Sprintf("ビジネスユーザー削除に失敗: %v", err),
@@ -325,7 +451,8 @@ func main() {
 			return
 		}
 
-		log.Printf("DELETE USER API: Firebase認証からビジネスユーザー %s を正常に削除しました", requestData.Email)
+		slog.InfoContext(r.Context(), "delete user: business user deleted from Firebase Auth",
+			slog.String("email", requestData.Email))
 
 		// 成功レスポンス
 		response := map[string]interface{}{
@@ -338,23 +465,17 @@ func main() {
 	})
 
 	addr := host + ":" + port
-	fmt.Printf("Server starting on %s...\n", addr)
-	fmt.Printf("GraphQL endpoint: http://%s/graphql\n", addr)
-	log.Fatal(http.ListenAndServe(addr, nil))
-}
-
-// CORSミドルウェア
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+	slog.Info("server starting", slog.String("addr", addr), slog.String("graphql_endpoint", "http://"+addr+"/graphql"))
+
+	var root http.Handler = http.DefaultServeMux
+	root = limiter.Middleware(root)
+	root = authn.Middleware(authClient)(root)
+	root = corsHandler(root)
+	root = logging.Middleware(root)
+	root = tracing.Middleware(root)
+
+	err = http.ListenAndServe(addr, root)
+	slog.Error("server stopped", slog.Any("error", err))
+	shutdownTracing(context.Background())
+	os.Exit(1)
 }
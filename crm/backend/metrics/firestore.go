@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreClientOptions Firestore の RPC 呼び出し回数を記録するクライアントオプション
+//
+// firebase.NewApp に渡すと、App から取得した Firestore クライアントの
+// 全ての呼び出しが FirestoreCalls に計上される。
+func FirestoreClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(firestoreUnaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(firestoreStreamInterceptor)),
+	}
+}
+
+func firestoreUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	FirestoreCalls.WithLabelValues(rpcName(method), status.Code(err).String()).Inc()
+	return err
+}
+
+func firestoreStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	FirestoreCalls.WithLabelValues(rpcName(method), status.Code(err).String()).Inc()
+	return stream, err
+}
+
+// rpcName "/google.firestore.v1.Firestore/RunQuery" から "RunQuery" を取り出す
+func rpcName(method string) string {
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		return method[i+1:]
+	}
+	return method
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphQLExtension オペレーションごとのレイテンシとエラー数を記録する gqlgen 拡張
+type GraphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "PrometheusMetrics"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (GraphQLExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse graphql.ResponseInterceptor の実装
+func (GraphQLExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	start := opCtx.Stats.OperationStart
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	resp := next(ctx)
+
+	name, opType := operationLabels(opCtx)
+	GraphQLOperationDuration.WithLabelValues(name, opType).Observe(time.Since(start).Seconds())
+	if resp != nil && len(resp.Errors) > 0 {
+		GraphQLOperationErrors.WithLabelValues(name, opType).Add(float64(len(resp.Errors)))
+	}
+	return resp
+}
+
+// operationLabels メトリクスのラベルに使うオペレーション名と種別
+//
+// クライアントが付けるオペレーション名はラベルの組み合わせを際限なく増やせるため使わず、
+// スキーマで検証済みの先頭のルートフィールド名を使う（エイリアスは使わない）。
+func operationLabels(opCtx *graphql.OperationContext) (string, string) {
+	if opCtx.Operation == nil {
+		return "unknown", "unknown"
+	}
+
+	opType := string(opCtx.Operation.Operation)
+	if name := rootField(opCtx.Operation.SelectionSet); name != "" {
+		return name, opType
+	}
+	return "unknown", opType
+}
+
+// rootField 選択セットの先頭のフィールド名（フラグメントの中も探す）
+func rootField(set ast.SelectionSet) string {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			return sel.Name
+		case *ast.InlineFragment:
+			if name := rootField(sel.SelectionSet); name != "" {
+				return name
+			}
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				if name := rootField(sel.Definition.SelectionSet); name != "" {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
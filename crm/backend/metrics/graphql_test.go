@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Query { users: [User!]! user(id: ID!): User }
+type Mutation { deleteUser(id: ID!): Boolean! }
+type User { id: ID! }
+`
+
+func TestOperationLabels(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphqls", Input: testSchema})
+
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		wantName      string
+		wantType      string
+	}{
+		{name: "anonymous query", query: `{ users { id } }`, wantName: "users", wantType: "query"},
+		{
+			name:     "client operation names are not used",
+			query:    `query RandomName123 { user(id: "1") { id } }`,
+			wantName: "user", wantType: "query",
+		},
+		{
+			name:          "operation name selects the operation",
+			query:         `query A { users { id } } mutation B { deleteUser(id: "1") }`,
+			operationName: "B",
+			wantName:      "deleteUser", wantType: "mutation",
+		},
+		{name: "aliases are not used", query: `{ anything: users { id } }`, wantName: "users", wantType: "query"},
+		{
+			name:     "fields in fragments",
+			query:    `query { ...Q } fragment Q on Query { user(id: "1") { id } }`,
+			wantName: "user", wantType: "query",
+		},
+		{name: "inline fragments", query: `{ ... on Query { users { id } } }`, wantName: "users", wantType: "query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			if errs != nil {
+				t.Fatalf("failed to load query: %v", errs)
+			}
+			opCtx := &graphql.OperationContext{
+				OperationName: tt.operationName,
+				Operation:     doc.Operations.ForName(tt.operationName),
+			}
+
+			name, opType := operationLabels(opCtx)
+			if name != tt.wantName || opType != tt.wantType {
+				t.Errorf("operationLabels() = %q, %q, want %q, %q", name, opType, tt.wantName, tt.wantType)
+			}
+		})
+	}
+
+	t.Run("no operation", func(t *testing.T) {
+		if name, opType := operationLabels(&graphql.OperationContext{}); name != "unknown" || opType != "unknown" {
+			t.Errorf("operationLabels() = %q, %q, want unknown, unknown", name, opType)
+		}
+	})
+}
+
+func TestRPCName(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"/google.firestore.v1.Firestore/RunQuery", "RunQuery"},
+		{"/google.firestore.v1.Firestore/BatchGetDocuments", "BatchGetDocuments"},
+		{"Commit", "Commit"},
+	}
+	for _, tt := range tests {
+		if got := rpcName(tt.method); got != tt.want {
+			t.Errorf("rpcName(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "narratives_crm"
+
+// Registry CRMバックエンドのメトリクスを登録するレジストリ
+var Registry = prometheus.NewRegistry()
+
+var (
+	// GraphQLOperationDuration GraphQLオペレーションごとの処理時間
+	GraphQLOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "graphql",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of GraphQL operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	// GraphQLOperationErrors GraphQLオペレーションごとのエラー数
+	GraphQLOperationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "graphql",
+		Name:      "operation_errors_total",
+		Help:      "Number of GraphQL errors returned, by operation.",
+	}, []string{"operation", "type"})
+
+	// FirestoreCalls Firestore API の呼び出し回数
+	FirestoreCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "firestore",
+		Name:      "calls_total",
+		Help:      "Number of Firestore RPCs, by method and status code.",
+	}, []string{"method", "code"})
+
+	// WatcherCycleDuration NotificationWatcher の1サイクルの処理時間
+	WatcherCycleDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "notification_watcher",
+		Name:      "cycle_duration_seconds",
+		Help:      "Duration of NotificationWatcher check cycles.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+
+	// UnprocessedNotifications 未処理通知の件数
+	UnprocessedNotifications = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "notification_watcher",
+		Name:      "unprocessed_notifications",
+		Help:      "Number of notifications with processed == false.",
+	})
+
+	// MailDeliveries 直近24時間のメール配信状態ごとの件数
+	MailDeliveries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "notification_watcher",
+		Name:      "mail_deliveries",
+		Help:      "Number of mails started in the last 24 hours, by delivery state.",
+	}, []string{"state"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		GraphQLOperationDuration,
+		GraphQLOperationErrors,
+		FirestoreCalls,
+		WatcherCycleDuration,
+		UnprocessedNotifications,
+		MailDeliveries,
+	)
+}
+
+// Handler /metrics エンドポイント用のハンドラ
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,361 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Collection 実行状態を保存するコレクション
+const Collection = "_migrations"
+
+// デフォルトの設定
+const (
+	// DefaultBatchSize 1回のトランザクションで更新するドキュメント数（チェックポイントの書き込みを含めて 500 以下）
+	DefaultBatchSize = 200
+	// DefaultLockTimeout 実行中のまま更新が止まったマイグレーションを再実行できるようになるまでの時間
+	DefaultLockTimeout = 10 * time.Minute
+)
+
+// Migration ドキュメント単位のスキーマ変更
+//
+// Collections のドキュメントをドキュメントIDの順に Apply に渡し、返された更新を適用する。
+// Apply は何度実行しても同じ結果になるように書くこと（変更が不要なドキュメントには nil を返す）。
+type Migration struct {
+	// ID 実行順を決める一意なID（例: 0001_notifications_processed）。登録後は変更しない
+	ID          string
+	Description string
+	Collections []string
+	Apply       func(doc *firestore.DocumentSnapshot) ([]firestore.Update, error)
+}
+
+// Status マイグレーションの状態
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// State マイグレーションの実行状態（_migrations ドキュメント）
+type State struct {
+	ID     string `firestore:"-"`
+	Status Status `firestore:"status"`
+
+	// チェックポイント。Collections[CollectionIndex] の LastDocID まで適用済み
+	CollectionIndex int    `firestore:"collection_index"`
+	LastDocID       string `firestore:"last_doc_id"`
+
+	Scanned int    `firestore:"scanned"`
+	Updated int    `firestore:"updated"`
+	Error   string `firestore:"error,omitempty"`
+
+	StartedAt  time.Time  `firestore:"started_at"`
+	UpdatedAt  time.Time  `firestore:"updated_at"`
+	FinishedAt *time.Time `firestore:"finished_at"`
+}
+
+// Result Up で実行した（dry-run の場合は実行する予定の）マイグレーションの結果
+type Result struct {
+	ID      string
+	Scanned int
+	Updated int
+	DryRun  bool
+}
+
+// Runner 登録されたマイグレーションを ID の順に実行する
+type Runner struct {
+	Client      *firestore.Client
+	Migrations  []Migration
+	BatchSize   int
+	LockTimeout time.Duration
+
+	// DryRun 更新内容を数えてログに出すだけで、ドキュメントも実行状態も書き込まない
+	DryRun bool
+}
+
+// NewRunner Runner のコンストラクタ。マイグレーションの ID が重複している場合はエラー
+func NewRunner(client *firestore.Client, migrations []Migration) (*Runner, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for i, m := range sorted {
+		if m.ID == "" || m.Apply == nil || len(m.Collections) == 0 {
+			return nil, fmt.Errorf("migration %q must have an ID, collections and Apply", m.ID)
+		}
+		if i > 0 && sorted[i-1].ID == m.ID {
+			return nil, fmt.Errorf("duplicate migration ID: %s", m.ID)
+		}
+	}
+	return &Runner{
+		Client:      client,
+		Migrations:  sorted,
+		BatchSize:   DefaultBatchSize,
+		LockTimeout: DefaultLockTimeout,
+	}, nil
+}
+
+// States 登録されたマイグレーションの状態（未実行のものは StatusPending）
+func (r *Runner) States(ctx context.Context) ([]*State, error) {
+	states := make([]*State, len(r.Migrations))
+	for i, m := range r.Migrations {
+		s, err := r.state(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		states[i] = s
+	}
+	return states, nil
+}
+
+// Up 完了していないマイグレーションを順に実行する。target を指定した場合はその ID まで
+//
+// 失敗したマイグレーションはチェックポイントから再開し、失敗した時点で後続は実行しない。
+func (r *Runner) Up(ctx context.Context, target string) ([]Result, error) {
+	if target != "" && r.find(target) == nil {
+		return nil, fmt.Errorf("unknown migration: %s", target)
+	}
+
+	var results []Result
+	for _, m := range r.Migrations {
+		if target != "" && m.ID > target {
+			break
+		}
+		s, err := r.state(ctx, m.ID)
+		if err != nil {
+			return results, err
+		}
+		if s.Status == StatusCompleted {
+			continue
+		}
+
+		result, err := r.run(ctx, m, s)
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("migration %s failed: %w", m.ID, err)
+		}
+	}
+	return results, nil
+}
+
+func (r *Runner) find(id string) *Migration {
+	for i := range r.Migrations {
+		if r.Migrations[i].ID == id {
+			return &r.Migrations[i]
+		}
+	}
+	return nil
+}
+
+// run チェックポイントから再開してマイグレーションを最後まで実行する
+func (r *Runner) run(ctx context.Context, m Migration, s *State) (Result, error) {
+	result := Result{ID: m.ID, DryRun: r.DryRun}
+	if !r.DryRun {
+		if err := r.claim(ctx, m.ID); err != nil {
+			return result, err
+		}
+		result.Scanned, result.Updated = s.Scanned, s.Updated
+		slog.InfoContext(ctx, "migration started",
+			slog.String("migration", m.ID), slog.Int("collection_index", s.CollectionIndex), slog.String("last_doc_id", s.LastDocID))
+	} else {
+		// dry-run は常に最初から数える
+		s = &State{ID: m.ID}
+	}
+
+	err := r.apply(ctx, m, s, &result)
+	if r.DryRun {
+		return result, err
+	}
+
+	now := time.Now()
+	ref := r.Client.Collection(Collection).Doc(m.ID)
+	if err != nil {
+		if _, uerr := ref.Update(context.WithoutCancel(ctx), []firestore.Update{
+			{Path: "status", Value: StatusFailed},
+			{Path: "error", Value: err.Error()},
+			{Path: "updated_at", Value: now},
+		}); uerr != nil {
+			slog.ErrorContext(ctx, "failed to record migration failure", slog.String("migration", m.ID), slog.Any("error", uerr))
+		}
+		return result, err
+	}
+
+	_, err = ref.Update(ctx, []firestore.Update{
+		{Path: "status", Value: StatusCompleted},
+		{Path: "error", Value: firestore.Delete},
+		{Path: "updated_at", Value: now},
+		{Path: "finished_at", Value: now},
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to record migration completion: %w", err)
+	}
+	slog.InfoContext(ctx, "migration completed",
+		slog.String("migration", m.ID), slog.Int("scanned", result.Scanned), slog.Int("updated", result.Updated))
+	return result, nil
+}
+
+// apply Collections を順に処理する。バッチごとにドキュメントの更新とチェックポイントを同じトランザクションで書き込む
+func (r *Runner) apply(ctx context.Context, m Migration, s *State, result *Result) error {
+	batchSize := r.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	stateRef := r.Client.Collection(Collection).Doc(m.ID)
+
+	for ci := s.CollectionIndex; ci < len(m.Collections); ci++ {
+		collection := m.Collections[ci]
+		lastID := ""
+		if ci == s.CollectionIndex {
+			lastID = s.LastDocID
+		}
+
+		for {
+			query := r.Client.Collection(collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(batchSize)
+			if lastID != "" {
+				query = query.StartAfter(lastID)
+			}
+			docs, err := query.Documents(ctx).GetAll()
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", collection, err)
+			}
+			if len(docs) == 0 {
+				break
+			}
+
+			type write struct {
+				ref     *firestore.DocumentRef
+				updates []firestore.Update
+			}
+			var writes []write
+			for _, doc := range docs {
+				updates, err := m.Apply(doc)
+				if err != nil {
+					return fmt.Errorf("%s/%s: %w", collection, doc.Ref.ID, err)
+				}
+				if len(updates) > 0 {
+					writes = append(writes, write{ref: doc.Ref, updates: updates})
+				}
+			}
+			lastID = docs[len(docs)-1].Ref.ID
+			result.Scanned += len(docs)
+			result.Updated += len(writes)
+
+			if r.DryRun {
+				for _, w := range writes {
+					paths := make([]string, len(w.updates))
+					for i, u := range w.updates {
+						paths[i] = u.Path
+					}
+					slog.InfoContext(ctx, "dry-run: document would be updated",
+						slog.String("migration", m.ID), slog.String("document", collection+"/"+w.ref.ID), slog.Any("fields", paths))
+				}
+				continue
+			}
+
+			checkpoint := []firestore.Update{
+				{Path: "collection_index", Value: ci},
+				{Path: "last_doc_id", Value: lastID},
+				{Path: "scanned", Value: result.Scanned},
+				{Path: "updated", Value: result.Updated},
+				{Path: "updated_at", Value: time.Now()},
+			}
+			err = r.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+				for _, w := range writes {
+					if err := tx.Update(w.ref, w.updates); err != nil {
+						return err
+					}
+				}
+				return tx.Update(stateRef, checkpoint)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to write batch of %s after %s: %w", collection, lastID, err)
+			}
+		}
+
+		// 次のコレクションは最初から処理する
+		if !r.DryRun && ci+1 < len(m.Collections) {
+			_, err := stateRef.Update(ctx, []firestore.Update{
+				{Path: "collection_index", Value: ci + 1},
+				{Path: "last_doc_id", Value: ""},
+				{Path: "updated_at", Value: time.Now()},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save checkpoint: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// claim 実行中として記録する。他のプロセスが実行中の場合はエラー
+func (r *Runner) claim(ctx context.Context, id string) error {
+	ref := r.Client.Collection(Collection).Doc(id)
+	return r.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		doc, err := tx.Get(ref)
+		if err != nil && grpcstatus.Code(err) != codes.NotFound {
+			return err
+		}
+		if doc == nil || !doc.Exists() {
+			return tx.Create(ref, &State{Status: StatusRunning, StartedAt: now, UpdatedAt: now})
+		}
+
+		var s State
+		if err := doc.DataTo(&s); err != nil {
+			return err
+		}
+		if s.Status == StatusRunning && now.Sub(s.UpdatedAt) < r.LockTimeout {
+			return fmt.Errorf("migration %s is already running (last checkpoint at %s)", id, s.UpdatedAt.Format(time.RFC3339))
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "status", Value: StatusRunning},
+			{Path: "updated_at", Value: now},
+		})
+	})
+}
+
+// state 実行状態。未実行の場合は StatusPending
+func (r *Runner) state(ctx context.Context, id string) (*State, error) {
+	doc, err := r.Client.Collection(Collection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return &State{ID: id, Status: StatusPending}, nil
+		}
+		return nil, fmt.Errorf("failed to get migration state %s: %w", id, err)
+	}
+	var s State
+	if err := doc.DataTo(&s); err != nil {
+		return nil, fmt.Errorf("failed to decode migration state %s: %w", id, err)
+	}
+	s.ID = id
+	return &s, nil
+}
+
+// Unregistered _migrations にあるが登録されていないマイグレーションのID（削除・改名の確認用）
+func (r *Runner) Unregistered(ctx context.Context) ([]string, error) {
+	it := r.Client.Collection(Collection).Documents(ctx)
+	defer it.Stop()
+
+	var ids []string
+	for {
+		doc, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if r.find(doc.Ref.ID) == nil {
+			ids = append(ids, doc.Ref.ID)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/migrate"
+)
+
+// notificationsProcessed processed がない通知に false を設定する（旧 scripts/update_notifications.go）
+var notificationsProcessed = migrate.Migration{
+	ID:          "0001_notifications_processed",
+	Description: "processed がない通知に processed=false を設定",
+	Collections: []string{"notifications"},
+	Apply: func(doc *firestore.DocumentSnapshot) ([]firestore.Update, error) {
+		if _, ok := doc.Data()["processed"]; ok {
+			return nil, nil
+		}
+		return []firestore.Update{{Path: "processed", Value: false}}, nil
+	},
+}
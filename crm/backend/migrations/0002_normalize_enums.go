@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"strings"
+
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/migrate"
+)
+
+// enumFields コレクションごとの列挙値のフィールド
+var enumFields = map[string][]string{
+	"users":        {"status", "role"},
+	"wallets":      {"status"},
+	"orders":       {"status"},
+	"interactions": {"type", "channel", "status"},
+}
+
+// enumAliases 表記ゆれの置き換え（正規化後の値で比較）
+var enumAliases = map[string]string{
+	"canceled": "cancelled",
+}
+
+// normalizeEnums 列挙値を GraphQL の値を小文字にした形（例: IN_PROGRESS → in_progress）にそろえる
+//
+// 手作業や古いクライアントで "Active" や "in progress" のように保存された値は
+// 一覧の絞り込みに一致しないため、前後の空白を除いて小文字・アンダースコア区切りにする。
+var normalizeEnums = migrate.Migration{
+	ID:          "0002_normalize_enums",
+	Description: "status / role / type / channel を小文字・アンダースコア区切りにそろえる",
+	Collections: []string{"users", "wallets", "orders", "interactions"},
+	Apply: func(doc *firestore.DocumentSnapshot) ([]firestore.Update, error) {
+		data := doc.Data()
+		var updates []firestore.Update
+		for _, field := range enumFields[doc.Ref.Parent.ID] {
+			v, ok := data[field].(string)
+			if !ok {
+				continue
+			}
+			if n := normalizeEnum(v); n != v {
+				updates = append(updates, firestore.Update{Path: field, Value: n})
+			}
+		}
+		return updates, nil
+	},
+}
+
+func normalizeEnum(v string) string {
+	n := strings.ToLower(strings.TrimSpace(v))
+	n = strings.NewReplacer(" ", "_", "-", "_").Replace(n)
+	if alias, ok := enumAliases[n]; ok {
+		return alias
+	}
+	return n
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/migrate"
+	"narratives-crm-backend/tenant"
+)
+
+// defaultTenant マルチテナント化より前のドキュメントを既定の組織（tenant.DefaultID）に所属させる
+//
+// 一覧は tenant_id で絞り込むため、tenant_id がないドキュメントはどの組織の一覧にも含まれない。
+// audit_logs は作成後に変更しないため対象にしない（tenant_id フィールドがない監査ログは参照時に既定の組織として扱う）。
+var defaultTenant = migrate.Migration{
+	ID:          "0003_default_tenant",
+	Description: "tenant_id がないドキュメントに tenant_id=default を設定",
+	Collections: []string{
+		"users", "wallets", "orders", "order_items", "interactions",
+		"business_users", "notifications", "segments", "import_jobs",
+	},
+	Apply: func(doc *firestore.DocumentSnapshot) ([]firestore.Update, error) {
+		if id, ok := doc.Data()[tenant.FieldTenantID].(string); ok && id != "" {
+			return nil, nil
+		}
+		return []firestore.Update{{Path: tenant.FieldTenantID, Value: tenant.DefaultID}}, nil
+	},
+}
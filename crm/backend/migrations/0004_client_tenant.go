@@ -0,0 +1,14 @@
+package migrations
+
+import "narratives-crm-backend/migrate"
+
+// clientTenant CRM の画面が直接作成した会社・ブランドを既定の組織（tenant.DefaultID）に所属させる
+//
+// Firestore のルールは tenant_id が呼び出し元の組織と一致するドキュメントだけを読み取らせるため、
+// tenant_id がないドキュメントは画面から見えなくなる。
+var clientTenant = migrate.Migration{
+	ID:          "0004_client_tenant",
+	Description: "tenant_id がない companies / brands に tenant_id=default を設定",
+	Collections: []string{"companies", "brands"},
+	Apply:       defaultTenant.Apply,
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import "narratives-crm-backend/migrate"
+
+// All 登録済みのマイグレーション（ID の順に実行される）
+//
+// 新しいマイグレーションはファイルを追加してここに加える。ID は _migrations の実行状態と
+// 対応付けられるため、一度適用したマイグレーションの ID と内容は変更しないこと。
+var All = []migrate.Migration{
+	notificationsProcessed,
+	normalizeEnums,
+	defaultTenant,
+	clientTenant,
+}
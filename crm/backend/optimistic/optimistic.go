@@ -0,0 +1,75 @@
+package optimistic
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+// FieldVersion 更新のたびに1つ増えるバージョン
+const FieldVersion = "version"
+
+// Version ドキュメントのバージョン（version がない古いドキュメントは 0）
+func Version(data map[string]interface{}) int {
+	switch v := data[FieldVersion].(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// Bump バージョンを確認せずに1つ進める更新（定期処理や論理削除など、画面の編集と競合しない更新で使う）
+func Bump() firestore.Update {
+	return firestore.Update{Path: FieldVersion, Value: firestore.Increment(1)}
+}
+
+// Update version が expected と一致する場合だけ updates を適用し、version を1つ進めて更新後のドキュメントを返す
+//
+// 画面で読み込んだあとに他の更新があった場合（version の不一致）と、ここでの読み取りから
+// 書き込みまでの間に他の更新があった場合（Firestore の前提条件 LastUpdateTime）の
+// どちらも CONFLICT エラーを返す。論理削除済みのドキュメントとスコープ外の組織のドキュメントは NOT_FOUND とする。
+func Update(ctx context.Context, client *firestore.Client, kind *softdelete.Kind, id string, expected int, updates []firestore.Update) (*firestore.DocumentSnapshot, error) {
+	ref := client.Collection(kind.Collection).Doc(id)
+
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("%s not found: %s", kind.Name, id)
+		}
+		return nil, apperr.Internal(err, "failed to get %s", kind.Name)
+	}
+	if softdelete.IsDeleted(doc.Data()) || !tenant.Allows(ctx, doc.Data()) {
+		return nil, apperr.NotFound("%s not found: %s", kind.Name, id)
+	}
+
+	current := Version(doc.Data())
+	if current != expected {
+		return nil, conflict(kind, id, expected)
+	}
+
+	updates = append(updates, firestore.Update{Path: FieldVersion, Value: current + 1})
+	if _, err := ref.Update(ctx, updates, firestore.LastUpdateTime(doc.UpdateTime)); err != nil {
+		if grpcstatus.Code(err) == codes.FailedPrecondition {
+			return nil, conflict(kind, id, expected)
+		}
+		return nil, apperr.Internal(err, "failed to update %s", kind.Name)
+	}
+
+	doc, err = ref.Get(ctx)
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to get %s", kind.Name)
+	}
+	return doc, nil
+}
+
+func conflict(kind *softdelete.Kind, id string, expected int) error {
+	return apperr.Conflict("%s %s was modified by another update since version %d; reload and try again", kind.Name, id, expected)
+}
@@ -0,0 +1,275 @@
+package optimistic
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+const documentsPath = "projects/test/databases/(default)/documents/"
+
+// fakeFirestore BatchGetDocuments と Commit だけを実装したメモリ上の Firestore
+//
+// Commit は Firestore と同じく前提条件 update_time が一致しない場合に FAILED_PRECONDITION を返す。
+type fakeFirestore struct {
+	pb.UnimplementedFirestoreServer
+
+	mu    sync.Mutex
+	docs  map[string]*pb.Document // ドキュメントのパス（users/u1）→ ドキュメント
+	clock time.Time
+
+	// afterGet 読み取りのあとに呼ばれる（読み取りと書き込みの間の他の更新を再現する）
+	afterGet func(f *fakeFirestore, path string)
+	commits  int
+}
+
+// put ドキュメントを保存し、update_time を進める。f.mu を保持した状態で呼ぶこと
+func (f *fakeFirestore) put(path string, fields map[string]*pb.Value) {
+	f.clock = f.clock.Add(time.Second)
+	now := timestamppb.New(f.clock)
+	doc, ok := f.docs[path]
+	if !ok {
+		doc = &pb.Document{Name: documentsPath + path, Fields: map[string]*pb.Value{}, CreateTime: now}
+		f.docs[path] = doc
+	}
+	for k, v := range fields {
+		doc.Fields[k] = v
+	}
+	doc.UpdateTime = now
+}
+
+func (f *fakeFirestore) BatchGetDocuments(req *pb.BatchGetDocumentsRequest, stream pb.Firestore_BatchGetDocumentsServer) error {
+	for _, name := range req.Documents {
+		path := strings.TrimPrefix(name, documentsPath)
+
+		f.mu.Lock()
+		resp := &pb.BatchGetDocumentsResponse{ReadTime: timestamppb.New(f.clock)}
+		if doc, ok := f.docs[path]; ok {
+			resp.Result = &pb.BatchGetDocumentsResponse_Found{Found: cloneDoc(doc)}
+		} else {
+			resp.Result = &pb.BatchGetDocumentsResponse_Missing{Missing: name}
+		}
+		f.mu.Unlock()
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+		if f.afterGet != nil {
+			f.afterGet(f, path)
+		}
+	}
+	return nil
+}
+
+func (f *fakeFirestore) Commit(_ context.Context, req *pb.CommitRequest) (*pb.CommitResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.commits++
+	resp := &pb.CommitResponse{}
+	for _, w := range req.Writes {
+		update := w.GetUpdate()
+		if update == nil {
+			return nil, grpcstatus.Error(codes.Unimplemented, "only updates are supported")
+		}
+		path := strings.TrimPrefix(update.Name, documentsPath)
+		doc, ok := f.docs[path]
+		if pre := w.GetCurrentDocument(); pre != nil {
+			if t := pre.GetUpdateTime(); t != nil && (!ok || !t.AsTime().Equal(doc.UpdateTime.AsTime())) {
+				return nil, grpcstatus.Error(codes.FailedPrecondition, "the stored version does not match the required base version")
+			}
+			if pre.GetExists() && !ok {
+				return nil, grpcstatus.Error(codes.NotFound, "no document to update")
+			}
+		}
+		fields := map[string]*pb.Value{}
+		for _, p := range w.GetUpdateMask().GetFieldPaths() {
+			fields[p] = update.Fields[p]
+		}
+		f.put(path, fields)
+		resp.WriteResults = append(resp.WriteResults, &pb.WriteResult{UpdateTime: timestamppb.New(f.clock)})
+	}
+	resp.CommitTime = timestamppb.New(f.clock)
+	return resp, nil
+}
+
+func cloneDoc(doc *pb.Document) *pb.Document {
+	fields := make(map[string]*pb.Value, len(doc.Fields))
+	for k, v := range doc.Fields {
+		fields[k] = v
+	}
+	return &pb.Document{Name: doc.Name, Fields: fields, CreateTime: doc.CreateTime, UpdateTime: doc.UpdateTime}
+}
+
+// newFakeClient fakeFirestore に接続した Firestore クライアント
+func newFakeClient(t *testing.T, fake *fakeFirestore) *firestore.Client {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterFirestoreServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := firestore.NewClient(context.Background(), "test", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestUpdate(t *testing.T) {
+	deletedAt := timestamppb.New(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		fields   map[string]*pb.Value // users/u1 の内容（nil の場合は存在しない）
+		expected int
+		scope    string // 呼び出し元の組織（空の場合はスコープなし）
+		afterGet func(f *fakeFirestore, path string)
+		wantCode apperr.Code // 空の場合は成功
+		wantVer  int
+	}{
+		{
+			name:     "matching version",
+			fields:   map[string]*pb.Value{"version": {ValueType: &pb.Value_IntegerValue{IntegerValue: 3}}},
+			expected: 3,
+			wantVer:  4,
+		},
+		{
+			name:     "document without a version",
+			fields:   map[string]*pb.Value{"first_name": {ValueType: &pb.Value_StringValue{StringValue: "太郎"}}},
+			expected: 0,
+			wantVer:  1,
+		},
+		{
+			name:     "stale version",
+			fields:   map[string]*pb.Value{"version": {ValueType: &pb.Value_IntegerValue{IntegerValue: 4}}},
+			expected: 3,
+			wantCode: apperr.CodeConflict,
+		},
+		{
+			name:     "updated between the read and the write",
+			fields:   map[string]*pb.Value{"version": {ValueType: &pb.Value_IntegerValue{IntegerValue: 3}}},
+			expected: 3,
+			afterGet: func(f *fakeFirestore, path string) {
+				f.mu.Lock()
+				defer f.mu.Unlock()
+				if f.commits == 0 {
+					f.put(path, map[string]*pb.Value{"status": {ValueType: &pb.Value_StringValue{StringValue: "hot"}}})
+				}
+			},
+			wantCode: apperr.CodeConflict,
+		},
+		{
+			name:     "missing document",
+			expected: 0,
+			wantCode: apperr.CodeNotFound,
+		},
+		{
+			name: "soft-deleted document",
+			fields: map[string]*pb.Value{
+				"version":    {ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+				"deleted_at": {ValueType: &pb.Value_TimestampValue{TimestampValue: deletedAt}},
+			},
+			expected: 1,
+			wantCode: apperr.CodeNotFound,
+		},
+		{
+			name: "document in the caller's organisation",
+			fields: map[string]*pb.Value{
+				"version":   {ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+				"tenant_id": {ValueType: &pb.Value_StringValue{StringValue: "acme"}},
+			},
+			expected: 1,
+			scope:    "acme",
+			wantVer:  2,
+		},
+		{
+			name: "document in another organisation",
+			fields: map[string]*pb.Value{
+				"version":   {ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+				"tenant_id": {ValueType: &pb.Value_StringValue{StringValue: "other"}},
+			},
+			expected: 1,
+			scope:    "acme",
+			wantCode: apperr.CodeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeFirestore{
+				docs:     map[string]*pb.Document{},
+				clock:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				afterGet: tt.afterGet,
+			}
+			if tt.fields != nil {
+				fake.put("users/u1", tt.fields)
+			}
+			client := newFakeClient(t, fake)
+			ctx := context.Background()
+			if tt.scope != "" {
+				ctx = tenant.WithScope(ctx, tenant.Scope{TenantID: tt.scope})
+			}
+
+			doc, err := Update(ctx, client, softdelete.Users, "u1", tt.expected,
+				[]firestore.Update{{Path: "first_name", Value: "次郎"}})
+			if tt.wantCode != "" {
+				if !apperr.Is(err, tt.wantCode) {
+					t.Fatalf("Update() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if got := Version(doc.Data()); got != tt.wantVer {
+				t.Errorf("version = %d, want %d", got, tt.wantVer)
+			}
+			if got := doc.Data()["first_name"]; got != "次郎" {
+				t.Errorf("first_name = %v, want 次郎", got)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		data map[string]interface{}
+		want int
+	}{
+		{map[string]interface{}{"version": int64(3)}, 3},
+		{map[string]interface{}{"version": float64(2)}, 2},
+		{map[string]interface{}{"version": "3"}, 0},
+		{map[string]interface{}{}, 0},
+	}
+	for _, tt := range tests {
+		if got := Version(tt.data); got != tt.want {
+			t.Errorf("Version(%v) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// デフォルトの設定
+const (
+	DefaultBufferSize  = 16
+	DefaultDedupWindow = time.Minute
+)
+
+// Bus プロセス内の Pub/Sub
+//
+// 同じ変更がミューテーションと Firestore のリスナーの両方から届くため、
+// Publish に渡したキーが dedupWindow 内に重複した場合は配信しない。
+// 受信側の処理が追いつかずバッファが一杯の購読者にはイベントを破棄する。
+type Bus[T any] struct {
+	name        string
+	bufferSize  int
+	dedupWindow time.Duration
+
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]*subscriber[T]
+	recent map[string]time.Time
+	now    func() time.Time
+}
+
+type subscriber[T any] struct {
+	ch     chan T
+	filter func(T) bool
+}
+
+// NewBus Bus のコンストラクタ。name はログに使う
+func NewBus[T any](name string) *Bus[T] {
+	return &Bus[T]{
+		name:        name,
+		bufferSize:  DefaultBufferSize,
+		dedupWindow: DefaultDedupWindow,
+		subs:        make(map[int]*subscriber[T]),
+		recent:      make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Publish 購読者にイベントを配信する。key が空でなければ重複を除外する
+func (b *Bus[T]) Publish(key string, event T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if key != "" {
+		now := b.now()
+		for k, at := range b.recent {
+			if now.Sub(at) > b.dedupWindow {
+				delete(b.recent, k)
+			}
+		}
+		if _, dup := b.recent[key]; dup {
+			return
+		}
+		b.recent[key] = now
+	}
+
+	for _, sub := range b.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			slog.Warn("subscriber is too slow, dropping event", slog.String("topic", b.name))
+		}
+	}
+}
+
+// Subscribe filter に一致するイベントを受け取るチャネルを返す（filter が nil なら全件）
+//
+// ctx が終了すると購読を解除してチャネルを閉じる。
+func (b *Bus[T]) Subscribe(ctx context.Context, filter func(T) bool) <-chan T {
+	sub := &subscriber[T]{ch: make(chan T, b.bufferSize), filter: filter}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, id)
+		close(sub.ch)
+		b.mu.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// Subscribers 現在の購読者数
+func (b *Bus[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
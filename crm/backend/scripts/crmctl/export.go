@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/export"
+)
+
+// exportResult export の結果
+type exportResult struct {
+	Dataset   string        `json:"dataset"`
+	Format    export.Format `json:"format"`
+	FileName  string        `json:"file_name"`
+	Location  string        `json:"location"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	Rows      int           `json:"rows"`
+}
+
+func newExportCommand(flags *globalFlags) *cobra.Command {
+	var (
+		datasetName, formatName string
+		outDir, bucket          string
+		expiry                  time.Duration
+		filter                  export.Filter
+		from, to                string
+	)
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "コレクションを CSV / JSON Lines / Parquet にエクスポートする",
+		Long: "--bucket を指定した場合は Cloud Storage に保存し、ダウンロード用の署名付きURLを表示する。\n" +
+			"--status / --type は Firestore に保存している値（小文字）で指定する。",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dataset, err := export.LookupDataset(datasetName)
+			if err != nil {
+				return err
+			}
+			format, err := export.ParseFormat(formatName)
+			if err != nil {
+				return err
+			}
+			if filter.DateFrom, err = parseTime(from); err != nil {
+				return fmt.Errorf("invalid --from: %w", err)
+			}
+			if filter.DateTo, err = parseTime(to); err != nil {
+				return fmt.Errorf("invalid --to: %w", err)
+			}
+
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			var dest export.Destination = export.LocalDir{Dir: outDir}
+			if bucket != "" {
+				storageClient, err := storage.NewClient(ctx, c.Options...)
+				if err != nil {
+					return fmt.Errorf("failed to create storage client: %w", err)
+				}
+				defer storageClient.Close()
+				dest = export.Bucket{Client: storageClient, Name: bucket, Prefix: export.DefaultPrefix, Expiry: expiry}
+			}
+
+			exporter := &export.Exporter{Client: c.Firestore, Destination: dest}
+			res, err := exporter.Export(ctx, export.Request{Dataset: dataset, Format: format, Filter: filter})
+			if err != nil {
+				return err
+			}
+
+			result := exportResult{
+				Dataset:   res.Dataset,
+				Format:    res.Format,
+				FileName:  res.FileName,
+				Location:  res.Location,
+				ExpiresAt: res.ExpiresAt,
+				Rows:      res.Rows,
+			}
+			return flags.output.write(cmd.OutOrStdout(), result, table{
+				header: []string{"DATASET", "FORMAT", "ROWS", "LOCATION"},
+				rows:   [][]string{{result.Dataset, string(result.Format), strconv.Itoa(result.Rows), result.Location}},
+			})
+		},
+	}
+	cmd.Flags().StringVar(&datasetName, "dataset", "", "users / wallets / orders / interactions")
+	cmd.Flags().StringVar(&formatName, "format", "csv", "csv / jsonl / parquet")
+	cmd.Flags().StringVar(&outDir, "out", ".", "出力先のディレクトリ")
+	cmd.Flags().StringVar(&bucket, "bucket", "", "Cloud Storage に保存する場合のバケット")
+	cmd.Flags().DurationVar(&expiry, "expiry", export.DefaultURLExpiry, "署名付きURLの有効期間")
+	cmd.Flags().StringVar(&filter.Search, "search", "", "first_name の前方一致（users）")
+	cmd.Flags().StringVar(&filter.UserID, "user-id", "", "user_id（wallets / orders / interactions）")
+	cmd.Flags().StringVar(&filter.Status, "status", "", "status")
+	cmd.Flags().StringVar(&filter.Type, "type", "", "type（interactions）")
+	cmd.Flags().StringVar(&from, "from", "", "order_date の開始（RFC3339、orders）")
+	cmd.Flags().StringVar(&to, "to", "", "order_date の終了（RFC3339、orders）")
+	_ = cmd.MarkFlagRequired("dataset")
+	return cmd
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
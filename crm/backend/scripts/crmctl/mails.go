@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/services"
+)
+
+func newMailsCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mails",
+		Short: "送信メール（Trigger Email 拡張の mails コレクション）",
+	}
+	cmd.AddCommand(
+		newMailsFailedCommand(flags),
+		newMailsReplayCommand(flags),
+	)
+	return cmd
+}
+
+// failedMail mails failed の結果
+type failedMail struct {
+	ID        string     `json:"id"`
+	To        string     `json:"to"`
+	Subject   string     `json:"subject"`
+	Error     string     `json:"error"`
+	Attempts  int        `json:"attempts"`
+	StartTime *time.Time `json:"start_time"`
+}
+
+func newMailsFailedCommand(flags *globalFlags) *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "failed",
+		Short: "送信に失敗したメールを表示する",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			found, err := services.NewMailService(c.Firestore).FailedMails(ctx, limit)
+			if err != nil {
+				return err
+			}
+
+			mails := make([]failedMail, len(found))
+			t := table{header: []string{"ID", "TO", "SUBJECT", "ATTEMPTS", "STARTED_AT", "ERROR"}}
+			for i, m := range found {
+				mails[i] = failedMail{ID: m.ID, To: m.To, Subject: m.Subject, Error: m.Error, Attempts: m.Attempts, StartTime: m.StartTime}
+				t.rows = append(t.rows, []string{m.ID, orDash(m.To), orDash(m.Subject), strconv.Itoa(m.Attempts), formatTime(m.StartTime), orDash(m.Error)})
+			}
+			return flags.output.write(cmd.OutOrStdout(), mails, t)
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", defaultListLimit, "表示する件数")
+	return cmd
+}
+
+// replayResult mails replay の結果
+type replayResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newMailsReplayCommand(flags *globalFlags) *cobra.Command {
+	var all bool
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "replay [メールID...]",
+		Short: "送信に失敗したメールを再送する（delivery.state を RETRY にする）",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all == (len(args) > 0) {
+				return errors.New("specify mail IDs or --all")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			mailService := services.NewMailService(c.Firestore)
+			ids := args
+			if all {
+				failed, err := mailService.FailedMails(ctx, limit)
+				if err != nil {
+					return err
+				}
+				for _, m := range failed {
+					ids = append(ids, m.ID)
+				}
+			}
+
+			// 1件の失敗で止めずにすべて試し、失敗があれば最後にエラーを返す
+			results := make([]replayResult, len(ids))
+			t := table{header: []string{"ID", "RESULT", "ERROR"}}
+			failures := 0
+			for i, id := range ids {
+				results[i] = replayResult{ID: id, Result: "retrying"}
+				if err := mailService.RetryMail(ctx, id); err != nil {
+					slog.WarnContext(ctx, "failed to replay mail", slog.String("mail_id", id), slog.Any("error", err))
+					results[i] = replayResult{ID: id, Result: "failed", Error: err.Error()}
+					failures++
+				}
+				t.rows = append(t.rows, []string{id, results[i].Result, orDash(results[i].Error)})
+			}
+			if err := flags.output.write(cmd.OutOrStdout(), results, t); err != nil {
+				return err
+			}
+			if failures > 0 {
+				return fmt.Errorf("%d of %d mails could not be replayed", failures, len(ids))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "送信に失敗したメールをすべて再送する")
+	cmd.Flags().IntVar(&limit, "limit", defaultListLimit, "--all で再送する件数の上限")
+	return cmd
+}
@@ -0,0 +1,135 @@
+// crmctl CRM の管理操作をまとめたコマンド
+//
+//	go run ./scripts/crmctl users invite --email taro@example.com --first-name 太郎 --last-name 山田 --role admin --tenant acme
+//	go run ./scripts/crmctl users delete --email taro@example.com
+//	go run ./scripts/crmctl users resend-verification --email taro@example.com
+//	go run ./scripts/crmctl users sync-claims
+//	go run ./scripts/crmctl notifications pending
+//	go run ./scripts/crmctl mails failed
+//	go run ./scripts/crmctl mails replay <メールID>... | --all
+//	go run ./scripts/crmctl migrate status
+//	go run ./scripts/crmctl migrate up --dry-run
+//	go run ./scripts/crmctl export --dataset orders --format parquet --status shipped --tenant acme
+//	go run ./scripts/crmctl orgs list
+//	go run ./scripts/crmctl orgs create --id acme --name "Acme" --currency USD --locale en-US
+//
+// 結果は -o table（デフォルト）または -o json で標準出力に、ログは標準エラーに出力する。
+// --tenant を指定すると、その組織のデータだけを対象にする（省略時はすべての組織）。
+// 認証情報は GOOGLE_APPLICATION_CREDENTIALS（未指定の場合はデフォルト認証情報）を使う。
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"cloud.google.com/go/firestore"
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/option"
+
+	"narratives-crm-backend/tenant"
+	"narratives-shared/logging"
+)
+
+func main() {
+	// Ctrl+C で止めた場合もマイグレーション・エクスポートは途中の状態を残して終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+// globalFlags すべてのサブコマンドで使うフラグ
+type globalFlags struct {
+	output outputFormat
+	tenant string
+}
+
+func newRootCommand() *cobra.Command {
+	flags := &globalFlags{output: outputTable}
+
+	root := &cobra.Command{
+		Use:   "crmctl",
+		Short: "CRM の管理操作（ユーザー招待・組織・通知・メール・マイグレーション・エクスポート）",
+		// エラー時に使い方は表示しない（エラーは cobra が標準エラーに表示する）
+		SilenceUsage: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// 環境変数を読み込み
+			envErr := godotenv.Load()
+
+			// 結果の出力と混ざらないようにログは標準エラーに出す
+			slog.SetDefault(logging.New(os.Stderr, logging.ConfigFromEnv("crmctl")))
+			if envErr != nil {
+				slog.Debug(".env file not found, using system environment variables")
+			}
+
+			if flags.tenant != "" {
+				cmd.SetContext(tenant.WithScope(cmd.Context(), tenant.Scope{TenantID: flags.tenant}))
+			}
+		},
+	}
+	root.PersistentFlags().VarP(&flags.output, "output", "o", "出力形式（table / json）")
+	root.PersistentFlags().StringVar(&flags.tenant, "tenant", "", "対象の組織（tenant_id、省略時はすべての組織）")
+
+	root.AddCommand(
+		newUsersCommand(flags),
+		newOrgsCommand(flags),
+		newNotificationsCommand(flags),
+		newMailsCommand(flags),
+		newMigrateCommand(flags),
+		newExportCommand(flags),
+	)
+
+	return root
+}
+
+// clients Firebase のクライアント
+type clients struct {
+	App       *firebase.App
+	Auth      *auth.Client
+	Firestore *firestore.Client
+
+	// Options Cloud Storage など他のクライアントにも使う認証情報
+	Options []option.ClientOption
+}
+
+// connect Firebase Admin SDK を初期化する（サーバーと同じ GOOGLE_CLOUD_PROJECT / GOOGLE_APPLICATION_CREDENTIALS を使う）
+func connect(ctx context.Context) (*clients, error) {
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if projectID == "" {
+		projectID = "narratives-test-64976" // デフォルト値
+	}
+
+	var opts []option.ClientOption
+	if credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: projectID}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing firebase app: %w", err)
+	}
+	authClient, err := app.Auth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting auth client: %w", err)
+	}
+	firestoreClient, err := app.Firestore(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting firestore client: %w", err)
+	}
+	return &clients{App: app, Auth: authClient, Firestore: firestoreClient, Options: opts}, nil
+}
+
+// Close クライアントを閉じる
+func (c *clients) Close() {
+	if err := c.Firestore.Close(); err != nil {
+		slog.Warn("failed to close firestore client", slog.Any("error", err))
+	}
+}
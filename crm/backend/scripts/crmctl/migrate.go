@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/migrate"
+	"narratives-crm-backend/migrations"
+)
+
+func newMigrateCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Firestore のマイグレーション",
+		Long: "実行状態は _migrations コレクションに保存される。途中で失敗・中断した場合は\n" +
+			"もう一度 up を実行するとチェックポイントから再開する。",
+	}
+	cmd.AddCommand(
+		newMigrateStatusCommand(flags),
+		newMigrateUpCommand(flags),
+	)
+	return cmd
+}
+
+// migrationStatus migrate status の結果
+type migrationStatus struct {
+	ID          string         `json:"id"`
+	Description string         `json:"description"`
+	Status      migrate.Status `json:"status"`
+	Scanned     int            `json:"scanned"`
+	Updated     int            `json:"updated"`
+	Error       string         `json:"error,omitempty"`
+	FinishedAt  *time.Time     `json:"finished_at"`
+}
+
+func newMigrateStatusCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "登録済みのマイグレーションの実行状態を表示する",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			runner, err := migrate.NewRunner(c.Firestore, migrations.All)
+			if err != nil {
+				return err
+			}
+			states, err := runner.States(ctx)
+			if err != nil {
+				return err
+			}
+
+			statuses := make([]migrationStatus, len(states))
+			t := table{header: []string{"ID", "STATUS", "SCANNED", "UPDATED", "FINISHED", "DESCRIPTION"}}
+			for i, s := range states {
+				statuses[i] = migrationStatus{
+					ID:          s.ID,
+					Description: runner.Migrations[i].Description,
+					Status:      s.Status,
+					Scanned:     s.Scanned,
+					Updated:     s.Updated,
+					Error:       s.Error,
+					FinishedAt:  s.FinishedAt,
+				}
+				t.rows = append(t.rows, []string{
+					s.ID, string(s.Status), strconv.Itoa(s.Scanned), strconv.Itoa(s.Updated), formatTime(s.FinishedAt), runner.Migrations[i].Description,
+				})
+			}
+			if err := flags.output.write(cmd.OutOrStdout(), statuses, t); err != nil {
+				return err
+			}
+
+			unknown, err := runner.Unregistered(ctx)
+			if err != nil {
+				return err
+			}
+			for _, id := range unknown {
+				slog.WarnContext(ctx, "migration state exists but the migration is not registered", slog.String("migration", id))
+			}
+			return nil
+		},
+	}
+}
+
+// migrationResult migrate up の結果
+type migrationResult struct {
+	ID      string `json:"id"`
+	Scanned int    `json:"scanned"`
+	Updated int    `json:"updated"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+func newMigrateUpCommand(flags *globalFlags) *cobra.Command {
+	var (
+		dryRun    bool
+		target    string
+		batchSize int
+	)
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "未完了のマイグレーションを順に実行する",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			runner, err := migrate.NewRunner(c.Firestore, migrations.All)
+			if err != nil {
+				return err
+			}
+			runner.BatchSize = batchSize
+			runner.DryRun = dryRun
+
+			// 失敗した場合もそこまでの結果を表示してからエラーを返す
+			results, upErr := runner.Up(ctx, target)
+
+			rows := make([]migrationResult, len(results))
+			t := table{header: []string{"ID", "SCANNED", "UPDATED", "DRY_RUN"}}
+			for i, r := range results {
+				rows[i] = migrationResult{ID: r.ID, Scanned: r.Scanned, Updated: r.Updated, DryRun: r.DryRun}
+				t.rows = append(t.rows, []string{r.ID, strconv.Itoa(r.Scanned), strconv.Itoa(r.Updated), strconv.FormatBool(r.DryRun)})
+			}
+			if err := flags.output.write(cmd.OutOrStdout(), rows, t); err != nil {
+				return err
+			}
+			if upErr == nil && len(results) == 0 {
+				slog.InfoContext(ctx, "no pending migrations")
+			}
+			return upErr
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "更新内容をログに出すだけで書き込まない")
+	cmd.Flags().StringVar(&target, "to", "", "指定した ID まで実行する")
+	cmd.Flags().IntVar(&batchSize, "batch", migrate.DefaultBatchSize, "1回のトランザクションで更新するドキュメント数")
+	return cmd
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/services"
+)
+
+// defaultListLimit 一覧で取得する件数のデフォルト
+const defaultListLimit = 50
+
+func newNotificationsCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notifications",
+		Short: "通知（notifications コレクション）",
+	}
+	cmd.AddCommand(newNotificationsPendingCommand(flags))
+	return cmd
+}
+
+// pendingNotification notifications pending の結果
+type pendingNotification struct {
+	ID               string    `json:"id"`
+	NotificationType string    `json:"notification_type"`
+	BusinessUserID   string    `json:"business_user_id"`
+	Title            string    `json:"title,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newNotificationsPendingCommand(flags *globalFlags) *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "pending",
+		Short: "未処理の通知を古い順に表示する",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			found, err := services.NewMailService(c.Firestore).PendingNotifications(ctx, limit)
+			if err != nil {
+				return err
+			}
+
+			notifications := make([]pendingNotification, len(found))
+			t := table{header: []string{"ID", "TYPE", "BUSINESS_USER_ID", "CREATED_AT"}}
+			for i, n := range found {
+				notifications[i] = pendingNotification{
+					ID:               n.ID,
+					NotificationType: n.NotificationType,
+					BusinessUserID:   n.BusinessUserID,
+					Title:            n.Title,
+					CreatedAt:        n.CreatedAt,
+				}
+				t.rows = append(t.rows, []string{n.ID, orDash(n.NotificationType), orDash(n.BusinessUserID), formatTime(&n.CreatedAt)})
+			}
+			return flags.output.write(cmd.OutOrStdout(), notifications, t)
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", defaultListLimit, "表示する件数")
+	return cmd
+}
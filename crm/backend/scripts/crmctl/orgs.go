@@ -0,0 +1,113 @@
+package main
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/segment"
+	"narratives-crm-backend/tenant"
+)
+
+func newOrgsCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "orgs",
+		Aliases: []string{"organizations"},
+		Short:   "組織（organizations コレクション）",
+	}
+	cmd.AddCommand(
+		newOrgsListCommand(flags),
+		newOrgsCreateCommand(flags),
+	)
+	return cmd
+}
+
+// organization orgs list / create の結果
+type organization struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Settings  tenant.Settings `json:"settings"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func newOrganization(org *tenant.Organization) organization {
+	return organization{ID: org.ID, Name: org.Name, Settings: org.Settings, CreatedAt: org.CreatedAt}
+}
+
+// row 表の1行
+func (o organization) row() []string {
+	return []string{o.ID, o.Name, o.Settings.Currency, o.Settings.Locale, formatTime(&o.CreatedAt)}
+}
+
+var organizationHeader = []string{"ID", "NAME", "CURRENCY", "LOCALE", "CREATED_AT"}
+
+func newOrgsListCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "組織をIDの順に表示する（--tenant を指定した場合はその組織だけ）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			found, err := tenant.List(ctx, c.Firestore)
+			if err != nil {
+				return err
+			}
+
+			orgs := make([]organization, len(found))
+			t := table{header: organizationHeader}
+			for i, org := range found {
+				orgs[i] = newOrganization(org)
+				t.rows = append(t.rows, orgs[i].row())
+			}
+			return flags.output.write(cmd.OutOrStdout(), orgs, t)
+		},
+	}
+}
+
+func newOrgsCreateCommand(flags *globalFlags) *cobra.Command {
+	var org tenant.Organization
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "組織を作成し、既定のセグメント（HOT / COLD）を用意する（createOrganization と同じ）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			created, err := tenant.Create(ctx, c.Firestore, &org)
+			if err != nil {
+				return err
+			}
+			if err := segment.EnsureDefaults(tenant.WithScope(ctx, tenant.Scope{TenantID: created.ID}), c.Firestore); err != nil {
+				return err
+			}
+
+			result := newOrganization(created)
+			return flags.output.write(cmd.OutOrStdout(), result, table{
+				header: organizationHeader,
+				rows:   [][]string{result.row()},
+			})
+		},
+	}
+	cmd.Flags().StringVar(&org.ID, "id", "", "組織のID（tenant_id、英小文字・数字・-・_）")
+	cmd.Flags().StringVar(&org.Name, "name", "", "組織名")
+	cmd.Flags().StringVar(&org.Settings.Currency, "currency", "", "通貨コード（省略時は JPY）")
+	cmd.Flags().StringVar(&org.Settings.Locale, "locale", "", "言語タグ（省略時は ja-JP）")
+	cmd.Flags().StringVar(&org.Settings.Branding.ProductName, "product-name", "", "メールに記載するサービス名")
+	cmd.Flags().StringVar(&org.Settings.Branding.SenderName, "sender-name", "", "メールの署名")
+	cmd.Flags().StringVar(&org.Settings.Branding.SupportEmail, "support-email", "", "問い合わせ先のメールアドレス")
+	for _, name := range []string{"id", "name"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// outputFormat 結果の出力形式（--output）
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+)
+
+// String pflag.Value の実装
+func (f *outputFormat) String() string { return string(*f) }
+
+// Set pflag.Value の実装
+func (f *outputFormat) Set(s string) error {
+	switch v := outputFormat(strings.ToLower(s)); v {
+	case outputTable, outputJSON:
+		*f = v
+		return nil
+	}
+	return fmt.Errorf("must be table or json: %q", s)
+}
+
+// Type pflag.Value の実装
+func (f *outputFormat) Type() string { return "format" }
+
+// table 表形式で出力する内容
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// write v を JSON で、または t を表で出力する
+func (f outputFormat) write(w io.Writer, v interface{}, t table) error {
+	if f == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// formatTime 表に出す日時（未設定は -）
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// orDash 表に出す文字列（空は -）
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
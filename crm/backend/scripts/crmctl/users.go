@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"narratives-crm-backend/graph/model"
+	"narratives-crm-backend/services"
+)
+
+func newUsersCommand(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "ビジネスユーザー（Firebase Auth）の招待・削除・認証メール",
+	}
+	cmd.AddCommand(
+		newUsersInviteCommand(flags),
+		newUsersDeleteCommand(flags),
+		newUsersResendVerificationCommand(flags),
+		newUsersSyncClaimsCommand(flags),
+	)
+	return cmd
+}
+
+// invitedUser users invite の結果
+type invitedUser struct {
+	UID               string    `json:"uid"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	TenantID          string    `json:"tenant_id"`
+	TemporaryPassword string    `json:"temporary_password"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newUsersInviteCommand(flags *globalFlags) *cobra.Command {
+	var input services.BusinessUserInput
+	cmd := &cobra.Command{
+		Use:     "invite",
+		Aliases: []string{"create"},
+		Short:   "ビジネスユーザーを作成して招待メール（welcome_email 通知）を作成する",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// inviteStaff ミューテーションと同じロールだけを受け付け、カスタムクレームには小文字で保存する
+			if input.Role != "" {
+				role := model.StaffRole(strings.ToUpper(input.Role))
+				if !role.IsValid() {
+					return fmt.Errorf("invalid --role %q (user / moderator / admin / super_admin)", input.Role)
+				}
+				input.Role = strings.ToLower(role.String())
+			}
+			input.TenantID = flags.tenant
+
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			created, err := services.NewFirebaseAuthService(c.Auth, c.Firestore).CreateBusinessUser(ctx, input)
+			if err != nil {
+				return err
+			}
+
+			result := invitedUser{
+				UID:               created.BusinessUserID,
+				Email:             created.EmailAddress,
+				Role:              created.Role,
+				TenantID:          created.TenantID,
+				TemporaryPassword: *created.TemporaryPassword,
+				CreatedAt:         created.CreatedAt,
+			}
+			return flags.output.write(cmd.OutOrStdout(), result, table{
+				header: []string{"UID", "EMAIL", "ROLE", "TENANT", "TEMPORARY_PASSWORD"},
+				rows:   [][]string{{result.UID, result.Email, result.Role, result.TenantID, result.TemporaryPassword}},
+			})
+		},
+	}
+	cmd.Flags().StringVar(&input.EmailAddress, "email", "", "メールアドレス")
+	cmd.Flags().StringVar(&input.FirstName, "first-name", "", "名")
+	cmd.Flags().StringVar(&input.LastName, "last-name", "", "姓")
+	cmd.Flags().StringVar(&input.FirstNameKatakana, "first-name-kana", "", "名（カタカナ）")
+	cmd.Flags().StringVar(&input.LastNameKatakana, "last-name-kana", "", "姓（カタカナ）")
+	cmd.Flags().StringVar(&input.Role, "role", "", "ロール（user / moderator / admin / super_admin、省略時は user）")
+	for _, name := range []string{"email", "first-name", "last-name"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+// userResult users delete / resend-verification の結果
+type userResult struct {
+	Email            string `json:"email"`
+	Result           string `json:"result"`
+	VerificationLink string `json:"verification_link,omitempty"`
+}
+
+func newUsersDeleteCommand(flags *globalFlags) *cobra.Command {
+	var email string
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "ビジネスユーザーを Firebase Auth から削除する（/api/auth/delete-user と同じ）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			if err := services.NewFirebaseAuthService(c.Auth, c.Firestore).DeleteUserByEmail(ctx, email); err != nil {
+				return err
+			}
+
+			result := userResult{Email: email, Result: "deleted"}
+			return flags.output.write(cmd.OutOrStdout(), result, table{
+				header: []string{"EMAIL", "RESULT"},
+				rows:   [][]string{{result.Email, result.Result}},
+			})
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "メールアドレス")
+	_ = cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func newUsersResendVerificationCommand(flags *globalFlags) *cobra.Command {
+	var email string
+	cmd := &cobra.Command{
+		Use:   "resend-verification",
+		Short: "認証メールの再送を確認し、送信用のメール認証リンクを表示する",
+		Long: "メールはフロントエンドが送信するため、ここでは再送できる状態か（一時パスワードが設定されているか）を確認し、\n" +
+			"メールに記載するメール認証リンクを生成する。",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			authService := services.NewFirebaseAuthService(c.Auth, c.Firestore)
+			if err := authService.ResendVerificationEmail(ctx, email); err != nil {
+				return err
+			}
+			link, err := authService.GenerateEmailVerificationLink(ctx, email)
+			if err != nil {
+				return err
+			}
+
+			result := userResult{Email: email, Result: "verification link generated", VerificationLink: link}
+			return flags.output.write(cmd.OutOrStdout(), result, table{
+				header: []string{"EMAIL", "VERIFICATION_LINK"},
+				rows:   [][]string{{result.Email, result.VerificationLink}},
+			})
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "メールアドレス")
+	_ = cmd.MarkFlagRequired("email")
+	return cmd
+}
+
+func newUsersSyncClaimsCommand(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync-claims",
+		Short: "business_users の組織をカスタムクレーム tenant_id に設定する（tenant_id クレームのないユーザーはデータにアクセスできない）",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			c, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+
+			updated, err := services.NewFirebaseAuthService(c.Auth, c.Firestore).SyncBusinessUserClaims(ctx)
+			if err != nil {
+				return err
+			}
+
+			t := table{header: []string{"UID", "RESULT"}}
+			for _, uid := range updated {
+				t.rows = append(t.rows, []string{uid, "claims updated"})
+			}
+			return flags.output.write(cmd.OutOrStdout(), updated, t)
+		},
+	}
+}
@@ -0,0 +1,139 @@
+// import_users CSV / XLSX からユーザーを一括で取り込む
+//
+//	go run ./scripts/import_users -file customers.csv
+//	go run ./scripts/import_users -file customers.xlsx -format xlsx
+//	go run ./scripts/import_users -job <ジョブID>   # 中断・失敗したジョブを再開
+//
+// GraphQL の importUsers と同じジョブ（import_jobs）を作成してこのプロセスで実行する。
+// 進捗は importJob クエリからも確認できる。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"cloud.google.com/go/firestore"
+	firebase "firebase.google.com/go/v4"
+	"github.com/joho/godotenv"
+	"google.golang.org/api/option"
+
+	"narratives-crm-backend/importer"
+	"narratives-shared/logging"
+)
+
+func main() {
+	filePath := flag.String("file", "", "取り込むファイル（CSV / XLSX）")
+	formatName := flag.String("format", "", "ファイル形式（csv / xlsx、省略時は拡張子で判定）")
+	jobID := flag.String("job", "", "再開するジョブID（-file の代わりに指定）")
+	createdBy := flag.String("created-by", "import_users", "ジョブの作成者として記録する名前")
+	flag.Parse()
+
+	if (*filePath == "") == (*jobID == "") {
+		fmt.Fprintln(os.Stderr, "either -file or -job is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// 環境変数を読み込み
+	envErr := godotenv.Load()
+
+	logging.Setup(logging.ConfigFromEnv("import-users"))
+	if envErr != nil {
+		slog.Warn(".env file not found, using system environment variables")
+	}
+
+	// Ctrl+C で止めた場合もジョブは途中から再開できる
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Firebase初期化
+	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if credentialsPath == "" {
+		credentialsPath = "./narratives-test-service-account.json"
+	}
+
+	app, err := firebase.NewApp(ctx, nil, option.WithCredentialsFile(credentialsPath))
+	if err != nil {
+		slog.Error("Firebase app initialization failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	client, err := app.Firestore(ctx)
+	if err != nil {
+		slog.Error("failed to get Firestore client", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	runner := importer.NewRunner(client)
+	runner.OnProgress = func(job *importer.Job) {
+		slog.Info("import progress",
+			slog.Int("processed", job.ProcessedRows),
+			slog.Int("total", job.TotalRows),
+			slog.Int("created", job.CreatedCount),
+			slog.Int("skipped", job.SkippedCount),
+			slog.Int("failed", job.FailedCount))
+	}
+
+	id := *jobID
+	if id != "" {
+		// 失敗したジョブは再開できる状態に戻す（実行中・待機中はそのまま）
+		if _, err := runner.Retry(ctx, id); err != nil {
+			slog.Error("failed to resume import job", slog.String("job_id", id), slog.Any("error", err))
+			os.Exit(1)
+		}
+	} else {
+		job, err := createJob(ctx, client, *filePath, *formatName, *createdBy)
+		if err != nil {
+			slog.Error("failed to create import job", slog.String("file", *filePath), slog.Any("error", err))
+			os.Exit(1)
+		}
+		id = job.ID
+		slog.Info("import job created", slog.String("job_id", id), slog.Int("rows", job.TotalRows), slog.Int("invalid_rows", job.FailedCount))
+	}
+
+	job, err := runner.Run(ctx, id)
+	if err != nil {
+		slog.Error("import job stopped", slog.String("job_id", id), slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	for _, e := range job.Errors {
+		fmt.Printf("line %d\t%s\t%s\n", e.Line, e.Field, e.Message)
+	}
+	slog.Info("import job finished",
+		slog.String("job_id", id),
+		slog.String("status", string(job.Status)),
+		slog.Int("created", job.CreatedCount),
+		slog.Int("skipped", job.SkippedCount),
+		slog.Int("failed", job.FailedCount))
+}
+
+// createJob ファイルを検証して取り込みジョブを作成
+func createJob(ctx context.Context, client *firestore.Client, path, formatName, createdBy string) (*importer.Job, error) {
+	var (
+		format importer.Format
+		err    error
+	)
+	if formatName != "" {
+		format, err = importer.ParseFormat(formatName)
+	} else {
+		format, err = importer.DetectFormat(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return importer.Create(ctx, client, f, filepath.Base(path), format, createdBy)
+}
@@ -0,0 +1,326 @@
+package segment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/optimistic"
+	"narratives-crm-backend/softdelete"
+	"narratives-crm-backend/tenant"
+)
+
+// デフォルトの設定
+const (
+	DefaultWindow   = 365 * 24 * time.Hour
+	DefaultInterval = 24 * time.Hour
+)
+
+// excludedOrderStatuses 集計に含めない注文ステータス
+var excludedOrderStatuses = map[string]bool{"cancelled": true, "refunded": true}
+
+// Config セグメント評価の設定
+type Config struct {
+	// Window Frequency / Monetary / InteractionCount の集計期間
+	Window time.Duration
+	// Interval 定期評価の間隔（0 の場合は定期評価しない）
+	Interval time.Duration
+}
+
+// ConfigFromEnv 環境変数から設定を読み込む
+//
+//	SEGMENT_WINDOW    集計期間（例: 4320h、デフォルト: 8760h = 365日）
+//	SEGMENT_INTERVAL  定期評価の間隔（例: 6h、デフォルト: 24h、"off" で無効）
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Window: DefaultWindow, Interval: DefaultInterval}
+
+	if v := os.Getenv("SEGMENT_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SEGMENT_WINDOW: %q", v)
+		}
+		cfg.Window = d
+	}
+	if v := os.Getenv("SEGMENT_INTERVAL"); v != "" {
+		if strings.EqualFold(v, "off") {
+			cfg.Interval = 0
+			return cfg, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SEGMENT_INTERVAL: %q", v)
+		}
+		cfg.Interval = d
+	}
+	return cfg, nil
+}
+
+// Run 評価の結果（segment_runs ドキュメント）
+type Run struct {
+	ID            string         `firestore:"-"`
+	TenantID      string         `firestore:"tenant_id"`
+	StartedAt     time.Time      `firestore:"started_at"`
+	FinishedAt    time.Time      `firestore:"finished_at"`
+	Users         int            `firestore:"users"`
+	StatusChanges int            `firestore:"status_changes"`
+	SegmentCounts map[string]int `firestore:"segment_counts"`
+}
+
+// Engine 注文・インタラクションから RFM を計算し、セグメントとステータスを更新する
+//
+// 評価のたびにユーザーの segments（所属するセグメントID）と rfm を書き換える。
+// ステータスは active / hot / cold のユーザーだけを対象にし、
+// Status を持つセグメントに該当すればそのステータス、該当しなければ active に戻す。
+// inactive / pending のユーザーのステータスは変えない。論理削除済みのユーザー・注文・インタラクションは評価に含めない。
+type Engine struct {
+	Client *firestore.Client
+	Config Config
+}
+
+// userState 評価前のユーザー
+type userState struct {
+	status   string
+	segments []string
+}
+
+// userWrite ユーザーの評価結果の書き込み
+type userWrite struct {
+	userID        string
+	job           *firestore.BulkWriterJob
+	segments      []string
+	statusChanged bool
+}
+
+// Evaluate コンテキストの組織のすべてのユーザーを、その組織のセグメントで評価する
+//
+// スコープがない場合は tenant.DefaultID の組織を評価する。すべての組織が対象のスコープではエラーを返す。
+func (e *Engine) Evaluate(ctx context.Context) (*Run, error) {
+	ctx, tenantID, err := tenant.Single(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run := &Run{TenantID: tenantID, StartedAt: time.Now(), SegmentCounts: make(map[string]int)}
+
+	segments, err := List(ctx, e.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	users, createdAt, err := e.loadUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := e.loadOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	interactions, err := e.loadInteractions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := computeMetrics(run.StartedAt, e.Config.Window, createdAt, orders, interactions)
+
+	bw := e.Client.BulkWriter(ctx)
+	var writes []userWrite
+	for id, m := range metrics {
+		matched := []string{}
+		status := ""
+		for _, s := range segments {
+			if s.Match(m) {
+				matched = append(matched, s.ID)
+				run.SegmentCounts[s.ID]++
+				if status == "" {
+					status = s.Status
+				}
+			}
+		}
+
+		updates := []firestore.Update{
+			{Path: "segments", Value: matched},
+			{Path: "rfm", Value: rfmData(m)},
+			{Path: "segmented_at", Value: run.StartedAt},
+		}
+		newStatus := nextStatus(users[id].status, status)
+		if newStatus != users[id].status {
+			updates = append(updates,
+				firestore.Update{Path: "status", Value: newStatus},
+				firestore.Update{Path: "updated_at", Value: run.StartedAt},
+				optimistic.Bump())
+			run.StatusChanges++
+		}
+
+		job, err := bw.Update(e.Client.Collection("users").Doc(id), updates)
+		if err != nil {
+			bw.End()
+			return nil, fmt.Errorf("failed to update user segments: %w", err)
+		}
+		writes = append(writes, userWrite{userID: id, job: job, segments: matched, statusChanged: newStatus != users[id].status})
+	}
+	bw.End()
+
+	run.Users = len(metrics)
+	for _, w := range writes {
+		_, err := w.job.Results()
+		switch {
+		case grpcstatus.Code(err) == codes.NotFound:
+			// 評価中に物理削除されたユーザーは評価の結果に含めない
+			slog.InfoContext(ctx, "user deleted during segment evaluation", slog.String("user_id", w.userID))
+			run.Users--
+			for _, id := range w.segments {
+				run.SegmentCounts[id]--
+			}
+			if w.statusChanged {
+				run.StatusChanges--
+			}
+		case err != nil:
+			return nil, fmt.Errorf("failed to update user segments: %w", err)
+		}
+	}
+	run.FinishedAt = time.Now()
+
+	for _, s := range segments {
+		_, err := e.Client.Collection(segmentsCollection).Doc(s.ID).Update(ctx, []firestore.Update{
+			{Path: "user_count", Value: run.SegmentCounts[s.ID]},
+			{Path: "evaluated_at", Value: run.FinishedAt},
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "failed to update segment count", slog.String("segment_id", s.ID), slog.Any("error", err))
+		}
+	}
+
+	ref, _, err := e.Client.Collection(runsCollection).Add(ctx, run)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record segment run", slog.Any("error", err))
+	} else {
+		run.ID = ref.ID
+	}
+	return run, nil
+}
+
+// nextStatus 評価後のステータス。segmentStatus は該当したセグメントのうち最優先のもの
+func nextStatus(current, segmentStatus string) string {
+	switch current {
+	case "", StatusActive, StatusHot, StatusCold:
+		if segmentStatus != "" {
+			return segmentStatus
+		}
+		return StatusActive
+	}
+	return current
+}
+
+// rfmData users.rfm に保存する指標
+func rfmData(m *Metrics) map[string]interface{} {
+	return map[string]interface{}{
+		"recency_days":        m.RecencyDays(),
+		"frequency":           m.Frequency,
+		"monetary":            m.Monetary,
+		"r_score":             m.RScore,
+		"f_score":             m.FScore,
+		"m_score":             m.MScore,
+		"interaction_count":   m.InteractionCount,
+		"last_order_at":       m.LastOrderAt,
+		"last_interaction_at": m.LastInteractionAt,
+	}
+}
+
+func (e *Engine) loadUsers(ctx context.Context) (map[string]userState, map[string]time.Time, error) {
+	users := make(map[string]userState)
+	createdAt := make(map[string]time.Time)
+	query := e.Client.Collection("users").Select("status", "created_at", softdelete.FieldDeletedAt)
+	err := each(ctx, tenant.Query(ctx, query), func(doc *firestore.DocumentSnapshot) {
+		data := doc.Data()
+		if softdelete.IsDeleted(data) {
+			return
+		}
+		status, _ := data["status"].(string)
+		users[doc.Ref.ID] = userState{status: status}
+		createdAt[doc.Ref.ID], _ = data["created_at"].(time.Time)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	return users, createdAt, nil
+}
+
+func (e *Engine) loadOrders(ctx context.Context) ([]order, error) {
+	var orders []order
+	query := e.Client.Collection("orders").Select("user_id", "order_date", "total_amount", "status", softdelete.FieldDeletedAt)
+	err := each(ctx, tenant.Query(ctx, query), func(doc *firestore.DocumentSnapshot) {
+		data := doc.Data()
+		if softdelete.IsDeleted(data) {
+			return
+		}
+		status, _ := data["status"].(string)
+		if excludedOrderStatuses[strings.ToLower(status)] {
+			return
+		}
+		userID, _ := data["user_id"].(string)
+		date, ok := data["order_date"].(time.Time)
+		if userID == "" || !ok {
+			return
+		}
+		orders = append(orders, order{UserID: userID, Date: date, Amount: toFloat(data["total_amount"])})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (e *Engine) loadInteractions(ctx context.Context) ([]interaction, error) {
+	var interactions []interaction
+	query := e.Client.Collection("interactions").Select("user_id", "created_at", softdelete.FieldDeletedAt)
+	err := each(ctx, tenant.Query(ctx, query), func(doc *firestore.DocumentSnapshot) {
+		data := doc.Data()
+		if softdelete.IsDeleted(data) {
+			return
+		}
+		userID, _ := data["user_id"].(string)
+		at, ok := data["created_at"].(time.Time)
+		if userID == "" || !ok {
+			return
+		}
+		interactions = append(interactions, interaction{UserID: userID, At: at})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load interactions: %w", err)
+	}
+	return interactions, nil
+}
+
+// each クエリの結果を1件ずつ handle に渡す
+func each(ctx context.Context, query firestore.Query, handle func(*firestore.DocumentSnapshot)) error {
+	it := query.Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		handle(doc)
+	}
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,69 @@
+package segment
+
+import (
+	"testing"
+	"time"
+
+	"narratives-crm-backend/tenant"
+)
+
+func TestNextStatus(t *testing.T) {
+	tests := []struct {
+		current       string
+		segmentStatus string
+		want          string
+	}{
+		{"", "", StatusActive},
+		{StatusActive, StatusHot, StatusHot},
+		{StatusHot, "", StatusActive},
+		{StatusHot, StatusCold, StatusCold},
+		{StatusCold, StatusHot, StatusHot},
+		{"inactive", StatusHot, "inactive"}, // 手動で設定したステータスは変えない
+		{"pending", StatusCold, "pending"},
+	}
+	for _, tt := range tests {
+		if got := nextStatus(tt.current, tt.segmentStatus); got != tt.want {
+			t.Errorf("nextStatus(%q, %q) = %q, want %q", tt.current, tt.segmentStatus, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   string
+		interval string
+		want     Config
+		wantErr  bool
+	}{
+		{name: "defaults", want: Config{Window: DefaultWindow, Interval: DefaultInterval}},
+		{name: "overrides", window: "720h", interval: "6h", want: Config{Window: 720 * time.Hour, Interval: 6 * time.Hour}},
+		{name: "scheduling off", interval: "OFF", want: Config{Window: DefaultWindow}},
+		{name: "invalid window", window: "a year", wantErr: true},
+		{name: "negative window", window: "-1h", wantErr: true},
+		{name: "invalid interval", interval: "0s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SEGMENT_WINDOW", tt.window)
+			t.Setenv("SEGMENT_INTERVAL", tt.interval)
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultSegmentID(t *testing.T) {
+	if got := defaultSegmentID(tenant.DefaultID, "champions"); got != "champions" {
+		t.Errorf("defaultSegmentID(default) = %q, want champions", got)
+	}
+	if got := defaultSegmentID("acme", "champions"); got != "acme_champions" {
+		t.Errorf("defaultSegmentID(acme) = %q, want acme_champions", got)
+	}
+}
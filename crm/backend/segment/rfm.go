@@ -0,0 +1,156 @@
+package segment
+
+import (
+	"sort"
+	"time"
+)
+
+// scoreLevels RFM スコアの段階数（五分位）
+const scoreLevels = 5
+
+// Metrics ユーザーごとの RFM とインタラクションの指標
+type Metrics struct {
+	UserID string
+
+	LastOrderAt *time.Time
+	Frequency   int
+	Monetary    float64
+
+	RScore int
+	FScore int
+	MScore int
+
+	LastInteractionAt *time.Time
+	InteractionCount  int
+
+	CreatedAt time.Time
+
+	now time.Time
+}
+
+// Value ルールで参照する指標の値。注文・インタラクションがない場合の経過日数は +Inf
+func (m *Metrics) Value(f Field) float64 {
+	switch f {
+	case FieldRecencyDays:
+		return m.daysSince(m.LastOrderAt)
+	case FieldFrequency:
+		return float64(m.Frequency)
+	case FieldMonetary:
+		return m.Monetary
+	case FieldRScore:
+		return float64(m.RScore)
+	case FieldFScore:
+		return float64(m.FScore)
+	case FieldMScore:
+		return float64(m.MScore)
+	case FieldInteractionCount:
+		return float64(m.InteractionCount)
+	case FieldDaysSinceInteraction:
+		return m.daysSince(m.LastInteractionAt)
+	case FieldAccountAgeDays:
+		return m.daysSince(&m.CreatedAt)
+	}
+	return 0
+}
+
+// RecencyDays 最後の注文からの日数（注文がなければ nil）
+func (m *Metrics) RecencyDays() *int {
+	if m.LastOrderAt == nil {
+		return nil
+	}
+	days := int(m.daysSince(m.LastOrderAt))
+	return &days
+}
+
+func (m *Metrics) daysSince(t *time.Time) float64 {
+	if t == nil || t.IsZero() {
+		return never
+	}
+	return float64(int(m.now.Sub(*t).Hours() / 24))
+}
+
+// order 集計に使う注文
+type order struct {
+	UserID string
+	Date   time.Time
+	Amount float64
+}
+
+// interaction 集計に使うインタラクション
+type interaction struct {
+	UserID string
+	At     time.Time
+}
+
+// computeMetrics 注文とインタラクションからユーザーごとの指標を計算する
+//
+// Frequency / Monetary / InteractionCount は window 内のものだけを数える。
+// Recency は期間外の注文も含めた最後の注文日から求める。
+func computeMetrics(now time.Time, window time.Duration, users map[string]time.Time, orders []order, interactions []interaction) map[string]*Metrics {
+	since := now.Add(-window)
+
+	metrics := make(map[string]*Metrics, len(users))
+	for id, createdAt := range users {
+		metrics[id] = &Metrics{UserID: id, CreatedAt: createdAt, now: now}
+	}
+
+	for _, o := range orders {
+		m, ok := metrics[o.UserID]
+		if !ok {
+			continue
+		}
+		if m.LastOrderAt == nil || o.Date.After(*m.LastOrderAt) {
+			date := o.Date
+			m.LastOrderAt = &date
+		}
+		if !o.Date.Before(since) {
+			m.Frequency++
+			m.Monetary += o.Amount
+		}
+	}
+
+	for _, i := range interactions {
+		m, ok := metrics[i.UserID]
+		if !ok {
+			continue
+		}
+		if m.LastInteractionAt == nil || i.At.After(*m.LastInteractionAt) {
+			at := i.At
+			m.LastInteractionAt = &at
+		}
+		if !i.At.Before(since) {
+			m.InteractionCount++
+		}
+	}
+
+	score(metrics)
+	return metrics
+}
+
+// score 注文があるユーザーの中での順位から RFM スコア（1〜5）を付ける。注文がないユーザーは 0
+//
+// 同じ値のユーザーには同じスコアを付ける。
+func score(metrics map[string]*Metrics) {
+	var customers []*Metrics
+	for _, m := range metrics {
+		if m.LastOrderAt != nil {
+			customers = append(customers, m)
+		}
+	}
+
+	assign := func(less func(a, b *Metrics) bool, set func(*Metrics, int)) {
+		sort.SliceStable(customers, func(i, j int) bool { return less(customers[i], customers[j]) })
+		s := 0
+		for rank, m := range customers {
+			if rank == 0 || less(customers[rank-1], m) {
+				s = rank*scoreLevels/len(customers) + 1
+			}
+			set(m, s)
+		}
+	}
+
+	// 値が小さい順に並べ、大きいほど（Recency は新しいほど）高いスコアにする
+	assign(func(a, b *Metrics) bool { return a.LastOrderAt.Before(*b.LastOrderAt) }, func(m *Metrics, s int) { m.RScore = s })
+	assign(func(a, b *Metrics) bool { return a.Frequency < b.Frequency }, func(m *Metrics, s int) { m.FScore = s })
+	assign(func(a, b *Metrics) bool { return a.Monetary < b.Monetary }, func(m *Metrics, s int) { m.MScore = s })
+}
@@ -0,0 +1,123 @@
+package segment
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(days int) time.Time {
+	return testNow.AddDate(0, 0, -days)
+}
+
+func TestComputeMetrics(t *testing.T) {
+	users := map[string]time.Time{"u1": daysAgo(400), "u2": daysAgo(10)}
+	orders := []order{
+		{UserID: "u1", Date: daysAgo(5), Amount: 1000},
+		{UserID: "u1", Date: daysAgo(40), Amount: 500},
+		{UserID: "u1", Date: daysAgo(380), Amount: 9000}, // 集計期間外
+		{UserID: "unknown", Date: daysAgo(1), Amount: 100},
+	}
+	interactions := []interaction{
+		{UserID: "u2", At: daysAgo(3)},
+		{UserID: "u2", At: daysAgo(1)},
+		{UserID: "u2", At: daysAgo(500)}, // 集計期間外
+	}
+
+	metrics := computeMetrics(testNow, 365*24*time.Hour, users, orders, interactions)
+	if len(metrics) != 2 {
+		t.Fatalf("len(metrics) = %d, want 2", len(metrics))
+	}
+
+	tests := []struct {
+		user  string
+		field Field
+		want  float64
+	}{
+		{"u1", FieldRecencyDays, 5},
+		{"u1", FieldFrequency, 2},
+		{"u1", FieldMonetary, 1500},
+		{"u1", FieldRScore, 1},
+		{"u1", FieldInteractionCount, 0},
+		{"u1", FieldDaysSinceInteraction, math.Inf(1)},
+		{"u1", FieldAccountAgeDays, 400},
+		{"u2", FieldRecencyDays, math.Inf(1)},
+		{"u2", FieldFrequency, 0},
+		{"u2", FieldRScore, 0},
+		{"u2", FieldFScore, 0},
+		{"u2", FieldMScore, 0},
+		{"u2", FieldInteractionCount, 2},
+		{"u2", FieldDaysSinceInteraction, 1},
+		{"u2", FieldAccountAgeDays, 10},
+	}
+	for _, tt := range tests {
+		if got := metrics[tt.user].Value(tt.field); got != tt.want {
+			t.Errorf("%s %s = %v, want %v", tt.user, tt.field, got, tt.want)
+		}
+	}
+
+	if got := metrics["u1"].RecencyDays(); got == nil || *got != 5 {
+		t.Errorf("u1 RecencyDays() = %v, want 5", got)
+	}
+	if got := metrics["u2"].RecencyDays(); got != nil {
+		t.Errorf("u2 RecencyDays() = %v, want nil", *got)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		frequencies []int
+		want        []int
+	}{
+		{name: "single customer", frequencies: []int{3}, want: []int{1}},
+		{name: "quintiles", frequencies: []int{1, 2, 3, 4, 5}, want: []int{1, 2, 3, 4, 5}},
+		{name: "ties share a score", frequencies: []int{1, 1, 2}, want: []int{1, 1, 4}},
+		{name: "all equal", frequencies: []int{2, 2, 2, 2}, want: []int{1, 1, 1, 1}},
+		{
+			name:        "ten customers",
+			frequencies: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			want:        []int{1, 1, 2, 2, 3, 3, 4, 4, 5, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := make(map[string]*Metrics)
+			ids := make([]string, len(tt.frequencies))
+			for i, f := range tt.frequencies {
+				last := daysAgo(i)
+				ids[i] = string(rune('a' + i))
+				metrics[ids[i]] = &Metrics{UserID: ids[i], LastOrderAt: &last, Frequency: f, Monetary: float64(f)}
+			}
+			metrics["none"] = &Metrics{UserID: "none"}
+
+			score(metrics)
+
+			for i, id := range ids {
+				if got := metrics[id].FScore; got != tt.want[i] {
+					t.Errorf("%s FScore = %d, want %d", id, got, tt.want[i])
+				}
+				if got := metrics[id].MScore; got != tt.want[i] {
+					t.Errorf("%s MScore = %d, want %d", id, got, tt.want[i])
+				}
+			}
+			if m := metrics["none"]; m.RScore != 0 || m.FScore != 0 || m.MScore != 0 {
+				t.Errorf("user without orders has scores %d/%d/%d", m.RScore, m.FScore, m.MScore)
+			}
+		})
+	}
+
+	t.Run("recent orders score higher", func(t *testing.T) {
+		old, recent := daysAgo(100), daysAgo(1)
+		metrics := map[string]*Metrics{
+			"old":    {LastOrderAt: &old},
+			"recent": {LastOrderAt: &recent},
+		}
+		score(metrics)
+		if metrics["old"].RScore >= metrics["recent"].RScore {
+			t.Errorf("RScore old = %d, recent = %d", metrics["old"].RScore, metrics["recent"].RScore)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package segment
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Field ルールで参照する指標
+type Field string
+
+const (
+	FieldRecencyDays          Field = "recency_days"           // 最後の注文からの日数
+	FieldFrequency            Field = "frequency"              // 集計期間内の注文数
+	FieldMonetary             Field = "monetary"               // 集計期間内の注文金額の合計
+	FieldRScore               Field = "r_score"                // Recency のスコア（1〜5、注文がなければ 0）
+	FieldFScore               Field = "f_score"                // Frequency のスコア
+	FieldMScore               Field = "m_score"                // Monetary のスコア
+	FieldInteractionCount     Field = "interaction_count"      // 集計期間内のインタラクション数
+	FieldDaysSinceInteraction Field = "days_since_interaction" // 最後のインタラクションからの日数
+	FieldAccountAgeDays       Field = "account_age_days"       // ユーザー作成からの日数
+)
+
+// Fields ルールで使える指標
+var Fields = []Field{
+	FieldRecencyDays, FieldFrequency, FieldMonetary,
+	FieldRScore, FieldFScore, FieldMScore,
+	FieldInteractionCount, FieldDaysSinceInteraction, FieldAccountAgeDays,
+}
+
+// Operator 比較演算子
+type Operator string
+
+const (
+	OpLT  Operator = "lt"
+	OpLTE Operator = "lte"
+	OpGT  Operator = "gt"
+	OpGTE Operator = "gte"
+	OpEQ  Operator = "eq"
+)
+
+// Rule 指標の条件（例: recency_days lte 30）
+type Rule struct {
+	Field Field    `firestore:"field"`
+	Op    Operator `firestore:"op"`
+	Value float64  `firestore:"value"`
+}
+
+// Validate 指標と演算子が正しいか
+func (r Rule) Validate() error {
+	valid := false
+	for _, f := range Fields {
+		valid = valid || f == r.Field
+	}
+	if !valid {
+		return fmt.Errorf("unknown segment field: %q", r.Field)
+	}
+	switch r.Op {
+	case OpLT, OpLTE, OpGT, OpGTE, OpEQ:
+		return nil
+	}
+	return fmt.Errorf("unknown segment operator: %q", r.Op)
+}
+
+// Match 指標が条件を満たすか
+func (r Rule) Match(m *Metrics) bool {
+	v := m.Value(r.Field)
+	switch r.Op {
+	case OpLT:
+		return v < r.Value
+	case OpLTE:
+		return v <= r.Value
+	case OpGT:
+		return v > r.Value
+	case OpGTE:
+		return v >= r.Value
+	case OpEQ:
+		return v == r.Value
+	}
+	return false
+}
+
+// Segment 保存済みのセグメント（segments ドキュメント）
+//
+// Rules をすべて満たすユーザーが所属する。Status を指定したセグメントは
+// ユーザーのステータス（hot / cold）も更新し、複数に該当する場合は Priority の小さいものが優先される。
+type Segment struct {
+	ID string `firestore:"-"`
+	// TenantID セグメントを定義した組織（評価もこの組織のユーザーだけを対象にする）
+	TenantID    string `firestore:"tenant_id"`
+	Name        string `firestore:"name"`
+	Description string `firestore:"description"`
+	Rules       []Rule `firestore:"rules"`
+	Status      string `firestore:"status,omitempty"` // "hot" / "cold"、空の場合はステータスを変えない
+	Priority    int    `firestore:"priority"`
+
+	// 最後の評価結果
+	UserCount   int        `firestore:"user_count"`
+	EvaluatedAt *time.Time `firestore:"evaluated_at"`
+
+	CreatedAt time.Time `firestore:"created_at"`
+	UpdatedAt time.Time `firestore:"updated_at"`
+}
+
+// Validate セグメントの定義が正しいか
+func (s *Segment) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("segment name is required")
+	}
+	if len(s.Rules) == 0 {
+		return fmt.Errorf("segment %s has no rules", s.Name)
+	}
+	for _, r := range s.Rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	switch s.Status {
+	case "", StatusHot, StatusCold:
+		return nil
+	}
+	return fmt.Errorf("segment status must be hot or cold: %q", s.Status)
+}
+
+// Match ユーザーがセグメントに所属するか
+func (s *Segment) Match(m *Metrics) bool {
+	for _, r := range s.Rules {
+		if !r.Match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+// never 一度も注文・インタラクションがない場合の経過日数（「無限に前」として比較する）
+var never = math.Inf(1)
@@ -0,0 +1,129 @@
+package segment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuleMatch(t *testing.T) {
+	last := daysAgo(30)
+	m := &Metrics{LastOrderAt: &last, Frequency: 3, Monetary: 1200, RScore: 4, now: testNow}
+
+	tests := []struct {
+		rule Rule
+		want bool
+	}{
+		{Rule{FieldRecencyDays, OpLTE, 30}, true},
+		{Rule{FieldRecencyDays, OpLT, 30}, false},
+		{Rule{FieldFrequency, OpGT, 2}, true},
+		{Rule{FieldFrequency, OpGTE, 4}, false},
+		{Rule{FieldMonetary, OpEQ, 1200}, true},
+		{Rule{FieldRScore, OpGTE, 4}, true},
+		{Rule{FieldDaysSinceInteraction, OpGT, 10000}, true}, // インタラクションなしは無限に前
+		{Rule{FieldDaysSinceInteraction, OpLTE, 14}, false},
+		{Rule{FieldFrequency, "ne", 3}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Match(m); got != tt.want {
+			t.Errorf("%+v Match() = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule    Rule
+		wantErr bool
+	}{
+		{rule: Rule{FieldMonetary, OpGTE, 100}},
+		{rule: Rule{FieldAccountAgeDays, OpLT, 30}},
+		{rule: Rule{"lifetime_value", OpGTE, 100}, wantErr: true},
+		{rule: Rule{FieldMonetary, "ne", 100}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v Validate() = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSegmentValidate(t *testing.T) {
+	rules := []Rule{{FieldFrequency, OpGTE, 1}}
+	tests := []struct {
+		name    string
+		segment Segment
+		wantErr bool
+	}{
+		{name: "valid", segment: Segment{Name: "buyers", Rules: rules}},
+		{name: "with status", segment: Segment{Name: "buyers", Rules: rules, Status: StatusHot}},
+		{name: "no name", segment: Segment{Rules: rules}, wantErr: true},
+		{name: "no rules", segment: Segment{Name: "buyers"}, wantErr: true},
+		{name: "invalid rule", segment: Segment{Name: "buyers", Rules: []Rule{{"x", OpGTE, 1}}}, wantErr: true},
+		{name: "invalid status", segment: Segment{Name: "buyers", Rules: rules, Status: StatusActive}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.segment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultSegments(t *testing.T) {
+	at := func(days int) *time.Time {
+		t := daysAgo(days)
+		return &t
+	}
+	tests := []struct {
+		name    string
+		metrics Metrics
+		want    []string
+	}{
+		{
+			name:    "champion who ordered recently",
+			metrics: Metrics{LastOrderAt: at(3), Frequency: 10, RScore: 5, FScore: 5, MScore: 4, CreatedAt: daysAgo(300)},
+			want:    []string{"champions", "recent_buyers"},
+		},
+		{
+			name:    "repeat customer who stopped ordering",
+			metrics: Metrics{LastOrderAt: at(120), Frequency: 2, RScore: 1, FScore: 3, MScore: 3, CreatedAt: daysAgo(300)},
+			want:    []string{"at_risk"},
+		},
+		{
+			name:    "user who never ordered",
+			metrics: Metrics{LastInteractionAt: at(200), CreatedAt: daysAgo(300)},
+			want:    []string{"dormant"},
+		},
+		{
+			name:    "new user who never ordered",
+			metrics: Metrics{LastInteractionAt: at(5), CreatedAt: daysAgo(10)},
+			want:    []string{"engaged"},
+		},
+	}
+	for _, s := range DefaultSegments {
+		if err := s.Validate(); err != nil {
+			t.Errorf("default segment %s: %v", s.ID, err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.metrics
+			m.now = testNow
+			var got []string
+			for _, s := range DefaultSegments {
+				if s.Match(&m) {
+					got = append(got, s.ID)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("matched %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("matched %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
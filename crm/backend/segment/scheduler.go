@@ -0,0 +1,91 @@
+package segment
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/tenant"
+)
+
+// scheduleDoc 定期評価の最終実行日時（segment_runs/schedule）
+//
+// 複数のインスタンスで Schedule を動かしても、Interval ごとに1回だけ評価する。
+const scheduleDoc = "schedule"
+
+// maxCheckInterval 実行時刻になったかを確認する間隔の上限
+const maxCheckInterval = 10 * time.Minute
+
+// Schedule Config.Interval ごとにすべての組織のセグメントを評価する（ctx が終了するまでブロック）
+func (e *Engine) Schedule(ctx context.Context) {
+	if e.Config.Interval <= 0 {
+		return
+	}
+
+	check := min(e.Config.Interval, maxCheckInterval)
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+
+	for {
+		due, err := e.claim(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to check segment schedule", slog.Any("error", err))
+		} else if due {
+			e.evaluateAll(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateAll 組織ごとに評価する（失敗した組織があっても残りの組織は評価する）
+func (e *Engine) evaluateAll(ctx context.Context) {
+	ids, err := tenant.IDs(ctx, e.Client)
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduled segment evaluation failed", slog.Any("error", err))
+		return
+	}
+
+	for _, id := range ids {
+		run, err := e.Evaluate(tenant.WithScope(ctx, tenant.Scope{TenantID: id}))
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduled segment evaluation failed", slog.String("tenant_id", id), slog.Any("error", err))
+			continue
+		}
+		slog.InfoContext(ctx, "segments evaluated",
+			slog.String("tenant_id", id),
+			slog.Int("users", run.Users),
+			slog.Int("status_changes", run.StatusChanges),
+			slog.Duration("took", run.FinishedAt.Sub(run.StartedAt)))
+	}
+}
+
+// claim 前回の実行から Interval が経過していれば実行日時を記録して true を返す
+func (e *Engine) claim(ctx context.Context) (bool, error) {
+	ref := e.Client.Collection(runsCollection).Doc(scheduleDoc)
+	due := false
+	err := e.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		now := time.Now()
+		doc, err := tx.Get(ref)
+		if err != nil && grpcstatus.Code(err) != codes.NotFound {
+			return err
+		}
+		if doc != nil && doc.Exists() {
+			if last, ok := doc.Data()["last_started_at"].(time.Time); ok && now.Sub(last) < e.Config.Interval {
+				due = false
+				return nil
+			}
+		}
+		due = true
+		return tx.Set(ref, map[string]interface{}{"last_started_at": now})
+	})
+	return due, err
+}
@@ -0,0 +1,223 @@
+package segment
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+)
+
+// Firestore のコレクション
+const (
+	segmentsCollection = "segments"
+	runsCollection     = "segment_runs"
+)
+
+// ユーザーのステータス（Firestore の値）
+const (
+	StatusActive = "active"
+	StatusHot    = "hot"
+	StatusCold   = "cold"
+)
+
+// DefaultSegments segments が空の場合に作成するセグメント
+//
+// 注文・インタラクションがない場合の経過日数は無限大として比較するため、
+// 「recency_days gt 180」は一度も注文していないユーザーも含む。
+var DefaultSegments = []Segment{
+	{
+		ID:          "champions",
+		Name:        "優良顧客",
+		Description: "最近・頻繁に・多く購入している顧客（RFM スコアがすべて 4 以上）",
+		Rules: []Rule{
+			{Field: FieldRScore, Op: OpGTE, Value: 4},
+			{Field: FieldFScore, Op: OpGTE, Value: 4},
+			{Field: FieldMScore, Op: OpGTE, Value: 4},
+		},
+		Status:   StatusHot,
+		Priority: 10,
+	},
+	{
+		ID:          "recent_buyers",
+		Name:        "最近の購入者",
+		Description: "30日以内に注文した顧客",
+		Rules:       []Rule{{Field: FieldRecencyDays, Op: OpLTE, Value: 30}},
+		Status:      StatusHot,
+		Priority:    20,
+	},
+	{
+		ID:          "engaged",
+		Name:        "対応中",
+		Description: "14日以内にインタラクションがあった顧客",
+		Rules:       []Rule{{Field: FieldDaysSinceInteraction, Op: OpLTE, Value: 14}},
+		Status:      StatusHot,
+		Priority:    30,
+	},
+	{
+		ID:          "at_risk",
+		Name:        "離反リスク",
+		Description: "2回以上注文しているが、90日以上注文がない顧客",
+		Rules: []Rule{
+			{Field: FieldFrequency, Op: OpGTE, Value: 2},
+			{Field: FieldRecencyDays, Op: OpGT, Value: 90},
+		},
+		Priority: 40,
+	},
+	{
+		ID:          "dormant",
+		Name:        "休眠顧客",
+		Description: "180日以上注文がなく、90日以上インタラクションもない顧客（登録から30日以内は除く）",
+		Rules: []Rule{
+			{Field: FieldRecencyDays, Op: OpGT, Value: 180},
+			{Field: FieldDaysSinceInteraction, Op: OpGT, Value: 90},
+			{Field: FieldAccountAgeDays, Op: OpGT, Value: 30},
+		},
+		Status:   StatusCold,
+		Priority: 50,
+	},
+}
+
+// List スコープの組織のセグメントを Priority の順に取得
+func List(ctx context.Context, client *firestore.Client) ([]*Segment, error) {
+	docs, err := tenant.Query(ctx, client.Collection(segmentsCollection).Query).OrderBy("priority", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to list segments")
+	}
+
+	segments := make([]*Segment, 0, len(docs))
+	for _, doc := range docs {
+		s, err := segmentFromSnapshot(doc)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// Get セグメントを取得（スコープ外の組織のセグメントは NOT_FOUND）
+func Get(ctx context.Context, client *firestore.Client, id string) (*Segment, error) {
+	doc, err := client.Collection(segmentsCollection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("segment not found: %s", id)
+		}
+		return nil, apperr.Internal(err, "failed to get segment")
+	}
+	s, err := segmentFromSnapshot(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.Contains(ctx, s.TenantID) {
+		return nil, apperr.NotFound("segment not found: %s", id)
+	}
+	return s, nil
+}
+
+// Save セグメントを作成・更新する（ID が空の場合はコンテキストの組織に作成）。評価結果は次回の評価で更新される
+func Save(ctx context.Context, client *firestore.Client, s *Segment) (*Segment, error) {
+	if err := s.Validate(); err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+
+	now := time.Now()
+	col := client.Collection(segmentsCollection)
+	if s.ID == "" {
+		tenantID, err := tenant.ID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ref := col.NewDoc()
+		s.ID = ref.ID
+		s.TenantID = tenantID
+		s.CreatedAt = now
+		s.UpdatedAt = now
+		if _, err := ref.Create(ctx, s); err != nil {
+			return nil, apperr.Internal(err, "failed to create segment")
+		}
+		return s, nil
+	}
+
+	if _, err := Get(ctx, client, s.ID); err != nil {
+		return nil, err
+	}
+	_, err := col.Doc(s.ID).Update(ctx, []firestore.Update{
+		{Path: "name", Value: s.Name},
+		{Path: "description", Value: s.Description},
+		{Path: "rules", Value: s.Rules},
+		{Path: "status", Value: s.Status},
+		{Path: "priority", Value: s.Priority},
+		{Path: "updated_at", Value: now},
+	})
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("segment not found: %s", s.ID)
+		}
+		return nil, apperr.Internal(err, "failed to update segment")
+	}
+	return Get(ctx, client, s.ID)
+}
+
+// Delete セグメントを削除する。ユーザーの所属は次回の評価で外れる
+func Delete(ctx context.Context, client *firestore.Client, id string) error {
+	if _, err := Get(ctx, client, id); err != nil {
+		return err
+	}
+	if _, err := client.Collection(segmentsCollection).Doc(id).Delete(ctx, firestore.Exists); err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return apperr.NotFound("segment not found: %s", id)
+		}
+		return apperr.Internal(err, "failed to delete segment")
+	}
+	return nil
+}
+
+// EnsureDefaults コンテキストの組織にセグメントがない場合に DefaultSegments を作成する
+func EnsureDefaults(ctx context.Context, client *firestore.Client) error {
+	ctx, tenantID, err := tenant.Single(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := tenant.Query(ctx, client.Collection(segmentsCollection).Query).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	if len(docs) > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, s := range DefaultSegments {
+		s.TenantID = tenantID
+		s.CreatedAt = now
+		s.UpdatedAt = now
+		if _, err := client.Collection(segmentsCollection).Doc(defaultSegmentID(tenantID, s.ID)).Create(ctx, s); err != nil && grpcstatus.Code(err) != codes.AlreadyExists {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultSegmentID DefaultSegments のドキュメントID
+//
+// セグメントのコレクションは組織で共通のため、DefaultID 以外の組織では組織IDを前に付ける。
+func defaultSegmentID(tenantID, id string) string {
+	if tenantID == tenant.DefaultID {
+		return id
+	}
+	return tenantID + "_" + id
+}
+
+func segmentFromSnapshot(doc *firestore.DocumentSnapshot) (*Segment, error) {
+	var s Segment
+	if err := doc.DataTo(&s); err != nil {
+		return nil, apperr.Internal(err, "failed to decode segment %s", doc.Ref.ID)
+	}
+	s.ID = doc.Ref.ID
+	return &s, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"firebase.google.com/go/v4/auth"
+	"go.opentelemetry.io/otel/attribute"
+
+	"narratives-crm-backend/tracing"
+)
+
+// Firebase Auth のエラーのうち、呼び出し側で扱いを変えるもの（errors.Is で判定する）
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailAlreadyExists = errors.New("email address already exists")
+)
+
+// AuthUserToCreate 作成する Firebase Auth ユーザー（auth.UserToCreate は値を取り出せないため別に定義する）
+type AuthUserToCreate struct {
+	Email         string
+	Password      string
+	DisplayName   string
+	EmailVerified bool
+	Disabled      bool
+}
+
+// AuthProvider FirebaseAuthService が使う Firebase Auth の操作
+//
+// 本番では NewFirebaseAuthProvider、テストでは FakeAuthProvider を使う。
+// ユーザーが存在しない場合は ErrUserNotFound、メールアドレスが登録済みの場合は
+// ErrEmailAlreadyExists を（ラップして）返すこと。
+type AuthProvider interface {
+	GetUser(ctx context.Context, uid string) (*auth.UserRecord, error)
+	GetUserByEmail(ctx context.Context, email string) (*auth.UserRecord, error)
+	CreateUser(ctx context.Context, user AuthUserToCreate) (*auth.UserRecord, error)
+	DeleteUser(ctx context.Context, uid string) error
+	SetDisabled(ctx context.Context, uid string, disabled bool) error
+	RevokeRefreshTokens(ctx context.Context, uid string) error
+	SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error
+	EmailVerificationLinkWithSettings(ctx context.Context, email string, settings *auth.ActionCodeSettings) (string, error)
+}
+
+// firebaseAuthProvider *auth.Client の AuthProvider 実装（呼び出しごとにスパンを作成する）
+type firebaseAuthProvider struct {
+	client *auth.Client
+}
+
+// NewFirebaseAuthProvider Firebase Admin SDK の Auth クライアントを AuthProvider として使う
+func NewFirebaseAuthProvider(client *auth.Client) AuthProvider {
+	return &firebaseAuthProvider{client: client}
+}
+
+// GetUser Firebase Auth の GetUser をスパン付きで呼び出す
+func (p *firebaseAuthProvider) GetUser(ctx context.Context, uid string) (user *auth.UserRecord, err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.GetUser", attribute.String("firebase.uid", uid))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	user, err = p.client.GetUser(ctx, uid)
+	return user, authError(err)
+}
+
+// GetUserByEmail Firebase Auth の GetUserByEmail をスパン付きで呼び出す
+func (p *firebaseAuthProvider) GetUserByEmail(ctx context.Context, email string) (user *auth.UserRecord, err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.GetUserByEmail")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	user, err = p.client.GetUserByEmail(ctx, email)
+	return user, authError(err)
+}
+
+// CreateUser Firebase Auth の CreateUser をスパン付きで呼び出す
+func (p *firebaseAuthProvider) CreateUser(ctx context.Context, user AuthUserToCreate) (record *auth.UserRecord, err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.CreateUser")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	params := (&auth.UserToCreate{}).
+		Email(user.Email).
+		EmailVerified(user.EmailVerified).
+		Password(user.Password).
+		Disabled(user.Disabled)
+	if user.DisplayName != "" {
+		params = params.DisplayName(user.DisplayName)
+	}
+	record, err = p.client.CreateUser(ctx, params)
+	return record, authError(err)
+}
+
+// DeleteUser Firebase Auth の DeleteUser をスパン付きで呼び出す
+func (p *firebaseAuthProvider) DeleteUser(ctx context.Context, uid string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.DeleteUser", attribute.String("firebase.uid", uid))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return authError(p.client.DeleteUser(ctx, uid))
+}
+
+// SetDisabled Firebase Auth の UpdateUser でアカウントを無効化（disabled=false の場合は有効化）する
+func (p *firebaseAuthProvider) SetDisabled(ctx context.Context, uid string, disabled bool) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.UpdateUser", attribute.String("firebase.uid", uid))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = p.client.UpdateUser(ctx, uid, (&auth.UserToUpdate{}).Disabled(disabled))
+	return authError(err)
+}
+
+// RevokeRefreshTokens Firebase Auth の RevokeRefreshTokens をスパン付きで呼び出す
+func (p *firebaseAuthProvider) RevokeRefreshTokens(ctx context.Context, uid string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.RevokeRefreshTokens", attribute.String("firebase.uid", uid))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return authError(p.client.RevokeRefreshTokens(ctx, uid))
+}
+
+// SetCustomUserClaims Firebase Auth の SetCustomUserClaims をスパン付きで呼び出す
+func (p *firebaseAuthProvider) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.SetCustomUserClaims", attribute.String("firebase.uid", uid))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return authError(p.client.SetCustomUserClaims(ctx, uid, claims))
+}
+
+// EmailVerificationLinkWithSettings Firebase Auth の EmailVerificationLinkWithSettings をスパン付きで呼び出す
+func (p *firebaseAuthProvider) EmailVerificationLinkWithSettings(ctx context.Context, email string, settings *auth.ActionCodeSettings) (link string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "firebase.auth.EmailVerificationLinkWithSettings")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	link, err = p.client.EmailVerificationLinkWithSettings(ctx, email, settings)
+	return link, authError(err)
+}
+
+// authError Firebase Auth のエラーを ErrUserNotFound / ErrEmailAlreadyExists でラップする
+func authError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case auth.IsUserNotFound(err), auth.IsEmailNotFound(err):
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	case auth.IsEmailAlreadyExists(err):
+		return fmt.Errorf("%w: %w", ErrEmailAlreadyExists, err)
+	}
+	return err
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+)
+
+const (
+	// DefaultBusinessUserRole ロールを指定しなかった場合のロール
+	DefaultBusinessUserRole = "user"
+
+	businessUsersCollection = "business_users"
+)
+
+// BusinessUserInput 招待するビジネスユーザー
+type BusinessUserInput struct {
+	FirstName         string
+	LastName          string
+	FirstNameKatakana string
+	LastNameKatakana  string
+	EmailAddress      string
+	// Role カスタムクレーム role に設定する値（空の場合は DefaultBusinessUserRole）
+	Role string
+	// TenantID 所属する組織（カスタムクレーム tenant_id、空の場合は tenant.DefaultID）
+	TenantID string
+}
+
+// CreateBusinessUser ビジネスユーザーを招待する
+//
+// 一時パスワードで Firebase Auth のユーザーを作成してロールと組織をカスタムクレームに設定し、
+// business_users に保存したうえで welcome_email 通知を作成する（メールはフロントエンドが送信する）。
+// 途中で失敗した場合は作成した Firebase Auth のユーザーを削除する。
+func (fas *FirebaseAuthService) CreateBusinessUser(ctx context.Context, input BusinessUserInput) (*BusinessUserData, error) {
+	if fas.client == nil || fas.firestoreClient == nil {
+		return nil, apperr.New(apperr.CodeInternal, "firebase clients are not initialized")
+	}
+	role := input.Role
+	if role == "" {
+		role = DefaultBusinessUserRole
+	}
+	tenantID := tenantOrDefault(input.TenantID)
+
+	tempPassword, err := generateTemporaryPassword()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to generate temporary password")
+	}
+
+	userRecord, err := fas.client.CreateUser(ctx, AuthUserToCreate{
+		Email:       input.EmailAddress,
+		Password:    tempPassword,
+		DisplayName: fmt.Sprintf("%s %s", input.LastName, input.FirstName),
+	})
+	if err != nil {
+		if errors.Is(err, ErrEmailAlreadyExists) {
+			return nil, apperr.Conflict("email address is already registered: %s", input.EmailAddress)
+		}
+		return nil, apperr.Internal(err, "failed to create user in Firebase Auth")
+	}
+
+	claims := map[string]interface{}{"role": role, tenant.ClaimTenantID: tenantID}
+	if err := fas.client.SetCustomUserClaims(ctx, userRecord.UID, claims); err != nil {
+		fas.rollbackCreatedUser(ctx, userRecord.UID)
+		return nil, apperr.Internal(err, "failed to set custom claims")
+	}
+
+	now := time.Now()
+	businessUser := map[string]interface{}{
+		"business_user_id":    userRecord.UID,
+		"first_name":          input.FirstName,
+		"last_name":           input.LastName,
+		"first_name_katakana": input.FirstNameKatakana,
+		"last_name_katakana":  input.LastNameKatakana,
+		"email_address":       input.EmailAddress,
+		"role":                role,
+		"balance":             0.0,
+		"status":              "active",
+		"temporary_password":  tempPassword,
+		tenant.FieldTenantID:  tenantID,
+		"created_at":          now,
+		"updated_at":          now,
+	}
+	if _, err := fas.firestoreClient.Collection(businessUsersCollection).Doc(userRecord.UID).Set(ctx, businessUser); err != nil {
+		fas.rollbackCreatedUser(ctx, userRecord.UID)
+		return nil, apperr.Internal(err, "failed to save business user to Firestore")
+	}
+
+	// Welcome email 通知を作成（通知の作成に失敗してもユーザーの作成は成功とする）
+	if err := fas.createWelcomeEmailNotification(ctx, userRecord.UID, tenantID, now); err != nil {
+		slog.WarnContext(ctx, "failed to create welcome email notification",
+			slog.String("email", input.EmailAddress), slog.Any("error", err))
+	}
+
+	slog.InfoContext(ctx, "business user created",
+		slog.String("uid", userRecord.UID), slog.String("role", role), slog.String("tenant_id", tenantID))
+	return &BusinessUserData{
+		BusinessUserID:    userRecord.UID,
+		TenantID:          tenantID,
+		FirstName:         input.FirstName,
+		LastName:          input.LastName,
+		FirstNameKatakana: input.FirstNameKatakana,
+		LastNameKatakana:  input.LastNameKatakana,
+		EmailAddress:      input.EmailAddress,
+		Role:              role,
+		TemporaryPassword: &tempPassword,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}, nil
+}
+
+// BusinessUserFilter ListBusinessUsers の絞り込み条件
+type BusinessUserFilter struct {
+	// Role 指定したロールのビジネスユーザーだけ（大文字・小文字は区別しない）
+	Role string
+	// IncludeDisabled 無効化したビジネスユーザーも含める
+	IncludeDisabled bool
+}
+
+// ListBusinessUsers スコープの組織のビジネスユーザーを姓・名の順に取得する
+func (fas *FirebaseAuthService) ListBusinessUsers(ctx context.Context, filter BusinessUserFilter) ([]*BusinessUserData, error) {
+	query := tenant.Query(ctx, fas.firestoreClient.Collection(businessUsersCollection).Query)
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to list business users")
+	}
+
+	users := make([]*BusinessUserData, 0, len(docs))
+	for _, doc := range docs {
+		user, err := businessUserFromSnapshot(doc)
+		if err != nil {
+			return nil, err
+		}
+		if user.Disabled && !filter.IncludeDisabled {
+			continue
+		}
+		if filter.Role != "" && !strings.EqualFold(user.Role, filter.Role) {
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		if users[i].LastName != users[j].LastName {
+			return users[i].LastName < users[j].LastName
+		}
+		return users[i].FirstName < users[j].FirstName
+	})
+	return users, nil
+}
+
+// GetBusinessUser ビジネスユーザーを取得する（スコープ外の組織のビジネスユーザーは NOT_FOUND）
+func (fas *FirebaseAuthService) GetBusinessUser(ctx context.Context, uid string) (*BusinessUserData, error) {
+	doc, err := fas.firestoreClient.Collection(businessUsersCollection).Doc(uid).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("business user not found: %s", uid)
+		}
+		return nil, apperr.Internal(err, "failed to get business user")
+	}
+	if !tenant.Allows(ctx, doc.Data()) {
+		return nil, apperr.NotFound("business user not found: %s", uid)
+	}
+	return businessUserFromSnapshot(doc)
+}
+
+// ChangeBusinessUserRole ビジネスユーザーのロールを変更する
+//
+// カスタムクレーム role（tenant_id などの他のクレームは残す）と business_users の role を更新する。
+// クレームは ID トークンの更新時（最長1時間後）に反映される。
+func (fas *FirebaseAuthService) ChangeBusinessUserRole(ctx context.Context, uid, role string) (*BusinessUserData, error) {
+	user, err := fas.GetBusinessUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := fas.client.GetUser(ctx, uid)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, apperr.NotFound("business user has no Firebase Auth account: %s", uid)
+		}
+		return nil, apperr.Internal(err, "failed to get user from Firebase Auth")
+	}
+	claims := make(map[string]interface{}, len(record.CustomClaims)+2)
+	for k, v := range record.CustomClaims {
+		claims[k] = v
+	}
+	claims["role"] = role
+	claims[tenant.ClaimTenantID] = tenantOrDefault(user.TenantID)
+	if err := fas.client.SetCustomUserClaims(ctx, uid, claims); err != nil {
+		return nil, apperr.Internal(err, "failed to set custom claims")
+	}
+
+	now := time.Now()
+	_, err = fas.firestoreClient.Collection(businessUsersCollection).Doc(uid).Update(ctx, []firestore.Update{
+		{Path: "role", Value: role},
+		{Path: "updated_at", Value: now},
+	})
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to update business user")
+	}
+
+	slog.InfoContext(ctx, "business user role changed",
+		slog.String("uid", uid), slog.String("from", user.Role), slog.String("to", role))
+	user.Role = role
+	user.UpdatedAt = now
+	return user, nil
+}
+
+// DisableBusinessUser ビジネスユーザーを無効化する
+//
+// Firebase Auth のアカウントを無効化してリフレッシュトークンを取り消し、business_users に disabled を記録する。
+// 発行済みの ID トークンは有効期限（最長1時間）まで使えるため、それ以降はログインできなくなる。
+func (fas *FirebaseAuthService) DisableBusinessUser(ctx context.Context, uid string) (*BusinessUserData, error) {
+	user, err := fas.GetBusinessUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return user, nil
+	}
+
+	// Firebase Auth のアカウントがない（手動で削除された）場合も business_users は無効化する
+	if err := fas.client.SetDisabled(ctx, uid, true); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, apperr.Internal(err, "failed to disable user in Firebase Auth")
+	}
+	if err := fas.client.RevokeRefreshTokens(ctx, uid); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, apperr.Internal(err, "failed to revoke refresh tokens")
+	}
+
+	now := time.Now()
+	_, err = fas.firestoreClient.Collection(businessUsersCollection).Doc(uid).Update(ctx, []firestore.Update{
+		{Path: "disabled", Value: true},
+		{Path: "disabled_at", Value: now},
+		{Path: "updated_at", Value: now},
+	})
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to update business user")
+	}
+
+	slog.InfoContext(ctx, "business user disabled", slog.String("uid", uid))
+	user.Disabled = true
+	user.DisabledAt = &now
+	user.UpdatedAt = now
+	return user, nil
+}
+
+// SyncBusinessUserClaims business_users の組織をカスタムクレーム tenant_id に設定し、更新したユーザーのUIDを返す
+//
+// tenant_id クレームのないユーザーはどの組織のデータにもアクセスできないため、マルチテナント化より前に
+// 作成したビジネスユーザーに使う。Firebase Auth のアカウントがないビジネスユーザーは飛ばす。
+func (fas *FirebaseAuthService) SyncBusinessUserClaims(ctx context.Context) ([]string, error) {
+	users, err := fas.ListBusinessUsers(ctx, BusinessUserFilter{IncludeDisabled: true})
+	if err != nil {
+		return nil, err
+	}
+
+	updated := []string{}
+	for _, user := range users {
+		record, err := fas.client.GetUser(ctx, user.BusinessUserID)
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return updated, apperr.Internal(err, "failed to get user from Firebase Auth")
+		}
+		if record.CustomClaims[tenant.ClaimTenantID] == user.TenantID {
+			continue
+		}
+
+		claims := make(map[string]interface{}, len(record.CustomClaims)+2)
+		for k, v := range record.CustomClaims {
+			claims[k] = v
+		}
+		claims[tenant.ClaimTenantID] = user.TenantID
+		if _, ok := claims["role"]; !ok {
+			claims["role"] = user.Role
+		}
+		if err := fas.client.SetCustomUserClaims(ctx, user.BusinessUserID, claims); err != nil {
+			return updated, apperr.Internal(err, "failed to set custom claims")
+		}
+		updated = append(updated, user.BusinessUserID)
+	}
+	return updated, nil
+}
+
+// businessUserFromSnapshot business_users ドキュメントを BusinessUserData に変換
+func businessUserFromSnapshot(doc *firestore.DocumentSnapshot) (*BusinessUserData, error) {
+	var user BusinessUserData
+	if err := doc.DataTo(&user); err != nil {
+		return nil, apperr.Internal(err, "failed to decode business user %s", doc.Ref.ID)
+	}
+	user.BusinessUserID = doc.Ref.ID
+	user.TenantID = tenantOrDefault(user.TenantID)
+	if user.Role == "" {
+		user.Role = DefaultBusinessUserRole
+	}
+	return &user, nil
+}
+
+// tenantOrDefault tenant_id がない（マルチテナント化より前の）場合は tenant.DefaultID
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return tenant.DefaultID
+	}
+	return tenantID
+}
+
+// createWelcomeEmailNotification welcome_email 通知を notifications に作成
+//
+// メールを送信する側が組織のブランドで送れるよう、送信時点の組織の設定（言語・ブランド）を含める。
+func (fas *FirebaseAuthService) createWelcomeEmailNotification(ctx context.Context, uid, tenantID string, now time.Time) error {
+	settings := tenant.SettingsFor(ctx, fas.firestoreClient, tenantID)
+	_, _, err := fas.firestoreClient.Collection("notifications").Add(ctx, map[string]interface{}{
+		"notification_id":    fmt.Sprintf("welcome_%s_%d", uid, now.Unix()),
+		"business_user_id":   uid,
+		"notification_type":  "welcome_email",
+		"processed":          false,
+		tenant.FieldTenantID: tenantID,
+		"locale":             settings.Locale,
+		"branding":           settings.Branding,
+		"created_at":         now,
+		"updated_at":         now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create welcome email notification: %w", err)
+	}
+	slog.InfoContext(ctx, "welcome email notification created", slog.String("uid", uid))
+	return nil
+}
+
+// rollbackCreatedUser 招待の途中で失敗した場合に作成済みの Firebase Auth ユーザーを削除する
+func (fas *FirebaseAuthService) rollbackCreatedUser(ctx context.Context, uid string) {
+	if err := fas.client.DeleteUser(context.WithoutCancel(ctx), uid); err != nil {
+		slog.ErrorContext(ctx, "failed to roll back created user", slog.String("uid", uid), slog.Any("error", err))
+	}
+}
+
+// generateTemporaryPassword 招待時の一時パスワードを生成
+func generateTemporaryPassword() (string, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	const length = 12
+
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n.Int64()]
+	}
+	return string(password), nil
+}
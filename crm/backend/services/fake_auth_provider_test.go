@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+// FakeAuthProvider テスト用のメモリ上の AuthProvider
+//
+// Firebase Auth と同じく、存在しないユーザーには ErrUserNotFound、登録済みのメールアドレスには
+// ErrEmailAlreadyExists を返す。カスタムクレームはユーザーごとに保存し、GetUserByEmail の結果に含める。
+type FakeAuthProvider struct {
+	mu     sync.Mutex
+	users  map[string]*fakeAuthUser // UID → ユーザー
+	nextID int
+
+	// Errors メソッド名（例: "SetCustomUserClaims"）ごとに返すエラー。失敗時の動作の確認に使う
+	Errors map[string]error
+
+	// Links EmailVerificationLinkWithSettings で生成したリンク（メールアドレス → リンク）
+	Links map[string]string
+}
+
+type fakeAuthUser struct {
+	record auth.UserRecord
+	info   auth.UserInfo
+}
+
+// NewFakeAuthProvider 空の FakeAuthProvider
+func NewFakeAuthProvider() *FakeAuthProvider {
+	return &FakeAuthProvider{
+		users:  map[string]*fakeAuthUser{},
+		Errors: map[string]error{},
+		Links:  map[string]string{},
+	}
+}
+
+// GetUser AuthProvider の実装
+func (f *FakeAuthProvider) GetUser(_ context.Context, uid string) (*auth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["GetUser"]; err != nil {
+		return nil, err
+	}
+	u, ok := f.users[uid]
+	if !ok {
+		return nil, fmt.Errorf("%w: no user record found for the given uid: %s", ErrUserNotFound, uid)
+	}
+	return u.copy(), nil
+}
+
+// GetUserByEmail AuthProvider の実装
+func (f *FakeAuthProvider) GetUserByEmail(_ context.Context, email string) (*auth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["GetUserByEmail"]; err != nil {
+		return nil, err
+	}
+	u := f.findByEmail(email)
+	if u == nil {
+		return nil, fmt.Errorf("%w: no user record found for the given email: %s", ErrUserNotFound, email)
+	}
+	return u.copy(), nil
+}
+
+// CreateUser AuthProvider の実装
+func (f *FakeAuthProvider) CreateUser(_ context.Context, user AuthUserToCreate) (*auth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["CreateUser"]; err != nil {
+		return nil, err
+	}
+	if !strings.Contains(user.Email, "@") {
+		return nil, fmt.Errorf("malformed email string: %q", user.Email)
+	}
+	if len(user.Password) < 6 {
+		return nil, fmt.Errorf("password must be a string at least 6 characters long")
+	}
+	if f.findByEmail(user.Email) != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEmailAlreadyExists, user.Email)
+	}
+
+	f.nextID++
+	u := &fakeAuthUser{
+		info: auth.UserInfo{
+			UID:         fmt.Sprintf("fake-uid-%d", f.nextID),
+			Email:       user.Email,
+			DisplayName: user.DisplayName,
+			ProviderID:  "firebase",
+		},
+	}
+	u.record = auth.UserRecord{EmailVerified: user.EmailVerified, Disabled: user.Disabled}
+	f.users[u.info.UID] = u
+	return u.copy(), nil
+}
+
+// DeleteUser AuthProvider の実装
+func (f *FakeAuthProvider) DeleteUser(_ context.Context, uid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["DeleteUser"]; err != nil {
+		return err
+	}
+	if _, ok := f.users[uid]; !ok {
+		return fmt.Errorf("%w: no user record found for the given uid: %s", ErrUserNotFound, uid)
+	}
+	delete(f.users, uid)
+	return nil
+}
+
+// SetDisabled AuthProvider の実装
+func (f *FakeAuthProvider) SetDisabled(_ context.Context, uid string, disabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["SetDisabled"]; err != nil {
+		return err
+	}
+	u, ok := f.users[uid]
+	if !ok {
+		return fmt.Errorf("%w: no user record found for the given uid: %s", ErrUserNotFound, uid)
+	}
+	u.record.Disabled = disabled
+	return nil
+}
+
+// RevokeRefreshTokens AuthProvider の実装（TokensValidAfterMillis を現在時刻にする）
+func (f *FakeAuthProvider) RevokeRefreshTokens(_ context.Context, uid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["RevokeRefreshTokens"]; err != nil {
+		return err
+	}
+	u, ok := f.users[uid]
+	if !ok {
+		return fmt.Errorf("%w: no user record found for the given uid: %s", ErrUserNotFound, uid)
+	}
+	u.record.TokensValidAfterMillis = time.Now().UnixMilli()
+	return nil
+}
+
+// SetCustomUserClaims AuthProvider の実装（nil を渡すとクレームを削除する）
+func (f *FakeAuthProvider) SetCustomUserClaims(_ context.Context, uid string, claims map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["SetCustomUserClaims"]; err != nil {
+		return err
+	}
+	u, ok := f.users[uid]
+	if !ok {
+		return fmt.Errorf("%w: no user record found for the given uid: %s", ErrUserNotFound, uid)
+	}
+	u.record.CustomClaims = copyClaims(claims)
+	return nil
+}
+
+// EmailVerificationLinkWithSettings AuthProvider の実装（生成したリンクは Links に記録する）
+func (f *FakeAuthProvider) EmailVerificationLinkWithSettings(_ context.Context, email string, settings *auth.ActionCodeSettings) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.Errors["EmailVerificationLinkWithSettings"]; err != nil {
+		return "", err
+	}
+	u := f.findByEmail(email)
+	if u == nil {
+		return "", fmt.Errorf("%w: no user record found for the given email: %s", ErrUserNotFound, email)
+	}
+
+	query := url.Values{"mode": {"verifyEmail"}, "oobCode": {"fake-oob-code-" + u.info.UID}}
+	if settings != nil && settings.URL != "" {
+		query.Set("continueUrl", settings.URL)
+	}
+	link := "https://fake-auth.example.com/__/auth/action?" + query.Encode()
+	f.Links[email] = link
+	return link, nil
+}
+
+// AddUser ユーザーを直接登録する（テストの前提データ用）
+func (f *FakeAuthProvider) AddUser(uid, email string, claims map[string]interface{}) *auth.UserRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u := &fakeAuthUser{info: auth.UserInfo{UID: uid, Email: email, ProviderID: "firebase"}}
+	u.record.CustomClaims = copyClaims(claims)
+	f.users[uid] = u
+	return u.copy()
+}
+
+// User UID のユーザー（存在しない場合は nil）
+func (f *FakeAuthProvider) User(uid string) *auth.UserRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if u, ok := f.users[uid]; ok {
+		return u.copy()
+	}
+	return nil
+}
+
+// Len 登録されているユーザー数
+func (f *FakeAuthProvider) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.users)
+}
+
+// findByEmail メールアドレスのユーザー（Firebase Auth と同じく大文字・小文字を区別しない）
+func (f *FakeAuthProvider) findByEmail(email string) *fakeAuthUser {
+	for _, u := range f.users {
+		if strings.EqualFold(u.info.Email, email) {
+			return u
+		}
+	}
+	return nil
+}
+
+// copy 呼び出し側で変更されても影響しないようにコピーを返す
+func (u *fakeAuthUser) copy() *auth.UserRecord {
+	record := u.record
+	info := u.info
+	record.UserInfo = &info
+	record.CustomClaims = copyClaims(u.record.CustomClaims)
+	return &record
+}
+
+func copyClaims(claims map[string]interface{}) map[string]interface{} {
+	if claims == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(claims))
+	for k, v := range claims {
+		copied[k] = v
+	}
+	return copied
+}
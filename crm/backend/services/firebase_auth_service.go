@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
@@ -16,14 +17,23 @@ import (
 
 // FirebaseAuthService Firebase認証サービス
 type FirebaseAuthService struct {
-	client          *auth.Client
+	client          AuthProvider
 	firestoreClient *firestore.Client
 }
 
 // NewFirebaseAuthService Firebase認証サービスのコンストラクタ
 func NewFirebaseAuthService(client *auth.Client, firestoreClient *firestore.Client) *FirebaseAuthService {
+	var provider AuthProvider
+	if client != nil {
+		provider = NewFirebaseAuthProvider(client)
+	}
+	return NewFirebaseAuthServiceWithProvider(provider, firestoreClient)
+}
+
+// NewFirebaseAuthServiceWithProvider Firebase Auth の操作を差し替えたコンストラクタ（テストでは FakeAuthProvider を渡す）
+func NewFirebaseAuthServiceWithProvider(provider AuthProvider, firestoreClient *firestore.Client) *FirebaseAuthService {
 	return &FirebaseAuthService{
-		client:          client,
+		client:          provider,
 		firestoreClient: firestoreClient,
 	}
 }
@@ -33,18 +43,20 @@ func (fas *FirebaseAuthService) GenerateEmailVerificationLink(ctx context.Contex
 	// ユーザー情報を取得
 	user, err := fas.client.GetUserByEmail(ctx, email)
 	if err != nil {
-		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		slog.ErrorContext(ctx, "failed to get user by email", slog.String("email", email), slog.Any("error", err))
 		return "", fmt.Errorf("ユーザー情報の取得に失敗: %v", err)
 	}
 
-	// カスタムクレームを設定（オプション）
-	claims := map[string]interface{}{
-		"email_verification": true,
-		"timestamp":          time.Now().Unix(),
+	// カスタムクレームを設定（オプション）。role / tenant_id が消えないよう既存のクレームに追加する
+	claims := make(map[string]interface{}, len(user.CustomClaims)+2)
+	for k, v := range user.CustomClaims {
+		claims[k] = v
 	}
+	claims["email_verification"] = true
+	claims["timestamp"] = time.Now().Unix()
 
 	if err := fas.client.SetCustomUserClaims(ctx, user.UID, claims); err != nil {
-		log.Printf("カスタムクレームの設定に失敗: %v", err)
+		slog.WarnContext(ctx, "failed to set custom claims", slog.String("uid", user.UID), slog.Any("error", err))
 		// エラーは無視してメイン処理を続行
 	}
 
@@ -62,20 +74,20 @@ func (fas *FirebaseAuthService) GenerateEmailVerificationLink(ctx context.Contex
 	// アクションコード設定付きでFirebase認証リンクを生成
 	link, err := fas.client.EmailVerificationLinkWithSettings(ctx, email, settings)
 	if err != nil {
-		log.Printf("Firebase認証リンクの生成に失敗: %v", err)
+		slog.ErrorContext(ctx, "failed to generate email verification link", slog.String("email", email), slog.Any("error", err))
 		return "", fmt.Errorf("firebase認証リンクの生成に失敗: %v", err)
 	}
 
-	log.Printf("Firebase認証リンクを生成しました: %s", email)
+	slog.InfoContext(ctx, "email verification link generated", slog.String("email", email))
 	return link, nil
 }
 
 // DeleteUserByEmail メールアドレスでビジネスユーザーをFirebase Authenticationから削除
 func (fas *FirebaseAuthService) DeleteUserByEmail(ctx context.Context, email string) error {
-	log.Printf("DeleteUserByEmail: Firebase Authenticationからビジネスユーザーを削除します (email: %s)", email)
+	slog.InfoContext(ctx, "deleting business user from Firebase Auth", slog.String("email", email))
 
 	if fas.client == nil {
-		log.Printf("DeleteUserByEmail: Firebase認証クライアントが初期化されていません")
+		slog.ErrorContext(ctx, "firebase auth client is not initialized")
 		return fmt.Errorf("firebase認証クライアントが初期化されていません")
 	}
 
@@ -83,34 +95,32 @@ func (fas *FirebaseAuthService) DeleteUserByEmail(ctx context.Context, email str
 	user, err := fas.client.GetUserByEmail(ctx, email)
 	if err != nil {
 		// ユーザーが見つからない場合は、既に削除されたと見なして成功を返す
-		if auth.IsUserNotFound(err) {
-			log.Printf("DeleteUserByEmail: ビジネスユーザーが見つかりません (email: %s). 既に削除済みとみなします。", email)
+		if errors.Is(err, ErrUserNotFound) {
+			slog.InfoContext(ctx, "business user not found, treating as already deleted", slog.String("email", email))
 			return nil
 		}
-		log.Printf("DeleteUserByEmail: ビジネスユーザー情報の取得に失敗: %v", err)
+		slog.ErrorContext(ctx, "failed to get business user by email", slog.String("email", email), slog.Any("error", err))
 		return fmt.Errorf("ユーザー情報の取得に失敗: %v", err)
 	}
 
-	log.Printf("DeleteUserByEmail: ビジネスユーザーID %s を削除します", user.UID)
-
 	// ユーザーを削除
 	if err := fas.client.DeleteUser(ctx, user.UID); err != nil {
-		log.Printf("DeleteUserByEmail: ビジネスユーザー削除に失敗: %v", err)
+		slog.ErrorContext(ctx, "failed to delete business user", slog.String("uid", user.UID), slog.Any("error", err))
 		return fmt.Errorf("ユーザー削除に失敗: %v", err)
 	}
 
-	log.Printf("DeleteUserByEmail: Firebase AuthenticationからビジネスユーザーID %s (email: %s) を削除しました", user.UID, email)
+	slog.InfoContext(ctx, "business user deleted from Firebase Auth", slog.String("uid", user.UID), slog.String("email", email))
 	return nil
 }
 
 // ResendVerificationEmail 認証メール再送信（フロントエンドに委譲）
 func (fas *FirebaseAuthService) ResendVerificationEmail(ctx context.Context, email string) error {
-	log.Printf("ResendVerificationEmail: 認証メール再送信要求 (email: %s)", email)
+	slog.InfoContext(ctx, "verification email resend requested", slog.String("email", email))
 
 	// ユーザー情報を取得
 	user, err := fas.client.GetUserByEmail(ctx, email)
 	if err != nil {
-		if auth.IsUserNotFound(err) {
+		if errors.Is(err, ErrUserNotFound) {
 			return fmt.Errorf("ユーザーが見つかりません: %s", email)
 		}
 		return fmt.Errorf("ユーザー情報の取得に失敗: %v", err)
@@ -133,9 +143,9 @@ func (fas *FirebaseAuthService) ResendVerificationEmail(ctx context.Context, ema
 		return fmt.Errorf("一時パスワードが設定されていません")
 	}
 
-	log.Printf("ResendVerificationEmail: ユーザー確認完了 (UserID: %s, email: %s)", user.UID, email)
-	log.Printf("注意: 実際のメール送信はフロントエンドのauthenticationEmailService.tsで処理されます")
-	
+	// 実際のメール送信はフロントエンドの authenticationEmailService.ts で処理される
+	slog.InfoContext(ctx, "verification email resend delegated to frontend", slog.String("uid", user.UID), slog.String("email", email))
+
 	return nil
 }
 
@@ -166,6 +176,6 @@ func (fas *FirebaseAuthService) CheckEmailVerificationStatus(ctx context.Context
 		return fmt.Errorf("Cloud Functionsからエラーレスポンス: %d", resp.StatusCode)
 	}
 
-	log.Printf("メール認証状態のチェックが完了しました (UserID: %s)", userID)
+	slog.DebugContext(ctx, "email verification status checked", slog.String("uid", userID))
 	return nil
 }
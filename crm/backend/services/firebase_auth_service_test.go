@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/testenv"
+)
+
+func TestDeleteUserByEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  bool
+		failGet error
+		wantErr bool
+	}{
+		{name: "existing user", exists: true},
+		{name: "missing user is treated as deleted"},
+		{name: "lookup failure", exists: true, failGet: errors.New("unavailable"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeAuthProvider()
+			if tt.exists {
+				fake.AddUser("u1", "taro@example.com", nil)
+			}
+			fake.Errors["GetUserByEmail"] = tt.failGet
+			fas := NewFirebaseAuthServiceWithProvider(fake, nil)
+
+			err := fas.DeleteUserByEmail(context.Background(), "Taro@example.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteUserByEmail error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if wantUsers := map[bool]int{true: 1, false: 0}[tt.wantErr && tt.exists]; fake.Len() != wantUsers {
+				t.Errorf("users = %d, want %d", fake.Len(), wantUsers)
+			}
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name         string
+		authUser     bool
+		businessUser map[string]interface{}
+		wantErr      bool
+	}{
+		{name: "missing user", wantErr: true},
+		{name: "missing business user", authUser: true, wantErr: true},
+		{name: "no temporary password", authUser: true, businessUser: map[string]interface{}{"role": "user"}, wantErr: true},
+		{name: "temporary password is set", authUser: true, businessUser: map[string]interface{}{"role": "user", "temporary_password": "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.New(t)
+			fas := NewFirebaseAuthService(env.Auth, env.Firestore)
+			if tt.authUser {
+				user := env.CreateUser(t, "taro@example.com", "password123", nil)
+				if tt.businessUser != nil {
+					env.Seed(t, testenv.Fixtures{"business_users": {user.UID: tt.businessUser}})
+				}
+			}
+
+			err := fas.ResendVerificationEmail(context.Background(), "taro@example.com")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResendVerificationEmail error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateEmailVerificationLink(t *testing.T) {
+	tests := []struct {
+		name       string
+		exists     bool
+		failClaims error
+		wantErr    bool
+	}{
+		{name: "existing user", exists: true},
+		{name: "claims failure does not block the link", exists: true, failClaims: errors.New("unavailable")},
+		{name: "missing user", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FRONTEND_URL", "https://crm.example.com")
+			fake := NewFakeAuthProvider()
+			if tt.exists {
+				fake.AddUser("u1", "taro@example.com", map[string]interface{}{"role": "admin", "tenant_id": "acme"})
+			}
+			fake.Errors["SetCustomUserClaims"] = tt.failClaims
+			fas := NewFirebaseAuthServiceWithProvider(fake, nil)
+
+			link, err := fas.GenerateEmailVerificationLink(context.Background(), "taro@example.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateEmailVerificationLink error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if link == "" || fake.Links["taro@example.com"] != link {
+				t.Errorf("link = %q, recorded %q", link, fake.Links["taro@example.com"])
+			}
+			if !strings.Contains(link, url.QueryEscape("https://crm.example.com/auth/verify")) {
+				t.Errorf("link %q does not continue to the frontend", link)
+			}
+			// 認証メールのクレームは既存のクレーム（role / tenant_id）に追加する
+			claims := fake.User("u1").CustomClaims
+			if wantClaims := tt.failClaims == nil; (claims["email_verification"] == true) != wantClaims {
+				t.Errorf("claims = %v", claims)
+			}
+			if claims["role"] != "admin" || claims["tenant_id"] != "acme" {
+				t.Errorf("existing claims were not kept: %v", claims)
+			}
+		})
+	}
+}
+
+func TestCreateBusinessUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		role     string
+		wantRole string
+		wantCode apperr.Code
+	}{
+		{name: "default role", wantRole: DefaultBusinessUserRole},
+		{name: "explicit role", role: "ADMIN", wantRole: "ADMIN"},
+		{name: "duplicate email", existing: true, wantCode: apperr.CodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.New(t)
+			fas := NewFirebaseAuthService(env.Auth, env.Firestore)
+			ctx := context.Background()
+			if tt.existing {
+				env.CreateUser(t, "taro@example.com", "password123", nil)
+			}
+
+			created, err := fas.CreateBusinessUser(ctx, BusinessUserInput{
+				FirstName:    "太郎",
+				LastName:     "山田",
+				EmailAddress: "taro@example.com",
+				Role:         tt.role,
+			})
+			if tt.wantCode != "" {
+				if !apperr.Is(err, tt.wantCode) {
+					t.Fatalf("CreateBusinessUser error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateBusinessUser: %v", err)
+			}
+
+			user, err := env.Auth.GetUser(ctx, created.BusinessUserID)
+			if err != nil {
+				t.Fatalf("auth user was not created: %v", err)
+			}
+			if user.CustomClaims["role"] != tt.wantRole {
+				t.Errorf("role claim = %v, want %s", user.CustomClaims["role"], tt.wantRole)
+			}
+			businessUser := env.Doc(t, "business_users", created.BusinessUserID)
+			if businessUser == nil {
+				t.Fatal("business_users document was not created")
+			}
+			if businessUser["temporary_password"] != *created.TemporaryPassword {
+				t.Errorf("temporary_password = %v, want %s", businessUser["temporary_password"], *created.TemporaryPassword)
+			}
+		})
+	}
+}
+
+func TestCreateBusinessUserRollback(t *testing.T) {
+	env := testenv.New(t)
+	fake := NewFakeAuthProvider()
+	fake.Errors["SetCustomUserClaims"] = errors.New("unavailable")
+	fas := NewFirebaseAuthServiceWithProvider(fake, env.Firestore)
+
+	_, err := fas.CreateBusinessUser(context.Background(), BusinessUserInput{
+		FirstName:    "太郎",
+		LastName:     "山田",
+		EmailAddress: "taro@example.com",
+	})
+	if !apperr.Is(err, apperr.CodeInternal) {
+		t.Fatalf("CreateBusinessUser error = %v, want %s", err, apperr.CodeInternal)
+	}
+	if fake.Len() != 0 {
+		t.Errorf("auth user was not rolled back (%d users)", fake.Len())
+	}
+	if docs := env.Docs(t, "business_users"); len(docs) != 0 {
+		t.Errorf("business_users = %v, want none", docs)
+	}
+}
+
+func TestDisableBusinessUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		authUser bool
+		seed     bool
+		wantCode apperr.Code
+	}{
+		{name: "existing user", authUser: true, seed: true},
+		{name: "auth account already deleted", seed: true},
+		{name: "missing business user", authUser: true, wantCode: apperr.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.New(t)
+			fake := NewFakeAuthProvider()
+			if tt.authUser {
+				fake.AddUser("u1", "taro@example.com", map[string]interface{}{"role": "admin"})
+			}
+			if tt.seed {
+				env.Seed(t, testenv.Fixtures{"business_users": {"u1": {"role": "admin", "email_address": "taro@example.com"}}})
+			}
+			fas := NewFirebaseAuthServiceWithProvider(fake, env.Firestore)
+
+			user, err := fas.DisableBusinessUser(context.Background(), "u1")
+			if tt.wantCode != "" {
+				if !apperr.Is(err, tt.wantCode) {
+					t.Fatalf("DisableBusinessUser error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DisableBusinessUser: %v", err)
+			}
+			if !user.Disabled || user.DisabledAt == nil {
+				t.Errorf("user = %+v, want disabled", user)
+			}
+			if doc := env.Doc(t, "business_users", "u1"); doc["disabled"] != true {
+				t.Errorf("business_users document = %v", doc)
+			}
+			if tt.authUser {
+				if record := fake.User("u1"); !record.Disabled || record.TokensValidAfterMillis == 0 {
+					t.Errorf("auth user disabled = %v, tokens valid after = %d", record.Disabled, record.TokensValidAfterMillis)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+)
+
+// Trigger Email 拡張の delivery.state
+const (
+	MailStateError = "ERROR"
+	// MailStateRetry この状態にすると拡張が送信をやり直す
+	MailStateRetry = "RETRY"
+)
+
+// MailService 未処理の通知と送信に失敗したメール（mails コレクション）の管理
+type MailService struct {
+	client *firestore.Client
+}
+
+// NewMailService メール管理サービスのコンストラクタ
+func NewMailService(client *firestore.Client) *MailService {
+	return &MailService{client: client}
+}
+
+// PendingNotification 未処理の通知
+type PendingNotification struct {
+	ID string
+	NotificationData
+}
+
+// Mail mails コレクションのメール
+type Mail struct {
+	ID        string
+	To        string
+	Subject   string
+	State     string
+	Error     string
+	Attempts  int
+	StartTime *time.Time
+}
+
+// PendingNotifications 未処理（processed == false）の通知を作成日時の古い順に limit 件まで取得
+func (ms *MailService) PendingNotifications(ctx context.Context, limit int) ([]PendingNotification, error) {
+	docs, err := ms.client.Collection("notifications").
+		Where("processed", "==", false).
+		OrderBy("created_at", firestore.Asc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("通知データ取得エラー: %w", err)
+	}
+
+	notifications := make([]PendingNotification, 0, len(docs))
+	for _, doc := range docs {
+		var n NotificationData
+		if err := doc.DataTo(&n); err != nil {
+			slog.WarnContext(ctx, "failed to decode notification", slog.String("notification_id", doc.Ref.ID), slog.Any("error", err))
+			continue
+		}
+		notifications = append(notifications, PendingNotification{ID: doc.Ref.ID, NotificationData: n})
+	}
+	return notifications, nil
+}
+
+// FailedMails 送信に失敗した（delivery.state == ERROR）メールを limit 件まで取得
+func (ms *MailService) FailedMails(ctx context.Context, limit int) ([]Mail, error) {
+	docs, err := ms.client.Collection("mails").
+		Where("delivery.state", "==", MailStateError).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("メールデータ取得エラー: %w", err)
+	}
+
+	mails := make([]Mail, len(docs))
+	for i, doc := range docs {
+		mails[i] = mailFromData(doc.Ref.ID, doc.Data())
+	}
+	return mails, nil
+}
+
+// RetryMail 送信に失敗したメールを再送する（delivery.state を RETRY にする）
+//
+// 送信に失敗していないメールは二重送信を避けるため CONFLICT エラーを返す。
+func (ms *MailService) RetryMail(ctx context.Context, id string) error {
+	ref := ms.client.Collection("mails").Doc(id)
+	return ms.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			if grpcstatus.Code(err) == codes.NotFound {
+				return apperr.NotFound("mail not found: %s", id)
+			}
+			return apperr.Internal(err, "failed to get mail")
+		}
+		if mail := mailFromData(id, doc.Data()); mail.State != MailStateError {
+			return apperr.Conflict("mail %s is not failed (state: %s)", id, mail.State)
+		}
+		return tx.Update(ref, []firestore.Update{{Path: "delivery.state", Value: MailStateRetry}})
+	})
+}
+
+// mailFromData mails ドキュメントを Mail に変換
+func mailFromData(id string, data map[string]interface{}) Mail {
+	mail := Mail{ID: id}
+	switch to := data["to"].(type) {
+	case string:
+		mail.To = to
+	case []interface{}:
+		if len(to) > 0 {
+			mail.To, _ = to[0].(string)
+		}
+	}
+	if message, ok := data["message"].(map[string]interface{}); ok {
+		mail.Subject, _ = message["subject"].(string)
+	}
+	if delivery, ok := data["delivery"].(map[string]interface{}); ok {
+		mail.State, _ = delivery["state"].(string)
+		mail.Error, _ = delivery["error"].(string)
+		if attempts, ok := delivery["attempts"].(int64); ok {
+			mail.Attempts = int(attempts)
+		}
+		if start, ok := delivery["startTime"].(time.Time); ok {
+			mail.StartTime = &start
+		}
+	}
+	return mail
+}
@@ -3,17 +3,27 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"cloud.google.com/go/firestore"
+	firestorepb "cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/api/iterator"
+
+	"narratives-crm-backend/metrics"
 )
 
+// watchInterval 監視サイクルの間隔
+const watchInterval = 30 * time.Second
+
 // NotificationWatcher Firestore通知・メール監視サービス（監視のみ、送信は行わない）
 type NotificationWatcher struct {
 	client          *firestore.Client
 	firebaseAuthSvc *FirebaseAuthService
+
+	startedAt   atomic.Int64 // 監視開始時刻（UnixNano）
+	lastSuccess atomic.Int64 // 最後に全ての確認が成功した時刻（UnixNano）
 }
 
 // NotificationData Firestore通知データ
@@ -31,13 +41,19 @@ type NotificationData struct {
 
 // BusinessUserData Firestore ビジネスユーザーデータ
 type BusinessUserData struct {
-	BusinessUserID    string    `firestore:"business_user_id"`
-	FirstName         string    `firestore:"first_name"`
-	LastName          string    `firestore:"last_name"`
-	EmailAddress      string    `firestore:"email_address"`
-	Role              string    `firestore:"role"`
-	TemporaryPassword *string   `firestore:"temporary_password"`
-	CreatedAt         time.Time `firestore:"created_at"`
+	BusinessUserID    string     `firestore:"business_user_id"`
+	FirstName         string     `firestore:"first_name"`
+	LastName          string     `firestore:"last_name"`
+	FirstNameKatakana string     `firestore:"first_name_katakana"`
+	LastNameKatakana  string     `firestore:"last_name_katakana"`
+	EmailAddress      string     `firestore:"email_address"`
+	Role              string     `firestore:"role"`
+	TenantID          string     `firestore:"tenant_id"`
+	TemporaryPassword *string    `firestore:"temporary_password"`
+	Disabled          bool       `firestore:"disabled"`
+	DisabledAt        *time.Time `firestore:"disabled_at"`
+	CreatedAt         time.Time  `firestore:"created_at"`
+	UpdatedAt         time.Time  `firestore:"updated_at"`
 }
 
 // NewNotificationWatcher 通知・メール監視サービスのコンストラクタ
@@ -50,19 +66,20 @@ func NewNotificationWatcher(client *firestore.Client, firebaseAuthSvc *FirebaseA
 
 // StartWatching 通知・メール監視を開始（確認のみ、処理は行わない）
 func (nw *NotificationWatcher) StartWatching(ctx context.Context) {
-	log.Println("通知・メール監視を開始しました（確認のみ）...")
+	slog.InfoContext(ctx, "notification watcher started (watch only)")
+	nw.startedAt.Store(time.Now().UnixNano())
 
-	ticker := time.NewTicker(30 * time.Second) // 30秒間隔で確認
+	ticker := time.NewTicker(watchInterval) // 30秒間隔で確認
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("通知・メール監視を停止しました")
+			slog.InfoContext(ctx, "notification watcher stopped")
 			return
 		case <-ticker.C:
 			if err := nw.checkUnprocessedItems(ctx); err != nil {
-				log.Printf("監視エラー: %v", err)
+				slog.ErrorContext(ctx, "notification watcher cycle failed", slog.Any("error", err))
 			}
 		}
 	}
@@ -70,30 +87,90 @@ func (nw *NotificationWatcher) StartWatching(ctx context.Context) {
 
 // checkUnprocessedItems 未処理の通知とメールを確認（監視のみ）
 func (nw *NotificationWatcher) checkUnprocessedItems(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		metrics.WatcherCycleDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	succeeded := true
+
 	// 未処理通知を確認
 	if err := nw.checkUnprocessedNotifications(ctx); err != nil {
-		log.Printf("未処理通知確認エラー: %v", err)
+		slog.ErrorContext(ctx, "failed to check unprocessed notifications", slog.Any("error", err))
+		succeeded = false
 	}
 
 	// mailsコレクションの状況を確認
 	if err := nw.checkMailsStatus(ctx); err != nil {
-		log.Printf("メール状況確認エラー: %v", err)
+		slog.ErrorContext(ctx, "failed to check mail status", slog.Any("error", err))
+		succeeded = false
 	}
 
 	// ユーザーのメール認証状態をチェック
 	if err := nw.checkEmailVerificationForUsers(ctx); err != nil {
-		log.Printf("メール認証状態確認エラー: %v", err)
+		slog.ErrorContext(ctx, "failed to check email verification", slog.Any("error", err))
+		succeeded = false
 	}
 
+	if succeeded {
+		nw.lastSuccess.Store(time.Now().UnixNano())
+	}
 	return nil
 }
 
+// LastSuccess 最後に監視サイクルが成功した時刻（未成功の場合はゼロ値）
+func (nw *NotificationWatcher) LastSuccess() time.Time {
+	if ns := nw.lastSuccess.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+// HealthCheck readiness 用の確認
+//
+// 直近 3 サイクル分の間に成功した監視サイクルがなければ異常とする。
+// 起動直後で最初のサイクルがまだ実行されていない間は正常とみなす。
+func (nw *NotificationWatcher) HealthCheck(ctx context.Context) (map[string]interface{}, error) {
+	staleAfter := 3 * watchInterval
+	details := map[string]interface{}{
+		"interval_seconds": watchInterval.Seconds(),
+	}
+
+	startedAt := nw.startedAt.Load()
+	if startedAt == 0 {
+		return details, fmt.Errorf("notification watcher is not running")
+	}
+
+	last := nw.LastSuccess()
+	if last.IsZero() {
+		if time.Since(time.Unix(0, startedAt)) < staleAfter {
+			details["last_success"] = nil
+			return details, nil
+		}
+		return details, fmt.Errorf("notification watcher has not completed a cycle since %s", time.Unix(0, startedAt).Format(time.RFC3339))
+	}
+
+	details["last_success"] = last.Format(time.RFC3339)
+	if age := time.Since(last); age > staleAfter {
+		return details, fmt.Errorf("last successful cycle was %s ago", age.Round(time.Second))
+	}
+	return details, nil
+}
+
 // checkUnprocessedNotifications 未処理の通知を確認（処理は行わない）
 func (nw *NotificationWatcher) checkUnprocessedNotifications(ctx context.Context) error {
+	unprocessed := nw.client.Collection("notifications").
+		Where("processed", "==", false)
+
+	// 未処理通知の総件数をメトリクスに反映
+	if backlog, err := countQuery(ctx, unprocessed); err != nil {
+		slog.WarnContext(ctx, "failed to count unprocessed notifications", slog.Any("error", err))
+	} else {
+		metrics.UnprocessedNotifications.Set(float64(backlog))
+	}
+
 	// 未処理通知を取得
-	query := nw.client.Collection("notifications").
-		Where("processed", "==", false).
-		Limit(10)
+	query := unprocessed.Limit(10)
 
 	iter := query.Documents(ctx)
 	defer iter.Stop()
@@ -113,7 +190,7 @@ func (nw *NotificationWatcher) checkUnprocessedNotifications(ctx context.Context
 
 		var notification NotificationData
 		if err := doc.DataTo(&notification); err != nil {
-			log.Printf("通知データ変換エラー: %v", err)
+			slog.WarnContext(ctx, "failed to decode notification", slog.String("notification_id", doc.Ref.ID), slog.Any("error", err))
 			continue
 		}
 
@@ -122,18 +199,28 @@ func (nw *NotificationWatcher) checkUnprocessedNotifications(ctx context.Context
 		switch notification.NotificationType {
 		case "welcome_email":
 			welcomeEmailCount++
-			log.Printf("未処理welcome_email通知: ID=%s, UserID=%s", doc.Ref.ID, notification.BusinessUserID)
+			slog.InfoContext(ctx, "unprocessed notification",
+				slog.String("notification_type", notification.NotificationType),
+				slog.String("notification_id", doc.Ref.ID),
+				slog.String("business_user_id", notification.BusinessUserID))
 		case "temporary_password":
 			temporaryPasswordCount++
-			log.Printf("未処理temporary_password通知: ID=%s, UserID=%s", doc.Ref.ID, notification.BusinessUserID)
+			slog.InfoContext(ctx, "unprocessed notification",
+				slog.String("notification_type", notification.NotificationType),
+				slog.String("notification_id", doc.Ref.ID),
+				slog.String("business_user_id", notification.BusinessUserID))
 		default:
-			log.Printf("未対応通知タイプ: %s (ID=%s)", notification.NotificationType, doc.Ref.ID)
+			slog.WarnContext(ctx, "unsupported notification type",
+				slog.String("notification_type", notification.NotificationType),
+				slog.String("notification_id", doc.Ref.ID))
 		}
 	}
 
 	if unprocessedCount > 0 {
-		log.Printf("未処理通知確認完了: 総件数=%d, welcome_email=%d, temporary_password=%d",
-			unprocessedCount, welcomeEmailCount, temporaryPasswordCount)
+		slog.InfoContext(ctx, "unprocessed notifications checked",
+			slog.Int("total", unprocessedCount),
+			slog.Int("welcome_email", welcomeEmailCount),
+			slog.Int("temporary_password", temporaryPasswordCount))
 	}
 
 	return nil
@@ -178,15 +265,24 @@ func (nw *NotificationWatcher) checkMailsStatus(ctx context.Context) error {
 					pendingMails++
 				case "ERROR":
 					errorMails++
-					log.Printf("メール送信エラー: DocumentID=%s, Error=%v", doc.Ref.ID, delivery["error"])
+					slog.WarnContext(ctx, "mail delivery failed",
+						slog.String("mail_id", doc.Ref.ID),
+						slog.Any("delivery_error", delivery["error"]))
 				}
 			}
 		}
 	}
 
+	metrics.MailDeliveries.WithLabelValues("SUCCESS").Set(float64(sentMails))
+	metrics.MailDeliveries.WithLabelValues("PENDING").Set(float64(pendingMails))
+	metrics.MailDeliveries.WithLabelValues("ERROR").Set(float64(errorMails))
+
 	if totalMails > 0 {
-		log.Printf("メール状況確認（24時間）: 総件数=%d, 送信完了=%d, 処理中=%d, エラー=%d",
-			totalMails, sentMails, pendingMails, errorMails)
+		slog.InfoContext(ctx, "mail status checked (last 24h)",
+			slog.Int("total", totalMails),
+			slog.Int("success", sentMails),
+			slog.Int("pending", pendingMails),
+			slog.Int("error", errorMails))
 	}
 
 	return nil
@@ -215,9 +311,23 @@ func (nw *NotificationWatcher) checkEmailVerificationForUsers(ctx context.Contex
 
 		// メール認証状態をチェック（Firebase Functionsを通じて）
 		if err := nw.firebaseAuthSvc.CheckEmailVerificationStatus(ctx, userID); err != nil {
-			log.Printf("ユーザー %s のメール認証チェックエラー: %v", userID, err)
+			slog.WarnContext(ctx, "failed to check email verification for user", slog.String("uid", userID), slog.Any("error", err))
 		}
 	}
 
 	return nil
 }
+
+
+// countQuery クエリに一致するドキュメント数を集計クエリで取得
+func countQuery(ctx context.Context, q firestore.Query) (int64, error) {
+	res, err := q.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := res["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result type %T", res["count"])
+	}
+	return v.GetIntegerValue(), nil
+}
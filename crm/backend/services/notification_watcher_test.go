@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"narratives-crm-backend/metrics"
+	"narratives-crm-backend/testenv"
+)
+
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("failed to read gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func notification(typ string, processed bool) map[string]interface{} {
+	return map[string]interface{}{
+		"business_user_id":  "b1",
+		"notification_type": typ,
+		"processed":         processed,
+		"created_at":        time.Now(),
+	}
+}
+
+func TestCheckUnprocessedNotifications(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications map[string]map[string]interface{}
+		want          float64
+	}{
+		{name: "no notifications", want: 0},
+		{
+			name: "processed notifications are not counted",
+			notifications: map[string]map[string]interface{}{
+				"n1": notification("welcome_email", true),
+				"n2": notification("welcome_email", false),
+				"n3": notification("temporary_password", false),
+				"n4": notification("unknown", false),
+			},
+			want: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.New(t)
+			env.Seed(t, testenv.Fixtures{"notifications": tt.notifications})
+			nw := NewNotificationWatcher(env.Firestore, NewFirebaseAuthService(env.Auth, env.Firestore))
+
+			if err := nw.checkUnprocessedNotifications(context.Background()); err != nil {
+				t.Fatalf("checkUnprocessedNotifications: %v", err)
+			}
+			if got := gaugeValue(t, metrics.UnprocessedNotifications); got != tt.want {
+				t.Errorf("unprocessed notifications = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mail(state string, startTime time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"to":       []interface{}{"taro@example.com"},
+		"message":  map[string]interface{}{"subject": "welcome"},
+		"delivery": map[string]interface{}{"state": state, "startTime": startTime},
+	}
+}
+
+func TestCheckMailsStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		mails map[string]map[string]interface{}
+		want  map[string]float64
+	}{
+		{name: "no mails", want: map[string]float64{"SUCCESS": 0, "PENDING": 0, "ERROR": 0}},
+		{
+			name: "counts by delivery state",
+			mails: map[string]map[string]interface{}{
+				"m1": mail("SUCCESS", now.Add(-time.Hour)),
+				"m2": mail("SUCCESS", now.Add(-2*time.Hour)),
+				"m3": mail("PENDING", now),
+				"m4": mail("PROCESSING", now),
+				"m5": mail("ERROR", now.Add(-time.Minute)),
+			},
+			want: map[string]float64{"SUCCESS": 2, "PENDING": 2, "ERROR": 1},
+		},
+		{
+			name: "mails older than 24 hours are ignored",
+			mails: map[string]map[string]interface{}{
+				"m1": mail("ERROR", now.Add(-48*time.Hour)),
+				"m2": mail("SUCCESS", now),
+			},
+			want: map[string]float64{"SUCCESS": 1, "PENDING": 0, "ERROR": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testenv.New(t)
+			env.Seed(t, testenv.Fixtures{"mails": tt.mails})
+			nw := NewNotificationWatcher(env.Firestore, NewFirebaseAuthService(env.Auth, env.Firestore))
+
+			if err := nw.checkMailsStatus(context.Background()); err != nil {
+				t.Fatalf("checkMailsStatus: %v", err)
+			}
+			for state, want := range tt.want {
+				if got := gaugeValue(t, metrics.MailDeliveries.WithLabelValues(state)); got != want {
+					t.Errorf("%s mails = %v, want %v", state, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		startedAt   time.Time
+		lastSuccess time.Time
+		wantErr     bool
+	}{
+		{name: "not started", wantErr: true},
+		{name: "first cycle pending", startedAt: now.Add(-time.Second)},
+		{name: "no cycle completed since start", startedAt: now.Add(-4 * watchInterval), wantErr: true},
+		{name: "recent success", startedAt: now.Add(-time.Hour), lastSuccess: now.Add(-watchInterval)},
+		{name: "stale success", startedAt: now.Add(-time.Hour), lastSuccess: now.Add(-4 * watchInterval), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nw := &NotificationWatcher{}
+			if !tt.startedAt.IsZero() {
+				nw.startedAt.Store(tt.startedAt.UnixNano())
+			}
+			if !tt.lastSuccess.IsZero() {
+				nw.lastSuccess.Store(tt.lastSuccess.UnixNano())
+			}
+
+			details, err := nw.HealthCheck(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HealthCheck error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if details["interval_seconds"] != watchInterval.Seconds() {
+				t.Errorf("interval_seconds = %v, want %v", details["interval_seconds"], watchInterval.Seconds())
+			}
+		})
+	}
+}
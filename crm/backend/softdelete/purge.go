@@ -0,0 +1,176 @@
+package softdelete
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// デフォルトの設定
+const (
+	DefaultRetention     = 30 * 24 * time.Hour
+	DefaultPurgeInterval = 24 * time.Hour
+)
+
+// purgeBatchSize 1回のクエリで完全削除するドキュメント数
+const purgeBatchSize = 200
+
+// Config 完全削除ジョブの設定
+type Config struct {
+	// Retention 論理削除から完全削除までの期間
+	Retention time.Duration
+	// Interval 完全削除ジョブの間隔（0 の場合は実行しない）
+	Interval time.Duration
+}
+
+// ConfigFromEnv 環境変数から設定を読み込む
+//
+//	SOFT_DELETE_RETENTION  完全削除までの期間（例: 2160h、デフォルト: 720h = 30日）
+//	PURGE_INTERVAL         完全削除ジョブの間隔（例: 6h、デフォルト: 24h、"off" で無効）
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Retention: DefaultRetention, Interval: DefaultPurgeInterval}
+
+	if v := os.Getenv("SOFT_DELETE_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SOFT_DELETE_RETENTION: %q", v)
+		}
+		cfg.Retention = d
+	}
+	if v := os.Getenv("PURGE_INTERVAL"); v != "" {
+		if strings.EqualFold(v, "off") {
+			cfg.Interval = 0
+			return cfg, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid PURGE_INTERVAL: %q", v)
+		}
+		cfg.Interval = d
+	}
+	return cfg, nil
+}
+
+// Purger 保存期間を過ぎた論理削除済みのドキュメントを完全に削除する
+type Purger struct {
+	Client *firestore.Client
+	Config Config
+}
+
+// Schedule Config.Interval ごとに Purge を実行する（ctx が終了するまでブロック）
+//
+// 完全削除は何度実行しても結果が変わらないため、複数のインスタンスで動かしてもよい。
+func (p *Purger) Schedule(ctx context.Context) {
+	if p.Config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		counts, err := p.Purge(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to purge deleted documents", slog.Any("error", err))
+		}
+		for name, n := range counts {
+			if n > 0 {
+				slog.InfoContext(ctx, "purged deleted documents", slog.String("kind", name), slog.Int("count", n))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge Kinds のうち保存期間を過ぎたものを子ドキュメントと一緒に削除し、種類ごとの件数を返す
+//
+// ユーザーはウォレット、注文は明細を、子ドキュメント自身の削除状態に関係なくすべて削除する。
+func (p *Purger) Purge(ctx context.Context) (map[string]int, error) {
+	cutoff := time.Now().Add(-p.Config.Retention)
+	counts := make(map[string]int, len(Kinds))
+	for _, kind := range Kinds {
+		n, err := p.purgeKind(ctx, kind, cutoff)
+		counts[kind.Name] = n
+		if err != nil {
+			return counts, fmt.Errorf("failed to purge %s: %w", kind.Collection, err)
+		}
+	}
+	return counts, nil
+}
+
+func (p *Purger) purgeKind(ctx context.Context, kind *Kind, cutoff time.Time) (int, error) {
+	query := p.Client.Collection(kind.Collection).Where(FieldDeletedAt, "<", cutoff).Limit(purgeBatchSize)
+	total := 0
+	for {
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+
+		bw := p.Client.BulkWriter(ctx)
+		var jobs []*firestore.BulkWriterJob
+		for _, doc := range docs {
+			// 子ドキュメントを削除できた場合だけ親を削除し、失敗しても次回の実行で続きを削除できるようにする
+			if err := p.deleteChildren(ctx, bw, kind, doc.Ref.ID); err != nil {
+				bw.End()
+				return total, err
+			}
+			job, err := bw.Delete(doc.Ref)
+			if err != nil {
+				bw.End()
+				return total, err
+			}
+			jobs = append(jobs, job)
+		}
+		bw.End()
+
+		for _, job := range jobs {
+			if _, err := job.Results(); err != nil {
+				return total, err
+			}
+		}
+		total += len(docs)
+	}
+}
+
+// deleteChildren 親に属する子ドキュメントをすべて削除し、完了を待つ
+func (p *Purger) deleteChildren(ctx context.Context, bw *firestore.BulkWriter, kind *Kind, id string) error {
+	var jobs []*firestore.BulkWriterJob
+	for _, c := range kind.Children {
+		children, err := p.Client.Collection(c.Collection).Where(c.ForeignKey, "==", id).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			job, err := bw.Delete(child.Ref)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	bw.Flush()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
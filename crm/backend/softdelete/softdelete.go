@@ -0,0 +1,214 @@
+package softdelete
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/tenant"
+)
+
+// 削除済みのドキュメントに付けるフィールド
+const (
+	FieldDeletedAt = "deleted_at"
+	FieldDeletedBy = "deleted_by"
+	// FieldDeletedWith 親と一緒に削除された子ドキュメントの親のパス（例: users/abc）。復元時に使う
+	FieldDeletedWith = "deleted_with"
+)
+
+// fieldVersion optimistic.FieldVersion（削除・復元の前に読み込んだ内容での更新を競合として扱うために進める）
+const fieldVersion = "version"
+
+// Child 親と一緒に削除・復元・完全削除するドキュメント
+type Child struct {
+	Collection string
+	// ForeignKey 親のドキュメントIDを持つフィールド
+	ForeignKey string
+}
+
+// Kind 論理削除の対象
+type Kind struct {
+	Name       string
+	Collection string
+	Children   []Child
+}
+
+// 論理削除の対象。ユーザーのウォレット、注文の明細は親と一緒に削除する
+var (
+	Users        = &Kind{Name: "user", Collection: "users", Children: []Child{{Collection: "wallets", ForeignKey: "user_id"}}}
+	Wallets      = &Kind{Name: "wallet", Collection: "wallets"}
+	Orders       = &Kind{Name: "order", Collection: "orders", Children: []Child{{Collection: "order_items", ForeignKey: "order_id"}}}
+	Interactions = &Kind{Name: "interaction", Collection: "interactions"}
+)
+
+// Kinds 完全削除ジョブの対象
+var Kinds = []*Kind{Users, Wallets, Orders, Interactions}
+
+// IsDeleted ドキュメントが論理削除済みかどうか
+func IsDeleted(data map[string]interface{}) bool {
+	_, ok := data[FieldDeletedAt].(time.Time)
+	return ok
+}
+
+// Delete ドキュメントを論理削除する（削除済みの場合は何もしない）
+//
+// 削除されていない子ドキュメントも同じ日時で削除し、deleted_with に親のパスを記録する。
+func Delete(ctx context.Context, client *firestore.Client, kind *Kind, id, deletedBy string) error {
+	ref := client.Collection(kind.Collection).Doc(id)
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := getForUpdate(ctx, tx, kind, ref)
+		if err != nil {
+			return err
+		}
+		if IsDeleted(doc.Data()) {
+			return nil
+		}
+
+		children, err := childDocs(tx, client, kind, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		marks := []firestore.Update{
+			{Path: FieldDeletedAt, Value: now},
+			{Path: FieldDeletedBy, Value: deletedBy},
+			{Path: "updated_at", Value: now},
+			{Path: fieldVersion, Value: firestore.Increment(1)},
+		}
+		if err := tx.Update(ref, marks); err != nil {
+			return err
+		}
+		parent := kind.Collection + "/" + id
+		for _, child := range children {
+			if IsDeleted(child.Data()) {
+				continue
+			}
+			if err := tx.Update(child.Ref, append(marks, firestore.Update{Path: FieldDeletedWith, Value: parent})); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Restore 論理削除したドキュメントを復元する（削除されていない場合は何もしない）
+//
+// 親と一緒に削除された子ドキュメントも復元する。個別に削除されていたものは削除済みのまま残す。
+func Restore(ctx context.Context, client *firestore.Client, kind *Kind, id string) error {
+	ref := client.Collection(kind.Collection).Doc(id)
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := getForUpdate(ctx, tx, kind, ref)
+		if err != nil {
+			return err
+		}
+		if !IsDeleted(doc.Data()) {
+			return nil
+		}
+
+		children, err := childDocs(tx, client, kind, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		unmarks := []firestore.Update{
+			{Path: FieldDeletedAt, Value: firestore.Delete},
+			{Path: FieldDeletedBy, Value: firestore.Delete},
+			{Path: "updated_at", Value: now},
+			{Path: fieldVersion, Value: firestore.Increment(1)},
+		}
+		if err := tx.Update(ref, unmarks); err != nil {
+			return err
+		}
+		parent := kind.Collection + "/" + id
+		for _, child := range children {
+			if with, _ := child.Data()[FieldDeletedWith].(string); with != parent {
+				continue
+			}
+			if err := tx.Update(child.Ref, append(unmarks, firestore.Update{Path: FieldDeletedWith, Value: firestore.Delete})); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getForUpdate 削除・復元の対象を取得する（スコープ外の組織のドキュメントは NOT_FOUND）
+func getForUpdate(ctx context.Context, tx *firestore.Transaction, kind *Kind, ref *firestore.DocumentRef) (*firestore.DocumentSnapshot, error) {
+	doc, err := tx.Get(ref)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil, apperr.NotFound("%s not found: %s", kind.Name, ref.ID)
+		}
+		return nil, apperr.Internal(err, "failed to get %s", kind.Name)
+	}
+	if !tenant.Allows(ctx, doc.Data()) {
+		return nil, apperr.NotFound("%s not found: %s", kind.Name, ref.ID)
+	}
+	return doc, nil
+}
+
+// childDocs 親に属する子ドキュメント（削除済みも含む）
+func childDocs(tx *firestore.Transaction, client *firestore.Client, kind *Kind, id string) ([]*firestore.DocumentSnapshot, error) {
+	var docs []*firestore.DocumentSnapshot
+	for _, c := range kind.Children {
+		found, err := tx.Documents(client.Collection(c.Collection).Where(c.ForeignKey, "==", id)).GetAll()
+		if err != nil {
+			return nil, apperr.Internal(err, "failed to get %s of %s", c.Collection, kind.Name)
+		}
+		docs = append(docs, found...)
+	}
+	return docs, nil
+}
+
+// ListActive 削除済みを除いたドキュメントを limit 件まで取得する（limit が 0 以下の場合はすべて）
+//
+// Firestore では「フィールドがない」ことを条件にできないため、削除済みは読み取ったあとで除き、
+// 足りない分は続きから読み足す。
+func ListActive(ctx context.Context, query firestore.Query, limit int) ([]*firestore.DocumentSnapshot, error) {
+	if limit <= 0 {
+		docs, err := query.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		return Active(docs), nil
+	}
+
+	var docs []*firestore.DocumentSnapshot
+	page := query.Limit(limit)
+	for {
+		found, err := page.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range found {
+			if IsDeleted(doc.Data()) {
+				continue
+			}
+			docs = append(docs, doc)
+			if len(docs) == limit {
+				return docs, nil
+			}
+		}
+		if len(found) < limit {
+			return docs, nil
+		}
+		page = query.StartAfter(found[len(found)-1]).Limit(limit)
+	}
+}
+
+// Active 削除済みを除いたドキュメント
+func Active(docs []*firestore.DocumentSnapshot) []*firestore.DocumentSnapshot {
+	active := docs[:0:0]
+	for _, doc := range docs {
+		if !IsDeleted(doc.Data()) {
+			active = append(active, doc)
+		}
+	}
+	return active
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"embed"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"text/template"
+
+	"narratives-crm-backend/tenant"
 )
 
 //go:embed email_templates/*.tmpl
@@ -25,6 +27,8 @@ type TemplateData struct {
 	TemporaryPassword string
 	VerificationLink  string
 	LoginURL          string
+	// Branding 送信元の組織のブランド（空の項目は tenant.DefaultSettings で補う）
+	Branding tenant.Branding
 }
 
 // RenderTemplate テンプレートをレンダリングする
@@ -33,7 +37,8 @@ func RenderTemplate(templateID string, data interface{}) (string, error) {
 	tmpl, err := template.ParseFS(emailTemplates, fmt.Sprintf("email_templates/%s.tmpl", templateID))
 	if err != nil {
 		// 埋め込みテンプレートの読み込みに失敗した場合、ファイルシステムから直接読み込む
-		log.Printf("埋め込みテンプレートの読み込みに失敗しました: %v, ファイルシステムから直接読み込みます", err)
+		slog.Warn("failed to load embedded template, falling back to filesystem",
+			slog.String("template_id", templateID), slog.Any("error", err))
 
 		// 候補となるパスのリスト
 		templatePaths := []string{
@@ -50,10 +55,10 @@ func RenderTemplate(templateID string, data interface{}) (string, error) {
 		// すべてのパスを試す
 		var foundPath string
 		for _, path := range templatePaths {
-			log.Printf("テンプレートパスを試行: %s", path)
+			slog.Debug("trying template path", slog.String("path", path))
 			if _, err := os.Stat(path); err == nil {
 				foundPath = path
-				log.Printf("テンプレートファイルを発見: %s", path)
+				slog.Debug("template file found", slog.String("path", path))
 				break
 			}
 		}
@@ -70,6 +75,11 @@ func RenderTemplate(templateID string, data interface{}) (string, error) {
 		}
 	}
 
+	if d, ok := data.(TemplateData); ok {
+		d.Branding = tenant.Settings{Branding: d.Branding}.WithDefaults().Branding
+		data = d
+	}
+
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("テンプレートの実行に失敗しました: %v", err)
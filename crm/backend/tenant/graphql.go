@@ -0,0 +1,61 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/authn"
+)
+
+// GraphQLExtension オペレーションごとに呼び出し元のスコープをコンテキストに設定する gqlgen 拡張
+//
+// resolver やこのスコープを参照するパッケージ（optimistic、softdelete、audit など）は、
+// スコープ外の組織のドキュメントを存在しないものとして扱う。
+// スーパー管理者は HeaderTenantID ヘッダー（WebSocket は connection_init のペイロード）で組織を選べる。
+// 呼び出し元はコンテキストから取得するため、authn.Middleware / authn.WebsocketInitFunc の後で実行される。
+type GraphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "Tenant"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (GraphQLExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation graphql.OperationInterceptor の実装
+func (GraphQLExtension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	scope, err := ScopeFor(authn.CallerFromContext(ctx), requestedTenant(ctx))
+	if err != nil {
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{{
+			Message:    err.Error(),
+			Extensions: map[string]interface{}{"code": string(apperr.CodeOf(err))},
+		}}})
+	}
+	return next(WithScope(ctx, scope))
+}
+
+// requestedTenant HeaderTenantID で指定された組織
+func requestedTenant(ctx context.Context) string {
+	if graphql.HasOperationContext(ctx) {
+		if v := graphql.GetOperationContext(ctx).Headers.Get(HeaderTenantID); v != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	if payload := transport.GetInitPayload(ctx); payload != nil {
+		return strings.TrimSpace(payload.GetString(HeaderTenantID))
+	}
+	return ""
+}
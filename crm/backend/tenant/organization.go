@@ -0,0 +1,271 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"narratives-crm-backend/apperr"
+)
+
+// Collection 組織のコレクション（ドキュメントIDが tenant_id）
+const Collection = "organizations"
+
+var (
+	// idPattern 組織のID（tenant_id）に使える文字列
+	idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,62}$`)
+	// colorPattern Branding.PrimaryColor の形式（#RGB または #RRGGBB）
+	colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+// Branding メールテンプレートに差し込むブランド設定
+type Branding struct {
+	// ProductName メールの件名・署名に使うサービス名
+	ProductName  string `firestore:"product_name" json:"product_name"`
+	SenderName   string `firestore:"sender_name" json:"sender_name"`
+	SupportEmail string `firestore:"support_email" json:"support_email"`
+	LogoURL      string `firestore:"logo_url" json:"logo_url"`
+	PrimaryColor string `firestore:"primary_color" json:"primary_color"`
+	FooterText   string `firestore:"footer_text" json:"footer_text"`
+}
+
+// Settings 組織ごとの設定
+type Settings struct {
+	// Currency ISO 4217 の通貨コード（ウォレット・注文のデフォルト）
+	Currency string `firestore:"currency" json:"currency"`
+	// Locale BCP 47 の言語タグ（メール・画面の表示言語）
+	Locale   string   `firestore:"locale" json:"locale"`
+	Branding Branding `firestore:"branding" json:"branding"`
+}
+
+// DefaultSettings 設定を省略した項目の値
+var DefaultSettings = Settings{
+	Currency: "JPY",
+	Locale:   "ja-JP",
+	Branding: Branding{
+		ProductName: "Narratives CRM",
+		SenderName:  "Narratives CRM システム",
+	},
+}
+
+// WithDefaults 空の項目を DefaultSettings で補った設定
+func (s Settings) WithDefaults() Settings {
+	if s.Currency == "" {
+		s.Currency = DefaultSettings.Currency
+	}
+	if s.Locale == "" {
+		s.Locale = DefaultSettings.Locale
+	}
+	if s.Branding.ProductName == "" {
+		s.Branding.ProductName = DefaultSettings.Branding.ProductName
+	}
+	if s.Branding.SenderName == "" {
+		s.Branding.SenderName = DefaultSettings.Branding.SenderName
+	}
+	return s
+}
+
+// Validate 通貨コード・言語タグ・ブランドカラーを検証し、正規化した値にそろえる
+func (s *Settings) Validate() error {
+	unit, err := currency.ParseISO(s.Currency)
+	if err != nil {
+		return fmt.Errorf("invalid currency %q", s.Currency)
+	}
+	s.Currency = unit.String()
+
+	tag, err := language.Parse(s.Locale)
+	if err != nil {
+		return fmt.Errorf("invalid locale %q", s.Locale)
+	}
+	s.Locale = tag.String()
+
+	if s.Branding.PrimaryColor != "" && !colorPattern.MatchString(s.Branding.PrimaryColor) {
+		return fmt.Errorf("invalid primary color %q", s.Branding.PrimaryColor)
+	}
+	return nil
+}
+
+// Organization 組織（organizations ドキュメント）。CRM のデータは組織ごとに分かれる
+type Organization struct {
+	ID string `firestore:"-"`
+	// TenantID ID と同じ値。他のコレクションと同じく tenant_id でスコープを確認できるように保存する
+	TenantID  string    `firestore:"tenant_id"`
+	Name      string    `firestore:"name"`
+	Settings  Settings  `firestore:"settings"`
+	CreatedAt time.Time `firestore:"created_at"`
+	UpdatedAt time.Time `firestore:"updated_at"`
+}
+
+// defaultOrganization organizations/default がまだない場合の DefaultID の組織
+func defaultOrganization() *Organization {
+	return &Organization{ID: DefaultID, TenantID: DefaultID, Name: DefaultSettings.Branding.ProductName, Settings: DefaultSettings}
+}
+
+// Get 組織を取得する（スコープに含まれない組織は NOT_FOUND）
+func Get(ctx context.Context, client *firestore.Client, id string) (*Organization, error) {
+	if !Contains(ctx, id) {
+		return nil, apperr.NotFound("organization not found: %s", id)
+	}
+
+	doc, err := client.Collection(Collection).Doc(id).Get(ctx)
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			if id == DefaultID {
+				return defaultOrganization(), nil
+			}
+			return nil, apperr.NotFound("organization not found: %s", id)
+		}
+		return nil, apperr.Internal(err, "failed to get organization")
+	}
+	return organizationFromSnapshot(doc)
+}
+
+// List スコープに含まれる組織をIDの順に取得する
+func List(ctx context.Context, client *firestore.Client) ([]*Organization, error) {
+	if s, ok := ScopeFromContext(ctx); ok && !s.All() {
+		if s.None {
+			return []*Organization{}, nil
+		}
+		org, err := Get(ctx, client, s.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		return []*Organization{org}, nil
+	}
+
+	docs, err := client.Collection(Collection).OrderBy(firestore.DocumentID, firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, apperr.Internal(err, "failed to list organizations")
+	}
+
+	orgs := make([]*Organization, 0, len(docs))
+	for _, doc := range docs {
+		org, err := organizationFromSnapshot(doc)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+// IDs 定期処理で組織ごとに実行するための組織ID（organizations が空の場合は DefaultID だけ）
+func IDs(ctx context.Context, client *firestore.Client) ([]string, error) {
+	refs, err := client.Collection(Collection).DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	ids := []string{DefaultID}
+	for _, ref := range refs {
+		if ref.ID != DefaultID {
+			ids = append(ids, ref.ID)
+		}
+	}
+	return ids, nil
+}
+
+// Create 組織を作成する（ID が使用済みの場合は CONFLICT）
+func Create(ctx context.Context, client *firestore.Client, org *Organization) (*Organization, error) {
+	if !idPattern.MatchString(org.ID) {
+		return nil, apperr.Validation("invalid organization id %q (lowercase letters, digits, - and _)", org.ID)
+	}
+	if org.Name == "" {
+		return nil, apperr.Validation("organization name is required")
+	}
+	org.Settings = org.Settings.WithDefaults()
+	if err := org.Settings.Validate(); err != nil {
+		return nil, apperr.Validation("%v", err)
+	}
+
+	now := time.Now()
+	org.TenantID = org.ID
+	org.CreatedAt = now
+	org.UpdatedAt = now
+	if _, err := client.Collection(Collection).Doc(org.ID).Create(ctx, org); err != nil {
+		if grpcstatus.Code(err) == codes.AlreadyExists {
+			return nil, apperr.Conflict("organization already exists: %s", org.ID)
+		}
+		return nil, apperr.Internal(err, "failed to create organization")
+	}
+	return org, nil
+}
+
+// Update 組織の名前・設定を更新する
+//
+// apply には現在の内容を渡し、変更後の設定を検証してから保存する。
+// DefaultID の組織はドキュメントがなければ作成する。
+func Update(ctx context.Context, client *firestore.Client, id string, apply func(*Organization)) (*Organization, error) {
+	if !Contains(ctx, id) {
+		return nil, apperr.NotFound("organization not found: %s", id)
+	}
+
+	ref := client.Collection(Collection).Doc(id)
+	var updated *Organization
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		var org *Organization
+		switch {
+		case grpcstatus.Code(err) == codes.NotFound && id == DefaultID:
+			org = defaultOrganization()
+			org.CreatedAt = time.Now()
+		case grpcstatus.Code(err) == codes.NotFound:
+			return apperr.NotFound("organization not found: %s", id)
+		case err != nil:
+			return apperr.Internal(err, "failed to get organization")
+		default:
+			if org, err = organizationFromSnapshot(doc); err != nil {
+				return err
+			}
+		}
+
+		apply(org)
+		if org.Name == "" {
+			return apperr.Validation("organization name is required")
+		}
+		org.Settings = org.Settings.WithDefaults()
+		if err := org.Settings.Validate(); err != nil {
+			return apperr.Validation("%v", err)
+		}
+		org.UpdatedAt = time.Now()
+		updated = org
+		return tx.Set(ref, org)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// SettingsFor 組織の設定（組織が見つからない場合は DefaultSettings）
+//
+// メール送信など、設定がなくても処理を続けたい場面で使う。スコープは確認しない。
+func SettingsFor(ctx context.Context, client *firestore.Client, id string) Settings {
+	doc, err := client.Collection(Collection).Doc(id).Get(ctx)
+	if err != nil {
+		return DefaultSettings
+	}
+	org, err := organizationFromSnapshot(doc)
+	if err != nil {
+		return DefaultSettings
+	}
+	return org.Settings
+}
+
+func organizationFromSnapshot(doc *firestore.DocumentSnapshot) (*Organization, error) {
+	var org Organization
+	if err := doc.DataTo(&org); err != nil {
+		return nil, apperr.Internal(err, "failed to decode organization %s", doc.Ref.ID)
+	}
+	org.ID = doc.Ref.ID
+	org.TenantID = doc.Ref.ID
+	org.Settings = org.Settings.WithDefaults()
+	return &org, nil
+}
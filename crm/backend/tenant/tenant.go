@@ -0,0 +1,151 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+
+	"narratives-crm-backend/apperr"
+	"narratives-crm-backend/authn"
+)
+
+const (
+	// FieldTenantID ドキュメントが属する組織のフィールド
+	FieldTenantID = "tenant_id"
+	// ClaimTenantID 組織を設定する Firebase Auth のカスタムクレーム
+	ClaimTenantID = "tenant_id"
+	// RoleSuperAdmin すべての組織にアクセスできるロール（カスタムクレーム role）
+	RoleSuperAdmin = "super_admin"
+	// HeaderTenantID スーパー管理者が対象の組織を指定するヘッダー（WebSocket は connection_init のペイロード）
+	HeaderTenantID = "X-Tenant-ID"
+	// DefaultID tenant_id がないドキュメントの組織（マルチテナント化より前のデータ）
+	DefaultID = "default"
+	// noTenantID どのドキュメントにも一致しない tenant_id（組織のIDの形式に合わない値）
+	noTenantID = "-"
+)
+
+// Scope 処理の対象にする組織
+type Scope struct {
+	// TenantID 対象の組織（空の場合はすべての組織。スーパー管理者だけが使える）
+	TenantID string
+	// None どの組織も対象にしない（未認証・組織が割り当てられていない呼び出し元）
+	None bool
+}
+
+// All すべての組織が対象か
+func (s Scope) All() bool {
+	return !s.None && s.TenantID == ""
+}
+
+type scopeContextKey struct{}
+
+// WithScope コンテキストにスコープを設定
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, s)
+}
+
+// ScopeFromContext コンテキストのスコープ（設定されていない場合は false）
+//
+// スコープがないコンテキスト（定期処理や crmctl など）はすべての組織を対象にする。
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeContextKey{}).(Scope)
+	return s, ok
+}
+
+// IsSuperAdmin 呼び出し元がスーパー管理者か
+func IsSuperAdmin(caller *authn.Caller) bool {
+	return caller != nil && strings.EqualFold(caller.Role, RoleSuperAdmin)
+}
+
+// ScopeFor 呼び出し元のスコープ。requested は HeaderTenantID で指定された組織
+//
+// スーパー管理者は requested の組織（空の場合はすべての組織）、それ以外はカスタムクレームの組織が対象になる。
+// 未認証のリクエストと tenant_id クレームのないユーザーはどの組織のデータにもアクセスできない
+// （マルチテナント化より前のスタッフは crmctl users sync-claims でクレームを設定する）。
+func ScopeFor(caller *authn.Caller, requested string) (Scope, error) {
+	if IsSuperAdmin(caller) {
+		return Scope{TenantID: requested}, nil
+	}
+	if caller == nil || caller.TenantID == "" {
+		if requested != "" {
+			return Scope{}, apperr.Forbidden("access to organization %s is not allowed", requested)
+		}
+		return Scope{None: true}, nil
+	}
+
+	if requested != "" && requested != caller.TenantID {
+		return Scope{}, apperr.Forbidden("access to organization %s is not allowed", requested)
+	}
+	return Scope{TenantID: caller.TenantID}, nil
+}
+
+// ID 新しいドキュメントに設定する組織
+//
+// すべての組織が対象のスコープでは作成先が決まらないため VALIDATION エラー、
+// 組織が割り当てられていない呼び出し元は FORBIDDEN を返す。スコープがない場合は DefaultID とする。
+func ID(ctx context.Context) (string, error) {
+	s, ok := ScopeFromContext(ctx)
+	if !ok {
+		return DefaultID, nil
+	}
+	if s.None {
+		return "", apperr.Forbidden("caller does not belong to any organization")
+	}
+	if s.All() {
+		return "", apperr.Validation("organization is required; set the %s header", HeaderTenantID)
+	}
+	return s.TenantID, nil
+}
+
+// Single ID の組織だけをスコープにしたコンテキストと組織ID
+//
+// 組織ごとに集計・作成する処理（セグメントの評価など）で、スコープのないコンテキストでも
+// すべての組織をまとめて扱わないようにするために使う。
+func Single(ctx context.Context) (context.Context, string, error) {
+	id, err := ID(ctx)
+	if err != nil {
+		return ctx, "", err
+	}
+	return WithScope(ctx, Scope{TenantID: id}), id, nil
+}
+
+// Of ドキュメントの組織（tenant_id がない場合は DefaultID）
+func Of(data map[string]interface{}) string {
+	if id, ok := data[FieldTenantID].(string); ok && id != "" {
+		return id
+	}
+	return DefaultID
+}
+
+// Contains スコープに組織が含まれるか
+func Contains(ctx context.Context, tenantID string) bool {
+	s, ok := ScopeFromContext(ctx)
+	if !ok || s.All() {
+		return true
+	}
+	if s.None {
+		return false
+	}
+	if tenantID == "" {
+		tenantID = DefaultID
+	}
+	return s.TenantID == tenantID
+}
+
+// Allows ドキュメントがスコープに含まれるか（含まれないドキュメントは存在しないものとして扱う）
+func Allows(ctx context.Context, data map[string]interface{}) bool {
+	return Contains(ctx, Of(data))
+}
+
+// Query スコープの組織のドキュメントだけに絞り込む
+func Query(ctx context.Context, q firestore.Query) firestore.Query {
+	s, ok := ScopeFromContext(ctx)
+	if !ok || s.All() {
+		return q
+	}
+	if s.None {
+		return q.Where(FieldTenantID, "==", noTenantID)
+	}
+	return q.Where(FieldTenantID, "==", s.TenantID)
+}
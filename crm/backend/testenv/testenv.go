@@ -0,0 +1,221 @@
+package testenv
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// エミュレータの接続先（firebase.json の emulators で起動したもの）
+//
+//	firebase emulators:exec --only firestore,auth "go test ./..."
+//
+// emulators:exec は接続先の環境変数を設定してコマンドを実行する。起動済みのエミュレータを使う場合は
+// FIRESTORE_EMULATOR_HOST=localhost:8081 FIREBASE_AUTH_EMULATOR_HOST=localhost:9099 go test ./...
+const (
+	FirestoreEmulatorHostEnv = "FIRESTORE_EMULATOR_HOST"
+	AuthEmulatorHostEnv      = "FIREBASE_AUTH_EMULATOR_HOST"
+)
+
+// projectPrefix エミュレータ用のプロジェクトID の接頭辞（demo- で始まるプロジェクトは本番に接続しない）
+const projectPrefix = "demo-crm-"
+
+// Env エミュレータに接続したテスト用の環境
+//
+// テストごとに別のプロジェクトIDを使うため、並列に実行してもデータは混ざらない。
+type Env struct {
+	ProjectID string
+	App       *firebase.App
+	Auth      *auth.Client
+	Firestore *firestore.Client
+
+	firestoreHost string
+	authHost      string
+}
+
+// New エミュレータに接続する。環境変数が設定されていない場合はテストをスキップする
+//
+// テストの終了時にプロジェクトのドキュメントと Auth のユーザーを削除する。
+func New(t testing.TB) *Env {
+	t.Helper()
+
+	firestoreHost := os.Getenv(FirestoreEmulatorHostEnv)
+	authHost := os.Getenv(AuthEmulatorHostEnv)
+	if firestoreHost == "" || authHost == "" {
+		t.Skipf("%s and %s must be set to run tests against the emulators", FirestoreEmulatorHostEnv, AuthEmulatorHostEnv)
+	}
+
+	ctx := context.Background()
+	projectID := projectPrefix + randomSuffix(t)
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: projectID})
+	if err != nil {
+		t.Fatalf("failed to initialize firebase app: %v", err)
+	}
+	authClient, err := app.Auth(ctx)
+	if err != nil {
+		t.Fatalf("failed to get auth client: %v", err)
+	}
+	firestoreClient, err := app.Firestore(ctx)
+	if err != nil {
+		t.Fatalf("failed to get firestore client: %v", err)
+	}
+
+	env := &Env{
+		ProjectID:     projectID,
+		App:           app,
+		Auth:          authClient,
+		Firestore:     firestoreClient,
+		firestoreHost: firestoreHost,
+		authHost:      authHost,
+	}
+	t.Cleanup(func() {
+		env.Reset(t)
+		firestoreClient.Close()
+	})
+	return env
+}
+
+// Fixtures 投入するドキュメント（コレクション → ドキュメントID → フィールド）
+type Fixtures map[string]map[string]map[string]interface{}
+
+// Seed fixtures を Firestore に書き込む
+func (e *Env) Seed(t testing.TB, fixtures Fixtures) {
+	t.Helper()
+
+	ctx := context.Background()
+	bw := e.Firestore.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for collection, docs := range fixtures {
+		for id, data := range docs {
+			job, err := bw.Set(e.Firestore.Collection(collection).Doc(id), data)
+			if err != nil {
+				t.Fatalf("failed to seed %s/%s: %v", collection, id, err)
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			t.Fatalf("failed to seed fixtures: %v", err)
+		}
+	}
+}
+
+// Doc ドキュメントのフィールド（存在しない場合は nil）
+func (e *Env) Doc(t testing.TB, collection, id string) map[string]interface{} {
+	t.Helper()
+
+	doc, err := e.Firestore.Collection(collection).Doc(id).Get(context.Background())
+	if err != nil {
+		if grpcstatus.Code(err) == codes.NotFound {
+			return nil
+		}
+		t.Fatalf("failed to get %s/%s: %v", collection, id, err)
+	}
+	return doc.Data()
+}
+
+// Docs コレクションのドキュメント（ドキュメントID → フィールド）
+func (e *Env) Docs(t testing.TB, collection string) map[string]map[string]interface{} {
+	t.Helper()
+
+	docs, err := e.Firestore.Collection(collection).Documents(context.Background()).GetAll()
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", collection, err)
+	}
+	result := make(map[string]map[string]interface{}, len(docs))
+	for _, doc := range docs {
+		result[doc.Ref.ID] = doc.Data()
+	}
+	return result
+}
+
+// CreateUser Auth エミュレータにユーザーを作成し、claims をカスタムクレームに設定する
+func (e *Env) CreateUser(t testing.TB, email, password string, claims map[string]interface{}) *auth.UserRecord {
+	t.Helper()
+
+	ctx := context.Background()
+	user, err := e.Auth.CreateUser(ctx, (&auth.UserToCreate{}).Email(email).Password(password))
+	if err != nil {
+		t.Fatalf("failed to create auth user %s: %v", email, err)
+	}
+	if claims != nil {
+		if err := e.Auth.SetCustomUserClaims(ctx, user.UID, claims); err != nil {
+			t.Fatalf("failed to set custom claims for %s: %v", email, err)
+		}
+	}
+	return user
+}
+
+// SignIn Auth エミュレータでパスワード認証し、ID トークン（Authorization: Bearer に使う）を返す
+func (e *Env) SignIn(t testing.TB, email, password string) string {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{"email": email, "password": password, "returnSecureToken": true})
+	url := fmt.Sprintf("http://%s/identitytoolkit.googleapis.com/v1/accounts:signInWithPassword?key=fake-api-key", e.authHost)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to sign in %s: %v", email, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		IDToken string `json:"idToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || resp.StatusCode != http.StatusOK || result.IDToken == "" {
+		t.Fatalf("failed to sign in %s: status %d, error %v", email, resp.StatusCode, err)
+	}
+	return result.IDToken
+}
+
+// Reset プロジェクトのドキュメントと Auth のユーザーをすべて削除する
+func (e *Env) Reset(t testing.TB) {
+	t.Helper()
+
+	e.delete(t, fmt.Sprintf("http://%s/emulator/v1/projects/%s/databases/(default)/documents", e.firestoreHost, e.ProjectID))
+	e.delete(t, fmt.Sprintf("http://%s/emulator/v1/projects/%s/accounts", e.authHost, e.ProjectID))
+}
+
+func (e *Env) delete(t testing.TB, url string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("failed to reset emulator (%s): %v", url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("failed to reset emulator (%s): status %d", url, resp.StatusCode)
+	}
+}
+
+// randomSuffix プロジェクトIDに使えるランダムな文字列（小文字の英数字）
+func randomSuffix(t testing.TB) string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to generate project id: %v", err)
+	}
+	return strings.ToLower(hex.EncodeToString(b))
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GraphQLExtension オペレーションとリゾルバごとにスパンを作成する gqlgen 拡張
+type GraphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "OpenTelemetryTracing"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (GraphQLExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse オペレーション全体のスパンを作成
+func (GraphQLExtension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	opType := "unknown"
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+	}
+	name := opCtx.OperationName
+	if name == "" {
+		name = "anonymous"
+	}
+
+	ctx, span := Tracer().Start(ctx, "graphql."+opType+" "+name,
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.operation.type", opType),
+		),
+	)
+
+	resp := next(ctx)
+
+	var err error
+	if resp != nil && len(resp.Errors) > 0 {
+		err = errors.New(resp.Errors.Error())
+	}
+	EndSpan(span, err)
+	return resp
+}
+
+// InterceptField リゾルバ関数を持つフィールドのスパンを作成
+//
+// 構造体のフィールドを読むだけのものはスパン数が膨大になるため対象外とする。
+func (GraphQLExtension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := Tracer().Start(ctx, "graphql.resolve "+fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			attribute.String("graphql.field.object", fc.Object),
+			attribute.String("graphql.field.name", fc.Field.Name),
+			attribute.String("graphql.field.path", fc.Path().String()),
+		),
+	)
+
+	res, err := next(ctx)
+	EndSpan(span, err)
+	return res, err
+}
@@ -0,0 +1,92 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"narratives-crm-backend/apperr"
+)
+
+// GraphQLExtension resolver の引数を validate タグに従って検証する gqlgen 拡張
+//
+// エラーはフィールドごとにまとめて1つの VALIDATION エラーとして返し、resolver は呼び出さない。
+type GraphQLExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "InputValidation"
+}
+
+// Validate graphql.HandlerExtension の実装
+//
+// 入力型の @goTag(key: "validate") のルールをすべて解析し、誤りがあればサーバーの起動時にエラーにする。
+func (GraphQLExtension) Validate(schema graphql.ExecutableSchema) error {
+	types := schema.Schema().Types
+	names := make([]string, 0, len(types))
+	for name, def := range types {
+		if def.Kind == ast.InputObject {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		for _, f := range types[name].Fields {
+			tag, ok := goTag(f.Directives)
+			if !ok {
+				continue
+			}
+			if err := Rules(tag); err != nil {
+				errs = append(errs, fmt.Errorf("validate: %s.%s: %w", name, f.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// goTag @goTag(key: "validate", value: "...") の value
+func goTag(directives ast.DirectiveList) (string, bool) {
+	for _, d := range directives.ForNames("goTag") {
+		key, value := d.Arguments.ForName("key"), d.Arguments.ForName("value")
+		if key == nil || value == nil || key.Value.Raw != TagName {
+			continue
+		}
+		return value.Value.Raw, true
+	}
+	return "", false
+}
+
+// InterceptField graphql.FieldInterceptor の実装
+func (GraphQLExtension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver || len(fc.Args) == 0 {
+		return next(ctx)
+	}
+
+	// エラーの順序を安定させるため引数名の順に検証する
+	names := make([]string, 0, len(fc.Args))
+	for name := range fc.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []apperr.FieldError
+	for _, name := range names {
+		errs = append(errs, Struct(name, fc.Args[name])...)
+	}
+	if len(errs) > 0 {
+		return nil, apperr.InvalidFields(errs)
+	}
+	return next(ctx)
+}
@@ -0,0 +1,32 @@
+package validate
+
+import (
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// IsEmail メールアドレスの形式かどうか（表示名付きの形式は許可しない）
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// IsKatakana カタカナのみかどうか
+//
+// 全角・半角カタカナに加えて、長音符（ー / ｰ）、半角の濁点・半濁点、中黒（・）、空白を許可する。
+func IsKatakana(s string) bool {
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Katakana):
+		case r == 'ー', r == 'ｰ', r == 'ﾞ', r == 'ﾟ', r == '・', r == ' ', r == '　':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"narratives-crm-backend/apperr"
+)
+
+// TagName 検証ルールを記述する struct タグ
+//
+// スキーマの @goTag(key: "validate", value: "...") で生成される入力型に付与する。
+//
+//	required   空文字・空のスライスを許可しない
+//	email      メールアドレスの形式
+//	katakana   カタカナ（長音符・中黒・空白を含む）のみ
+//	min=N      文字列・スライスの長さ、数値の最小値
+//	max=N      文字列・スライスの長さ、数値の最大値
+//	gte=N      数値が N 以上
+//	gt=N       数値が N より大きい
+//
+// ポインタが nil のフィールド（省略された任意項目）は検証しない。
+// 構造体・構造体のスライスは再帰的に検証する。
+// スキーマのルールは起動時に GraphQLExtension.Validate で確かめる。
+const TagName = "validate"
+
+// rule 解析済みの検証ルール
+type rule struct {
+	name  string
+	param float64
+}
+
+// parseRule ルールを解析する（未知のルール・数値でないパラメータはエラー）
+func parseRule(s string) (rule, error) {
+	name, param, _ := strings.Cut(strings.TrimSpace(s), "=")
+	r := rule{name: name}
+	switch name {
+	case "", "required", "email", "katakana":
+	case "min", "max", "gte", "gt":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid parameter in rule %q", s)
+		}
+		r.param = n
+	default:
+		return r, fmt.Errorf("unknown rule %q", s)
+	}
+	return r, nil
+}
+
+// Rules タグのルールがすべて解析できるか
+func Rules(tag string) error {
+	for _, s := range strings.Split(tag, ",") {
+		if _, err := parseRule(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Struct v のフィールドを検証し、エラーをすべて返す。path はエラーのフィールド名の接頭辞
+func Struct(path string, v interface{}) []apperr.FieldError {
+	var errs []apperr.FieldError
+	walk(path, reflect.ValueOf(v), "", &errs)
+	return errs
+}
+
+// Check v を検証し、エラーがあれば apperr.InvalidFields を返す
+func Check(path string, v interface{}) error {
+	if errs := Struct(path, v); len(errs) > 0 {
+		return apperr.InvalidFields(errs)
+	}
+	return nil
+}
+
+func walk(path string, v reflect.Value, tag string, errs *[]apperr.FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if tag != "" {
+		for _, s := range strings.Split(tag, ",") {
+			if msg := check(s, v); msg != "" {
+				*errs = append(*errs, apperr.FieldError{Field: path, Message: msg})
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			walk(join(path, fieldName(f)), v.Field(i), f.Tag.Get(TagName), errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i), "", errs)
+		}
+	}
+}
+
+// check ルールに違反していればメッセージを返す
+//
+// 解析できないルールは値によらず違反として扱う（スキーマのルールは起動時に確かめている）。
+func check(s string, v reflect.Value) string {
+	r, err := parseRule(s)
+	if err != nil {
+		return fmt.Sprintf("cannot be validated: %v", err)
+	}
+	switch r.name {
+	case "required":
+		if isEmpty(v) {
+			return "is required"
+		}
+	case "email":
+		if s, ok := str(v); ok && s != "" && !IsEmail(s) {
+			return "must be a valid email address"
+		}
+	case "katakana":
+		if s, ok := str(v); ok && s != "" && !IsKatakana(s) {
+			return "must contain only katakana"
+		}
+	case "min", "max", "gte", "gt":
+		return compare(r.name, r.param, v)
+	}
+	return ""
+}
+
+func compare(name string, n float64, v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		length := float64(utf8.RuneCountInString(v.String()))
+		if name == "min" && length < n {
+			return fmt.Sprintf("must be at least %g characters", n)
+		}
+		if name == "max" && length > n {
+			return fmt.Sprintf("must be at most %g characters", n)
+		}
+	case reflect.Slice, reflect.Array:
+		length := float64(v.Len())
+		if name == "min" && length < n {
+			return fmt.Sprintf("must contain at least %g items", n)
+		}
+		if name == "max" && length > n {
+			return fmt.Sprintf("must contain at most %g items", n)
+		}
+	default:
+		num, ok := number(v)
+		if !ok {
+			return ""
+		}
+		switch {
+		case (name == "min" || name == "gte") && num < n:
+			return fmt.Sprintf("must be greater than or equal to %g", n)
+		case name == "gt" && num <= n:
+			return fmt.Sprintf("must be greater than %g", n)
+		case name == "max" && num > n:
+			return fmt.Sprintf("must be less than or equal to %g", n)
+		}
+	}
+	return ""
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return false
+}
+
+func str(v reflect.Value) (string, bool) {
+	if v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// fieldName json タグの名前（GraphQL のフィールド名と同じ）
+func fieldName(f reflect.StructField) string {
+	if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,212 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"narratives-crm-backend/apperr"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+type address struct {
+	Zip string `json:"zip" validate:"required,max=8"`
+}
+
+type input struct {
+	Name      string    `json:"name" validate:"required,max=5"`
+	Kana      string    `json:"kana" validate:"katakana"`
+	Email     *string   `json:"email,omitempty" validate:"email"`
+	Tags      []string  `json:"tags" validate:"max=2"`
+	Age       *int      `json:"age,omitempty" validate:"gte=0,max=150"`
+	Amount    float64   `json:"amount" validate:"gt=0"`
+	Addresses []address `json:"addresses"`
+}
+
+func valid() input {
+	return input{Name: "Taro", Kana: "タロウ", Amount: 1}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*input)
+		want   []apperr.FieldError
+	}{
+		{name: "valid", modify: func(*input) {}},
+		{
+			name:   "required and max length",
+			modify: func(in *input) { in.Name = "   " },
+			want:   []apperr.FieldError{{Field: "in.name", Message: "is required"}},
+		},
+		{
+			name:   "max counts characters, not bytes",
+			modify: func(in *input) { in.Name = "やまだたろう" },
+			want:   []apperr.FieldError{{Field: "in.name", Message: "must be at most 5 characters"}},
+		},
+		{
+			name:   "katakana",
+			modify: func(in *input) { in.Kana = "たろう" },
+			want:   []apperr.FieldError{{Field: "in.kana", Message: "must contain only katakana"}},
+		},
+		{
+			name:   "nil pointers are not validated",
+			modify: func(in *input) { in.Email, in.Age = nil, nil },
+		},
+		{
+			name:   "pointer values are validated",
+			modify: func(in *input) { in.Email, in.Age = ptr("taro"), ptr(-1) },
+			want: []apperr.FieldError{
+				{Field: "in.email", Message: "must be a valid email address"},
+				{Field: "in.age", Message: "must be greater than or equal to 0"},
+			},
+		},
+		{
+			name:   "slice length",
+			modify: func(in *input) { in.Tags = []string{"a", "b", "c"} },
+			want:   []apperr.FieldError{{Field: "in.tags", Message: "must contain at most 2 items"}},
+		},
+		{
+			name:   "gt",
+			modify: func(in *input) { in.Amount = 0 },
+			want:   []apperr.FieldError{{Field: "in.amount", Message: "must be greater than 0"}},
+		},
+		{
+			name:   "nested structs in slices",
+			modify: func(in *input) { in.Addresses = []address{{Zip: "1000001"}, {}} },
+			want:   []apperr.FieldError{{Field: "in.addresses[1].zip", Message: "is required"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid()
+			tt.modify(&in)
+
+			if got := Struct("in", &in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructUnknownRule(t *testing.T) {
+	v := struct {
+		Code string `json:"code" validate:"uuid"`
+	}{Code: "x"}
+
+	want := []apperr.FieldError{{Field: "code", Message: `cannot be validated: unknown rule "uuid"`}}
+	if got := Struct("", v); !reflect.DeepEqual(got, want) {
+		t.Errorf("Struct() = %v, want %v", got, want)
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"taro@example.com", true},
+		{"taro.yamada+crm@mail.example.co.jp", true},
+		{"taro", false},
+		{"taro@localhost", false},
+		{"taro@example.", false},
+		{"Taro <taro@example.com>", false},
+	}
+	for _, tt := range tests {
+		if got := IsEmail(tt.email); got != tt.want {
+			t.Errorf("IsEmail(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestIsKatakana(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"ヤマダ タロウ", true},
+		{"ｻﾄｳ・ｼﾞﾛｳ", true},
+		{"ローマ", true},
+		{"やまだ", false},
+		{"Yamada", false},
+		{"山田", false},
+	}
+	for _, tt := range tests {
+		if got := IsKatakana(tt.s); got != tt.want {
+			t.Errorf("IsKatakana(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		tag     string
+		wantErr string
+	}{
+		{tag: "required,email,max=254"},
+		{tag: "katakana, min=1, gte=0, gt=0.5"},
+		{tag: "max=ten", wantErr: `invalid parameter in rule "max=ten"`},
+		{tag: "required,uuid", wantErr: `unknown rule "uuid"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			err := Rules(tt.tag)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Rules() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Rules() = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphQLExtensionValidate(t *testing.T) {
+	const directive = `directive @goTag(key: String!, value: String) on INPUT_FIELD_DEFINITION | FIELD_DEFINITION
+type Query { ping(input: PingInput): String }
+`
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:  "valid rules",
+			input: `input PingInput { name: String @goTag(key: "validate", value: "required,max=5") }`,
+		},
+		{
+			name:  "other tags are ignored",
+			input: `input PingInput { name: String @goTag(key: "json", value: "name,omitempty") }`,
+		},
+		{
+			name:    "unknown rule",
+			input:   `input PingInput { name: String @goTag(key: "validate", value: "required,uuid") }`,
+			wantErr: `validate: PingInput.name: unknown rule "uuid"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphqls", Input: directive + tt.input})
+			if err != nil {
+				t.Fatalf("failed to load schema: %v", err)
+			}
+			es := &graphql.ExecutableSchemaMock{SchemaFunc: func() *ast.Schema { return schema }}
+
+			err = GraphQLExtension{}.Validate(es)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,8 +8,7 @@ Write-Host "🚀 Deploying Narratives CRM to Cloud Run..." -ForegroundColor Gree
 
 # Build and push the image
 Write-Host "📦 Building Docker image..." -ForegroundColor Yellow
-Set-Location backend
-docker build -t "${IMAGE_NAME}:latest" .
+docker build -f backend/Dockerfile -t "${IMAGE_NAME}:latest" ..
 
 Write-Host "📤 Pushing image to Container Registry..." -ForegroundColor Yellow
 docker push "${IMAGE_NAME}:latest"
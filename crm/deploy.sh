@@ -10,8 +10,7 @@ echo "🚀 Deploying Narratives CRM to Cloud Run..."
 
 # Build and push the image
 echo "📦 Building Docker image..."
-cd backend
-docker build -t $IMAGE_NAME:latest .
+docker build -f backend/Dockerfile -t $IMAGE_NAME:latest ..
 
 echo "📤 Pushing image to Container Registry..."
 docker push $IMAGE_NAME:latest
@@ -0,0 +1,21 @@
+import { crmAuth } from '../config/firebase';
+
+/**
+ * ログイン中のユーザーの組織ID（バックエンドが設定するカスタムクレーム tenant_id）
+ *
+ * Firestore のルールは同じ組織のドキュメントだけを読み取れるため、
+ * 一覧のクエリは where('tenant_id', '==', 組織ID) で絞り込み、作成するドキュメントには tenant_id を設定する。
+ */
+export async function getTenantId(): Promise<string> {
+  const currentUser = crmAuth.currentUser;
+  if (!currentUser) {
+    throw new Error('ログインしていません');
+  }
+
+  const { claims } = await currentUser.getIdTokenResult();
+  const tenantId = claims.tenant_id;
+  if (typeof tenantId !== 'string' || tenantId === '') {
+    throw new Error('組織が割り当てられていません。管理者に連絡してください');
+  }
+  return tenantId;
+}
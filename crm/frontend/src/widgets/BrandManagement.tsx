@@ -2,6 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { collection, addDoc, query, where, getDocs } from 'firebase/firestore';
 import { crmDb } from '../config/firebase';
 import { BrandModel } from '../models/Brands';
+import { getTenantId } from '../utils/tenant';
 import './BrandManagement.css';
 
 interface BrandManagementProps {
@@ -40,6 +41,7 @@ const BrandManagement: React.FC<BrandManagementProps> = ({ isOpen, onClose, curr
       const usersCollection = collection(crmDb, 'users');
       const membersQuery = query(
         usersCollection,
+        where('tenant_id', '==', await getTenantId()),
         where('belong_to', 'array-contains', companyId)
       );
       
@@ -72,6 +74,7 @@ const BrandManagement: React.FC<BrandManagementProps> = ({ isOpen, onClose, curr
       const brandsCollection = collection(crmDb, 'brands');
       const brandsQuery = query(
         brandsCollection,
+        where('tenant_id', '==', await getTenantId()),
         where('company_id', '==', companyId)
       );
       
@@ -150,7 +153,7 @@ const BrandManagement: React.FC<BrandManagementProps> = ({ isOpen, onClose, curr
 
       // Firestoreにブランドを保存
       const brandsCollection = collection(crmDb, 'brands');
-      await addDoc(brandsCollection, brand.toMap());
+      await addDoc(brandsCollection, { ...brand.toMap(), tenant_id: await getTenantId() });
       
       alert(`✅ ブランド「${brand.brandName}」が正常に作成されました！`);
       
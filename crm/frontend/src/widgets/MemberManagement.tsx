@@ -4,6 +4,7 @@ import { collection, query, where, getDocs, doc, updateDoc, deleteDoc, onSnapsho
 import BusinessUserModel from '../models/BusinessUsers';
 import { EmailService } from '../services/authenticationEmailService';
 import { deleteUserFromAuth } from '../services/authService';
+import { getTenantId } from '../utils/tenant';
 import './MemberManagement.css';
 
 interface MemberManagementProps {
@@ -40,6 +41,7 @@ const MemberManagement: React.FC<MemberManagementProps> = ({
     try {
       const membersQuery = query(
         collection(crmDb, 'business_users'),
+        where('tenant_id', '==', await getTenantId()),
         where('belong_to', 'array-contains', companyId)
       );
       
@@ -70,11 +72,20 @@ const MemberManagement: React.FC<MemberManagementProps> = ({
   };
 
   // リアルタイムでメンバー一覧を監視
-  const setupRealtimeListener = () => {
+  const setupRealtimeListener = async () => {
     if (!companyId) return;
 
+    let tenantId: string;
+    try {
+      tenantId = await getTenantId();
+    } catch (error) {
+      console.error('リアルタイムリスナーエラー:', error);
+      return;
+    }
+
     const membersQuery = query(
       collection(crmDb, 'business_users'),
+      where('tenant_id', '==', tenantId),
       where('belong_to', 'array-contains', companyId)
     );
 
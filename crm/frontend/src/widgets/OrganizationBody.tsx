@@ -10,6 +10,7 @@ import MemberManagement from './MemberManagement';
 import BrandManagement from './BrandManagement';
 import EmailService from '../services/authenticationEmailService';
 import { debugCurrentUserPermissions, testFirestoreRules } from '../utils/debugPermissions';
+import { getTenantId } from '../utils/tenant';
 import './OrganizationBody.css';
 
 interface OrganizationBodyProps {
@@ -107,6 +108,7 @@ const OrganizationBody: React.FC<OrganizationBodyProps> = ({ currentUser, onComp
         // その会社のウォレット情報を取得
         const walletsQuery = query(
           collection(crmDb, 'wallets'),
+          where('tenant_id', '==', await getTenantId()),
           where('company_id', '==', companyId)
         );
         const walletDocs = await getDocs(walletsQuery);
@@ -158,6 +160,7 @@ const OrganizationBody: React.FC<OrganizationBodyProps> = ({ currentUser, onComp
       const brandsCollection = collection(crmDb, 'brands');
       const brandsQuery = query(
         brandsCollection,
+        where('tenant_id', '==', await getTenantId()),
         where('company_id', '==', companyId)
       );
       
@@ -296,6 +299,9 @@ const OrganizationBody: React.FC<OrganizationBodyProps> = ({ currentUser, onComp
         createdBy: currentUser.userId,
       });
 
+      // 会社とウォレットは自分の組織に作成する
+      const tenantId = await getTenantId();
+
       // CompanyModelインスタンスを作成
       const company = CompanyModel.newCompany({
         userId: currentUser.userId,
@@ -310,7 +316,7 @@ const OrganizationBody: React.FC<OrganizationBodyProps> = ({ currentUser, onComp
       // Firestoreに会社を保存
       const companiesCollection = collection(crmDb, 'companies');
       console.log('Adding document to Firestore...');
-      const docRef = await addDoc(companiesCollection, company.toMap());
+      const docRef = await addDoc(companiesCollection, { ...company.toMap(), tenant_id: tenantId });
       
       console.log('Company created with ID:', docRef.id);
       
@@ -410,7 +416,7 @@ const OrganizationBody: React.FC<OrganizationBodyProps> = ({ currentUser, onComp
 
         // Firestoreにウォレット情報を保存
         const walletsCollection = collection(crmDb, 'wallets');
-        const walletDocRef = await addDoc(walletsCollection, solanaWallet.toMap());
+        const walletDocRef = await addDoc(walletsCollection, { ...solanaWallet.toMap(), tenant_id: tenantId });
         
         console.log('Solana wallet created successfully:', {
           walletId: walletDocRef.id,
@@ -0,0 +1,142 @@
+package cors
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	rscors "github.com/rs/cors"
+)
+
+// Config CORS ポリシー設定
+type Config struct {
+	// AllowedOrigins 許可するオリジン。"https://*.web.app" のように * を1つ含めるとワイルドカード
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // プリフライト結果のキャッシュ秒数
+}
+
+// DefaultConfig 環境変数が未設定の場合に使う設定
+func DefaultConfig() Config {
+	return Config{
+		AllowedOrigins: []string{
+			"http://localhost:*",
+			"https://localhost:*",
+		},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Requested-With", "X-Request-ID"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         600,
+	}
+}
+
+// ConfigFromEnv 環境変数から設定を読み込む
+//
+//	CORS_ORIGIN       許可するオリジン（カンマ区切り）
+//	CORS_METHODS      許可するメソッド（カンマ区切り）
+//	CORS_HEADERS      許可するリクエストヘッダー（カンマ区切り）
+//	CORS_CREDENTIALS  Cookie 等の認証情報を許可するか（true / false）
+//	CORS_MAX_AGE      プリフライト結果のキャッシュ秒数
+//
+// 未設定の項目は base の値を使う。
+func ConfigFromEnv(base Config) Config {
+	cfg := base
+	if v := splitList(os.Getenv("CORS_ORIGIN")); len(v) > 0 {
+		cfg.AllowedOrigins = v
+	}
+	if v := splitList(os.Getenv("CORS_METHODS")); len(v) > 0 {
+		cfg.AllowedMethods = v
+	}
+	if v := splitList(os.Getenv("CORS_HEADERS")); len(v) > 0 {
+		cfg.AllowedHeaders = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("CORS_CREDENTIALS")); err == nil {
+		cfg.AllowCredentials = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE")); err == nil {
+		cfg.MaxAge = v
+	}
+	return cfg
+}
+
+// Middleware 設定に従って CORS ヘッダーを付与するミドルウェア
+//
+// 許可されたプリフライトには 204 を、オリジン・メソッド・ヘッダーのいずれかが
+// 許可されていないプリフライトには 403 を返し、後続のハンドラは呼び出さない。
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	c := rscors.New(rscors.Options{
+		AllowedOrigins:     cfg.AllowedOrigins,
+		AllowedMethods:     cfg.AllowedMethods,
+		AllowedHeaders:     cfg.AllowedHeaders,
+		ExposedHeaders:     cfg.ExposedHeaders,
+		AllowCredentials:   cfg.AllowCredentials,
+		MaxAge:             cfg.MaxAge,
+		OptionsPassthrough: true,
+	})
+
+	return func(next http.Handler) http.Handler {
+		return c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isPreflight(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// rs/cors は許可したプリフライトにだけ Access-Control-Allow-Origin を設定する
+			if w.Header().Get("Access-Control-Allow-Origin") == "" {
+				slog.WarnContext(r.Context(), "CORS preflight rejected",
+					slog.String("origin", r.Header.Get("Origin")),
+					slog.String("method", r.Header.Get("Access-Control-Request-Method")),
+					slog.String("headers", r.Header.Get("Access-Control-Request-Headers")),
+					slog.String("path", r.URL.Path))
+				http.Error(w, "CORS preflight request not allowed", http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+}
+
+// AllowsOrigin origin が AllowedOrigins に一致するかどうか（WebSocket の Origin 検証用）
+//
+// Origin ヘッダーのないリクエスト（ブラウザ以外のクライアント）は許可する。
+func (c Config) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isPreflight CORS プリフライトリクエストかどうか
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
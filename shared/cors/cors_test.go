@@ -0,0 +1,145 @@
+package cors
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.AllowedOrigins = []string{"https://crm.example.com", "https://*.web.app"}
+	return cfg
+}
+
+func TestMiddleware(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name        string
+		method      string
+		headers     map[string]string
+		wantCode    int
+		wantOrigin  string
+		wantReached bool
+	}{
+		{
+			name:        "simple request from an allowed origin",
+			method:      http.MethodPost,
+			headers:     map[string]string{"Origin": "https://crm.example.com"},
+			wantCode:    http.StatusOK,
+			wantOrigin:  "https://crm.example.com",
+			wantReached: true,
+		},
+		{
+			name:        "simple request from another origin is passed without CORS headers",
+			method:      http.MethodPost,
+			headers:     map[string]string{"Origin": "https://evil.example.com"},
+			wantCode:    http.StatusOK,
+			wantReached: true,
+		},
+		{
+			name:   "preflight from a wildcard origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://narratives-crm.web.app",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "authorization,content-type,x-request-id",
+			},
+			wantCode:   http.StatusNoContent,
+			wantOrigin: "https://narratives-crm.web.app",
+		},
+		{
+			name:   "preflight from another origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "preflight with a header that is not allowed",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://crm.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "x-debug",
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:        "OPTIONS without CORS headers is not a preflight",
+			method:      http.MethodOptions,
+			wantCode:    http.StatusOK,
+			wantReached: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			h := Middleware(testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "/graphql", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if reached != tt.wantReached {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantReached)
+			}
+		})
+	}
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"https://crm.example.com", true},
+		{"HTTPS://CRM.EXAMPLE.COM", true},
+		{"https://narratives-crm.web.app", true},
+		{"https://web.app", false},
+		{"https://crm.example.com.evil.com", false},
+		{"http://crm.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := testConfig().AllowsOrigin(tt.origin); got != tt.want {
+			t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ORIGIN", " https://a.example.com, ,https://b.example.com ")
+	t.Setenv("CORS_METHODS", "")
+	t.Setenv("CORS_HEADERS", "Authorization")
+	t.Setenv("CORS_CREDENTIALS", "true")
+	t.Setenv("CORS_MAX_AGE", "not a number")
+
+	base := DefaultConfig()
+	want := base
+	want.AllowedOrigins = []string{"https://a.example.com", "https://b.example.com"}
+	want.AllowedHeaders = []string{"Authorization"}
+	want.AllowCredentials = true
+
+	if got := ConfigFromEnv(base); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigFromEnv() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// probeID 疎通確認で参照する存在しないドキュメント／ユーザーのID
+const probeID = "__readiness_probe__"
+
+// FirestoreCheck Firestore への疎通確認
+//
+// 存在しないドキュメントを読み、NotFound が返れば接続できているとみなす。
+func FirestoreCheck(client *firestore.Client) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		if client == nil {
+			return nil, errors.New("firestore client is not initialized")
+		}
+		_, err := client.Collection("_health").Doc(probeID).Get(ctx)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// FirebaseAuthCheck Firebase Authentication への疎通確認
+//
+// 存在しないユーザーを取得し、user-not-found が返れば接続できているとみなす。
+func FirebaseAuthCheck(client *auth.Client) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		if client == nil {
+			return nil, errors.New("firebase auth client is not initialized")
+		}
+		_, err := client.GetUser(ctx, probeID)
+		if err != nil && !auth.IsUserNotFound(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
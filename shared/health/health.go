@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ステータス
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// CheckFunc 依存先の疎通確認。details はレスポンスにそのまま含まれる
+type CheckFunc func(ctx context.Context) (details map[string]interface{}, err error)
+
+// Result 依存先ごとの確認結果
+type Result struct {
+	Status     string                 `json:"status"`
+	DurationMS int64                  `json:"duration_ms"`
+	Error      string                 `json:"error,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report readiness の確認結果
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker 登録された依存先をまとめて確認する
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []namedCheck
+}
+
+// NewChecker Checker のコンストラクタ。timeout は依存先ごとの制限時間
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// TimeoutFromEnv READINESS_TIMEOUT（例: "3s"）から制限時間を読み込む
+func TimeoutFromEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("READINESS_TIMEOUT")); err == nil && d > 0 {
+		return d
+	}
+	return 3 * time.Second
+}
+
+// Register 依存先の確認を登録
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run 全ての依存先を並行して確認
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]*Result, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := c.runOne(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusError
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, check CheckFunc) *Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := &Result{
+		Status:     StatusOK,
+		DurationMS: time.Since(start).Milliseconds(),
+		Details:    details,
+	}
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LiveHandler /livez 用ハンドラ。プロセスが応答できれば常に 200 を返す
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadyHandler /readyz 用ハンドラ。依存先が1つでも異常なら 503 を返す
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+			slog.WarnContext(r.Context(), "readiness check failed", slog.Any("checks", report.Checks))
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"bucket": "b"}, nil
+}
+
+func failing(context.Context) (map[string]interface{}, error) {
+	return nil, errors.New("connection refused")
+}
+
+// slow 制限時間を過ぎるまで終わらない確認
+func slow(ctx context.Context) (map[string]interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return nil, nil
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{name: "no checks", wantCode: http.StatusOK, wantStatus: StatusOK, wantChecks: map[string]string{}},
+		{
+			name:       "all checks pass",
+			checks:     map[string]CheckFunc{"firestore": ok, "storage": ok},
+			wantCode:   http.StatusOK,
+			wantStatus: StatusOK,
+			wantChecks: map[string]string{"firestore": StatusOK, "storage": StatusOK},
+		},
+		{
+			name:       "one check fails",
+			checks:     map[string]CheckFunc{"firestore": ok, "firebase_auth": failing},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusError,
+			wantChecks: map[string]string{"firestore": StatusOK, "firebase_auth": StatusError},
+		},
+		{
+			name:       "checks time out",
+			checks:     map[string]CheckFunc{"firestore": slow},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: StatusError,
+			wantChecks: map[string]string{"firestore": StatusError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(20 * time.Millisecond)
+			for name, check := range tt.checks {
+				c.Register(name, check)
+			}
+
+			rec := httptest.NewRecorder()
+			c.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			var report Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("failed to decode report: %v", err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				got := report.Checks[name]
+				if got == nil || got.Status != want {
+					t.Errorf("checks[%s] = %+v, want status %s", name, got, want)
+					continue
+				}
+				if want == StatusError && got.Error == "" {
+					t.Errorf("checks[%s] has no error message", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestTimeoutFromEnv(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 3 * time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"-1s", 3 * time.Second},
+		{"soon", 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("READINESS_TIMEOUT", tt.env)
+			if got := TimeoutFromEnv(); got != tt.want {
+				t.Errorf("TimeoutFromEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(typ string, b []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}))
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "PKCS#8", key: encode("PRIVATE KEY", pkcs8)},
+		{name: "PKCS#1", key: encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+		{name: "not PEM", key: "MIIEvQIBADANBg", wantErr: true},
+		{name: "unsupported key", key: encode("EC PRIVATE KEY", sec1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePrivateKey(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("validatePrivateKey() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// StorageCheck 署名付きURLの発行に使うストレージ認証情報の確認
+//
+// 秘密鍵が署名に使える形式であることと、サービスアカウントで
+// バケットのメタデータを取得できることを確認する。
+func StorageCheck(credentialFile, bucketName, privateKey string) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details := map[string]interface{}{"bucket": bucketName}
+
+		if err := validatePrivateKey(privateKey); err != nil {
+			return details, err
+		}
+
+		client, err := storage.NewClient(ctx, option.WithCredentialsFile(credentialFile))
+		if err != nil {
+			return details, fmt.Errorf("failed to create storage client: %w", err)
+		}
+		defer client.Close()
+
+		if _, err := client.Bucket(bucketName).Attrs(ctx); err != nil {
+			return details, fmt.Errorf("failed to access bucket: %w", err)
+		}
+		return details, nil
+	}
+}
+
+// validatePrivateKey PEM 形式の PKCS#8 / PKCS#1 秘密鍵として読み込めるか確認
+func validatePrivateKey(privateKey string) error {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return errors.New("private key is not PEM encoded")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return nil
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return errors.New("private key cannot be parsed")
+	}
+	return nil
+}
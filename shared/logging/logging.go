@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config ロガー設定
+type Config struct {
+	Level   string // debug / info / warn / error
+	Format  string // json / text
+	Service string
+}
+
+// ConfigFromEnv 環境変数 LOG_LEVEL / LOG_FORMAT からロガー設定を読み込む
+func ConfigFromEnv(service string) Config {
+	cfg := Config{
+		Level:   os.Getenv("LOG_LEVEL"),
+		Format:  os.Getenv("LOG_FORMAT"),
+		Service: service,
+	}
+	if cfg.Level == "" {
+		cfg.Level = "info"
+	}
+	if cfg.Format == "" {
+		cfg.Format = "json"
+	}
+	return cfg
+}
+
+// Setup 設定に従ってロガーを生成し、slog のデフォルトに設定する
+//
+// slog.SetDefault により標準 log パッケージの出力も同じハンドラを経由する。
+func Setup(cfg Config) *slog.Logger {
+	logger := New(os.Stdout, cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New 出力先を指定してロガーを生成
+//
+// JSON 形式では Cloud Logging が解釈できるよう severity / message キーで出力する。
+// いずれの形式でも秘匿情報のマスクとリクエストIDの付与が行われる。
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level: ParseLevel(cfg.Level),
+	}
+
+	var h slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		opts.ReplaceAttr = cloudLoggingAttr
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	logger := slog.New(newContextHandler(newRedactHandler(h)))
+	if cfg.Service != "" {
+		logger = logger.With(slog.String("service", cfg.Service))
+	}
+	return logger
+}
+
+// ParseLevel ログレベル文字列を slog.Level に変換（不明な値は info）
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// cloudLoggingAttr Cloud Logging の構造化ログ形式に合わせてキー名とレベル表記を変換
+func cloudLoggingAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		level, _ := a.Value.Any().(slog.Level)
+		return slog.String("severity", severity(level))
+	case slog.MessageKey:
+		a.Key = "message"
+	}
+	return a
+}
+
+// severity slog.Level を Cloud Logging の LogSeverity に対応付ける
+func severity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Redacted マスク後に出力される値
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys キー名にこれらを含む属性は値を出力しない
+var sensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"private_key",
+	"privatekey",
+	"authorization",
+	"cookie",
+	"api_key",
+	"apikey",
+	"signed_url",
+	"signedurl",
+}
+
+// Secret ログに出力してはならない値
+//
+// slog.Any("x", logging.Secret(v)) のように包むとキー名に関係なくマスクされる。
+type Secret string
+
+// LogValue slog.LogValuer の実装
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// IsSensitiveKey キー名が秘匿情報を示すかどうか（HTTP ヘッダー名の - は _ とみなす）
+func IsSensitiveKey(key string) bool {
+	k := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHandler 秘匿情報をマスクしてから下位のハンドラに渡す
+type redactHandler struct {
+	next slog.Handler
+}
+
+func newRedactHandler(next slog.Handler) slog.Handler {
+	return &redactHandler{next: next}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, RedactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr 属性（グループ内も含む）をマスク
+func redactAttr(a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(v.String()))
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// RedactString 値そのものが鍵やトークンに見える場合はマスク
+func RedactString(s string) string {
+	if strings.Contains(s, "PRIVATE KEY-----") {
+		return Redacted
+	}
+	if strings.Contains(s, "X-Goog-Signature=") {
+		return Redacted
+	}
+	if strings.HasPrefix(s, "Bearer ") {
+		return Redacted
+	}
+	return s
+}
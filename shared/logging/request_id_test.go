@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	tests := []struct {
+		name   string
+		header string
+		want   string // 空の場合は新しく採番される
+	}{
+		{name: "no header"},
+		{name: "valid header is kept", header: "req-123.abc_DEF", want: "req-123.abc_DEF"},
+		{name: "header with spaces is replaced", header: "req 123"},
+		{name: "header with newlines is replaced", header: "req\n123"},
+		{name: "too long header is replaced", header: strings.Repeat("a", 129)},
+	}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			switch {
+			case tt.want != "" && got != tt.want:
+				t.Errorf("%s = %q, want %q", RequestIDHeader, got, tt.want)
+			case tt.want == "" && !generated.MatchString(got):
+				t.Errorf("%s = %q, want a generated ID", RequestIDHeader, got)
+			}
+			if fromContext != got {
+				t.Errorf("request ID in context = %q, want %q", fromContext, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus float64
+		wantLevel  string
+	}{
+		{
+			name:       "implicit 200",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) },
+			wantStatus: 200,
+			wantLevel:  "INFO",
+		},
+		{
+			name: "first status wins",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantStatus: 404,
+			wantLevel:  "INFO",
+		},
+		{
+			name:       "server errors are logged as errors",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+			wantStatus: 502,
+			wantLevel:  "ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(New(&buf, Config{Format: "json"}))
+
+			Middleware(tt.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/graphql", nil))
+
+			var line map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("failed to decode access log %q: %v", buf.String(), err)
+			}
+			if line["status"] != tt.wantStatus || line["severity"] != tt.wantLevel {
+				t.Errorf("status = %v, severity = %v, want %v, %s", line["status"], line["severity"], tt.wantStatus, tt.wantLevel)
+			}
+			if line[RequestIDKey] == "" || line[RequestIDKey] == nil {
+				t.Errorf("access log has no %s: %v", RequestIDKey, line)
+			}
+		})
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x02},
+		TraceFlags: trace.FlagsSampled,
+	})
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]interface{}
+	}{
+		{name: "no context values", ctx: context.Background(), want: map[string]interface{}{}},
+		{
+			name: "request ID",
+			ctx:  WithRequestID(context.Background(), "req-1"),
+			want: map[string]interface{}{RequestIDKey: "req-1"},
+		},
+		{
+			name: "trace",
+			ctx:  trace.ContextWithSpanContext(context.Background(), sc),
+			want: map[string]interface{}{"trace_id": sc.TraceID().String(), "span_id": sc.SpanID().String()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := logLine(t, func(l *slog.Logger) { l.InfoContext(tt.ctx, "hello") })
+			for _, key := range []string{RequestIDKey, "trace_id", "span_id"} {
+				if line[key] != tt.want[key] {
+					t.Errorf("%s = %v, want %v", key, line[key], tt.want[key])
+				}
+			}
+		})
+	}
+}
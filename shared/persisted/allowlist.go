@@ -0,0 +1,93 @@
+package persisted
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// エラーコード
+const (
+	ErrCodeNotFound   = "PERSISTED_QUERY_NOT_FOUND"
+	ErrCodeNotAllowed = "PERSISTED_QUERY_NOT_ALLOWED"
+)
+
+// AllowList マニフェストに登録されたクエリだけを実行する gqlgen 拡張
+//
+// APQ 形式（extensions.persistedQuery.sha256Hash）のリクエストはハッシュから
+// クエリを引き、クエリ本文を送ってきた場合もハッシュがマニフェストにあるものだけ許可する。
+// 未登録のクエリを APQ で登録することはできない。
+type AllowList struct {
+	Manifest *Manifest
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = AllowList{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (AllowList) ExtensionName() string {
+	return "PersistedQueryAllowList"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (a AllowList) Validate(graphql.ExecutableSchema) error {
+	if a.Manifest == nil {
+		return errors.New("PersistedQueryAllowList.Manifest can not be nil")
+	}
+	return nil
+}
+
+// MutateOperationParameters graphql.OperationParameterMutator の実装
+func (a AllowList) MutateOperationParameters(_ context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	hash, err := requestedHash(rawParams)
+	if err != nil {
+		return err
+	}
+
+	if rawParams.Query == "" {
+		query, ok := a.Manifest.Get(hash)
+		if !ok {
+			err := gqlerror.Errorf("PersistedQueryNotFound")
+			errcode.Set(err, ErrCodeNotFound)
+			return err
+		}
+		rawParams.Query = query
+		return nil
+	}
+
+	if Hash(rawParams.Query) != hash {
+		return gqlerror.Errorf("provided APQ hash does not match query")
+	}
+	if _, ok := a.Manifest.Get(hash); !ok {
+		err := gqlerror.Errorf("operation is not in the persisted query allow-list")
+		errcode.Set(err, ErrCodeNotAllowed)
+		return err
+	}
+	return nil
+}
+
+// requestedHash リクエストで指定されたハッシュ。APQ の拡張がない場合はクエリ本文から計算する
+func requestedHash(rawParams *graphql.RawParams) (string, *gqlerror.Error) {
+	ext, ok := rawParams.Extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		if rawParams.Extensions["persistedQuery"] != nil {
+			return "", gqlerror.Errorf("invalid APQ extension data")
+		}
+		return Hash(rawParams.Query), nil
+	}
+
+	if fmt.Sprint(ext["version"]) != "1" {
+		return "", gqlerror.Errorf("unsupported APQ version")
+	}
+	hash, _ := ext["sha256Hash"].(string)
+	if hash == "" {
+		return "", gqlerror.Errorf("invalid APQ extension data")
+	}
+	return hash, nil
+}
@@ -0,0 +1,119 @@
+package persisted
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+func apq(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+	}
+}
+
+func TestAllowList(t *testing.T) {
+	a := AllowList{Manifest: testManifest(t)}
+
+	tests := []struct {
+		name       string
+		params     graphql.RawParams
+		wantQuery  string
+		wantErr    bool
+		wantCode   string
+		wantErrMsg string
+	}{
+		{
+			name:      "hash only",
+			params:    graphql.RawParams{Extensions: apq(Hash(usersQuery))},
+			wantQuery: usersQuery,
+		},
+		{
+			name:      "hash and query",
+			params:    graphql.RawParams{Query: userQuery, Extensions: apq(Hash(userQuery))},
+			wantQuery: userQuery,
+		},
+		{
+			name:      "query without APQ extension",
+			params:    graphql.RawParams{Query: usersQuery},
+			wantQuery: usersQuery,
+		},
+		{
+			name:     "unknown hash",
+			params:   graphql.RawParams{Extensions: apq(Hash(`{ __typename }`))},
+			wantErr:  true,
+			wantCode: ErrCodeNotFound,
+		},
+		{
+			name:     "unknown query",
+			params:   graphql.RawParams{Query: `{ __typename }`},
+			wantErr:  true,
+			wantCode: ErrCodeNotAllowed,
+		},
+		{
+			name:     "unknown query can not be registered with APQ",
+			params:   graphql.RawParams{Query: `{ __typename }`, Extensions: apq(Hash(`{ __typename }`))},
+			wantErr:  true,
+			wantCode: ErrCodeNotAllowed,
+		},
+		{
+			name:       "query does not match a registered hash",
+			params:     graphql.RawParams{Query: `{ __typename }`, Extensions: apq(Hash(usersQuery))},
+			wantErr:    true,
+			wantErrMsg: "provided APQ hash does not match query",
+		},
+		{
+			name: "unsupported version",
+			params: graphql.RawParams{Extensions: map[string]interface{}{
+				"persistedQuery": map[string]interface{}{"version": 2, "sha256Hash": Hash(usersQuery)},
+			}},
+			wantErr:    true,
+			wantErrMsg: "unsupported APQ version",
+		},
+		{
+			name:       "invalid extension",
+			params:     graphql.RawParams{Extensions: map[string]interface{}{"persistedQuery": "x"}},
+			wantErr:    true,
+			wantErrMsg: "invalid APQ extension data",
+		},
+		{
+			name: "missing hash",
+			params: graphql.RawParams{Extensions: map[string]interface{}{
+				"persistedQuery": map[string]interface{}{"version": 1},
+			}},
+			wantErr:    true,
+			wantErrMsg: "invalid APQ extension data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			err := a.MutateOperationParameters(context.Background(), &params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MutateOperationParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tt.wantCode != "" && err.Extensions["code"] != tt.wantCode {
+					t.Errorf("code = %v, want %s", err.Extensions["code"], tt.wantCode)
+				}
+				if tt.wantErrMsg != "" && err.Message != tt.wantErrMsg {
+					t.Errorf("message = %q, want %q", err.Message, tt.wantErrMsg)
+				}
+				return
+			}
+			if params.Query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", params.Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestAllowListValidate(t *testing.T) {
+	if err := (AllowList{}).Validate(nil); err == nil {
+		t.Error("Validate() without a manifest: want error")
+	}
+	if err := (AllowList{Manifest: &Manifest{}}).Validate(nil); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package persisted
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+)
+
+// Cache APQ 用のキャッシュ。マニフェストのクエリは LRU から追い出されない
+type Cache struct {
+	manifest *Manifest
+	lru      *lru.LRU[string]
+}
+
+var _ graphql.Cache[string] = (*Cache)(nil)
+
+// NewCache Cache のコンストラクタ
+func NewCache(size int, manifest *Manifest) *Cache {
+	return &Cache{manifest: manifest, lru: lru.New[string](size)}
+}
+
+// Get graphql.Cache の実装
+func (c *Cache) Get(ctx context.Context, hash string) (string, bool) {
+	if q, ok := c.manifest.Get(hash); ok {
+		return q, true
+	}
+	return c.lru.Get(ctx, hash)
+}
+
+// Add graphql.Cache の実装
+func (c *Cache) Add(ctx context.Context, hash, query string) {
+	if _, ok := c.manifest.Get(hash); ok {
+		return
+	}
+	c.lru.Add(ctx, hash, query)
+}
@@ -0,0 +1,75 @@
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Manifest 事前登録されたクエリ（SHA-256 ハッシュ → クエリ本文）
+type Manifest struct {
+	queries map[string]string
+}
+
+// manifestFile マニフェストファイルの形式
+//
+// Apollo の persisted query manifest 形式
+//
+//	{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [{"id": "<sha256>", "name": "...", "body": "query ..."}]}
+//
+// と、ハッシュをキーにした単純なオブジェクト {"<sha256>": "query ..."} の両方を受け付ける。
+type manifestFile struct {
+	Format     string `json:"format"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// LoadManifest マニフェストファイルを読み込む
+//
+// 各クエリのハッシュを検証し、一致しないものがあればエラーにする。
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted query manifest: %v", err)
+	}
+
+	queries := make(map[string]string)
+
+	var file manifestFile
+	if err := json.Unmarshal(data, &file); err == nil && file.Format != "" {
+		for _, op := range file.Operations {
+			queries[op.ID] = op.Body
+		}
+	} else if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted query manifest: %v", err)
+	}
+
+	for hash, query := range queries {
+		if Hash(query) != hash {
+			return nil, fmt.Errorf("persisted query manifest: hash %s does not match its query", hash)
+		}
+	}
+	return &Manifest{queries: queries}, nil
+}
+
+// Get ハッシュに対応するクエリ
+func (m *Manifest) Get(hash string) (string, bool) {
+	q, ok := m.queries[hash]
+	return q, ok
+}
+
+// Len 登録されているクエリの件数
+func (m *Manifest) Len() int {
+	return len(m.queries)
+}
+
+// Hash クエリの SHA-256 ハッシュ（APQ の sha256Hash と同じ形式）
+func Hash(query string) string {
+	b := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,132 @@
+package persisted
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	usersQuery = `query Users { users { id } }`
+	userQuery  = `query User($id: ID!) { user(id: $id) { id } }`
+)
+
+// writeManifest 一時ディレクトリにマニフェストを書き出す
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testManifest usersQuery と userQuery を登録したマニフェスト
+func testManifest(t *testing.T) *Manifest {
+	t.Helper()
+	m, err := LoadManifest(writeManifest(t, fmt.Sprintf(`{%q: %q, %q: %q}`,
+		Hash(usersQuery), usersQuery, Hash(userQuery), userQuery)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHash(t *testing.T) {
+	// echo -n '{ __typename }' | sha256sum
+	const want = "7f56e67dd21ab3f30d1ff8b7bed08893f0a0db86449836189b361dd1e56ddb4b"
+	if got := Hash(`{ __typename }`); got != want {
+		t.Errorf("Hash() = %s, want %s", got, want)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "hash map",
+			content: fmt.Sprintf(`{%q: %q}`, Hash(usersQuery), usersQuery),
+			want:    map[string]string{Hash(usersQuery): usersQuery},
+		},
+		{
+			name: "apollo manifest",
+			content: fmt.Sprintf(`{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [
+				{"id": %q, "name": "Users", "body": %q},
+				{"id": %q, "name": "User", "body": %q}
+			]}`, Hash(usersQuery), usersQuery, Hash(userQuery), userQuery),
+			want: map[string]string{Hash(usersQuery): usersQuery, Hash(userQuery): userQuery},
+		},
+		{name: "empty", content: `{}`, want: map[string]string{}},
+		{
+			name:    "hash mismatch",
+			content: fmt.Sprintf(`{%q: %q}`, Hash(usersQuery), userQuery),
+			wantErr: true,
+		},
+		{
+			name: "hash mismatch in apollo manifest",
+			content: fmt.Sprintf(`{"format": "apollo-persisted-query-manifest", "operations": [{"id": %q, "body": %q}]}`,
+				Hash(userQuery), usersQuery),
+			wantErr: true,
+		},
+		{name: "invalid json", content: `[`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := LoadManifest(writeManifest(t, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m.Len() != len(tt.want) {
+				t.Errorf("Len() = %d, want %d", m.Len(), len(tt.want))
+			}
+			for hash, want := range tt.want {
+				if got, ok := m.Get(hash); !ok || got != want {
+					t.Errorf("Get(%s) = %q, %v, want %q", hash, got, ok, want)
+				}
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadManifest(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+			t.Error("LoadManifest() want error")
+		}
+	})
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(1, testManifest(t))
+
+	if got, ok := c.Get(ctx, Hash(usersQuery)); !ok || got != usersQuery {
+		t.Errorf("Get(manifest query) = %q, %v", got, ok)
+	}
+
+	// LRU は 1 件なので、2 件目を追加すると 1 件目が追い出される
+	c.Add(ctx, "a", "query A { a }")
+	c.Add(ctx, "b", "query B { b }")
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("Get(a) was not evicted")
+	}
+	if got, ok := c.Get(ctx, "b"); !ok || got != "query B { b }" {
+		t.Errorf("Get(b) = %q, %v", got, ok)
+	}
+
+	// マニフェストのクエリは LRU を使わないので、追い出されない
+	c.Add(ctx, Hash(userQuery), userQuery)
+	if _, ok := c.Get(ctx, "b"); !ok {
+		t.Error("adding a manifest query evicted b")
+	}
+	if _, ok := c.Get(ctx, Hash(usersQuery)); !ok {
+		t.Error("manifest query was evicted")
+	}
+}
@@ -0,0 +1,79 @@
+package persisted
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+// DefaultCacheSize APQ の LRU キャッシュのデフォルト件数
+const DefaultCacheSize = 1000
+
+// Config 永続化クエリの設定
+type Config struct {
+	CacheSize    int    // APQ の LRU キャッシュの件数
+	ManifestPath string // 事前登録するクエリのマニフェスト（空の場合は読み込まない）
+	AllowList    bool   // マニフェストに登録されたクエリだけを実行する
+}
+
+// ConfigFromEnv 環境変数から設定を読み込む
+//
+//	PERSISTED_QUERIES_CACHE_SIZE  APQ のキャッシュ件数（デフォルト 1000）
+//	PERSISTED_QUERIES_MANIFEST    マニフェストファイルのパス
+//	PERSISTED_QUERIES_ALLOWLIST   true の場合、マニフェスト外のクエリを拒否する
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		CacheSize:    DefaultCacheSize,
+		ManifestPath: strings.TrimSpace(os.Getenv("PERSISTED_QUERIES_MANIFEST")),
+	}
+
+	if s := os.Getenv("PERSISTED_QUERIES_CACHE_SIZE"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("PERSISTED_QUERIES_CACHE_SIZE: invalid size %q", s)
+		}
+		cfg.CacheSize = n
+	}
+
+	if s := os.Getenv("PERSISTED_QUERIES_ALLOWLIST"); s != "" {
+		allow, err := strconv.ParseBool(s)
+		if err != nil {
+			return cfg, fmt.Errorf("PERSISTED_QUERIES_ALLOWLIST: invalid bool %q", s)
+		}
+		cfg.AllowList = allow
+	}
+
+	if cfg.AllowList && cfg.ManifestPath == "" {
+		return cfg, fmt.Errorf("PERSISTED_QUERIES_ALLOWLIST requires PERSISTED_QUERIES_MANIFEST")
+	}
+	return cfg, nil
+}
+
+// Extension 設定に応じた gqlgen 拡張
+//
+// 通常は APQ（マニフェストのクエリは常にキャッシュ済みとして扱う）、
+// AllowList が有効な場合はマニフェストに登録されたクエリだけを実行する拡張を返す。
+func Extension(cfg Config) (graphql.HandlerExtension, *Manifest, error) {
+	manifest := &Manifest{}
+	if cfg.ManifestPath != "" {
+		m, err := LoadManifest(cfg.ManifestPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifest = m
+	}
+
+	if cfg.AllowList {
+		return AllowList{Manifest: manifest}, manifest, nil
+	}
+
+	size := cfg.CacheSize
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return extension.AutomaticPersistedQuery{Cache: NewCache(size, manifest)}, manifest, nil
+}
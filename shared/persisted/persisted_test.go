@@ -0,0 +1,86 @@
+package persisted
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		cacheSize string
+		manifest  string
+		allowList string
+		want      Config
+		wantErr   bool
+	}{
+		{name: "defaults", want: Config{CacheSize: DefaultCacheSize}},
+		{
+			name:      "all settings",
+			cacheSize: "50",
+			manifest:  " manifest.json ",
+			allowList: "true",
+			want:      Config{CacheSize: 50, ManifestPath: "manifest.json", AllowList: true},
+		},
+		{name: "invalid cache size", cacheSize: "0", wantErr: true},
+		{name: "invalid allow-list flag", allowList: "yes please", wantErr: true},
+		{name: "allow-list without a manifest", allowList: "true", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERSISTED_QUERIES_CACHE_SIZE", tt.cacheSize)
+			t.Setenv("PERSISTED_QUERIES_MANIFEST", tt.manifest)
+			t.Setenv("PERSISTED_QUERIES_ALLOWLIST", tt.allowList)
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtension(t *testing.T) {
+	path := writeManifest(t, fmt.Sprintf(`{%q: %q}`, Hash(usersQuery), usersQuery))
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantExt string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "APQ without a manifest", cfg: Config{}, wantExt: "AutomaticPersistedQuery"},
+		{name: "APQ with a manifest", cfg: Config{ManifestPath: path}, wantExt: "AutomaticPersistedQuery", wantLen: 1},
+		{name: "allow-list", cfg: Config{ManifestPath: path, AllowList: true}, wantExt: "PersistedQueryAllowList", wantLen: 1},
+		{name: "missing manifest", cfg: Config{ManifestPath: filepath.Join(t.TempDir(), "missing.json")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext, manifest, err := Extension(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extension() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ext.ExtensionName() != tt.wantExt {
+				t.Errorf("ExtensionName() = %s, want %s", ext.ExtensionName(), tt.wantExt)
+			}
+			if manifest.Len() != tt.wantLen {
+				t.Errorf("manifest.Len() = %d, want %d", manifest.Len(), tt.wantLen)
+			}
+			if apq, ok := ext.(extension.AutomaticPersistedQuery); ok {
+				if _, ok := apq.Cache.(*Cache); !ok {
+					t.Errorf("APQ cache = %T, want *Cache", apq.Cache)
+				}
+			}
+		})
+	}
+}
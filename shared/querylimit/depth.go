@@ -0,0 +1,91 @@
+package querylimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrCodeDepthLimitExceeded 深さの上限を超えた場合のエラーコード
+const ErrCodeDepthLimitExceeded = "DEPTH_LIMIT_EXCEEDED"
+
+// DepthLimit フィールドのネストの深さを制限する gqlgen 拡張
+//
+// フラグメントは展開して数える。イントロスペクション（__schema など）は対象外。
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (d DepthLimit) Validate(graphql.ExecutableSchema) error {
+	if d.Max <= 0 {
+		return fmt.Errorf("depth limit must be positive, got %d", d.Max)
+	}
+	return nil
+}
+
+// MutateOperationContext graphql.OperationContextMutator の実装
+func (d DepthLimit) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if opCtx.Operation == nil {
+		return nil
+	}
+
+	depth := selectionDepth(opCtx.Operation.SelectionSet, opCtx.Doc, map[string]bool{})
+	if depth > d.Max {
+		return &gqlerror.Error{
+			Message: fmt.Sprintf("operation has depth %d, which exceeds the limit of %d", depth, d.Max),
+			Extensions: map[string]interface{}{
+				"code":     ErrCodeDepthLimitExceeded,
+				"depth":    depth,
+				"maxDepth": d.Max,
+			},
+		}
+	}
+	return nil
+}
+
+// selectionDepth 選択セットの最大の深さ。visiting は展開中のフラグメント（循環防止）
+func selectionDepth(set ast.SelectionSet, doc *ast.QueryDocument, visiting map[string]bool) int {
+	max := 0
+	for _, sel := range set {
+		var depth int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(sel.SelectionSet, doc, visiting)
+		case *ast.InlineFragment:
+			depth = selectionDepth(sel.SelectionSet, doc, visiting)
+		case *ast.FragmentSpread:
+			if doc == nil || visiting[sel.Name] {
+				continue
+			}
+			def := doc.Fragments.ForName(sel.Name)
+			if def == nil {
+				continue
+			}
+			visiting[sel.Name] = true
+			depth = selectionDepth(def.SelectionSet, doc, visiting)
+			delete(visiting, sel.Name)
+		}
+		if depth > max {
+			max = depth
+		}
+	}
+	return max
+}
@@ -0,0 +1,66 @@
+package querylimit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+)
+
+// デフォルトの上限
+const (
+	DefaultMaxComplexity = 5000
+	DefaultMaxDepth      = 10
+)
+
+// Config GraphQL クエリの上限（0 以下で無効）
+type Config struct {
+	MaxComplexity int
+	MaxDepth      int
+}
+
+// ConfigFromEnv 環境変数から上限を読み込む
+//
+//	GRAPHQL_MAX_COMPLEXITY  複雑度の上限（デフォルト 5000）
+//	GRAPHQL_MAX_DEPTH       ネストの深さの上限（デフォルト 10）
+func ConfigFromEnv() (Config, error) {
+	def := Config{MaxComplexity: DefaultMaxComplexity, MaxDepth: DefaultMaxDepth}
+	cfg := def
+	var err error
+	if cfg.MaxComplexity, err = intFromEnv("GRAPHQL_MAX_COMPLEXITY", def.MaxComplexity); err != nil {
+		return def, err
+	}
+	if cfg.MaxDepth, err = intFromEnv("GRAPHQL_MAX_DEPTH", def.MaxDepth); err != nil {
+		return def, err
+	}
+	return cfg, nil
+}
+
+func intFromEnv(key string, def int) (int, error) {
+	s := os.Getenv(key)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def, fmt.Errorf("%s: invalid integer %q", key, s)
+	}
+	return n, nil
+}
+
+// Extensions 設定に応じた gqlgen 拡張
+//
+// 複雑度はスキーマの ComplexityRoot に従って計算され、超過時は
+// COMPLEXITY_LIMIT_EXCEEDED、深さの超過時は DEPTH_LIMIT_EXCEEDED のエラーになる。
+func Extensions(cfg Config) []graphql.HandlerExtension {
+	var exts []graphql.HandlerExtension
+	if cfg.MaxComplexity > 0 {
+		exts = append(exts, extension.FixedComplexityLimit(cfg.MaxComplexity))
+	}
+	if cfg.MaxDepth > 0 {
+		exts = append(exts, DepthLimit{Max: cfg.MaxDepth})
+	}
+	return exts
+}
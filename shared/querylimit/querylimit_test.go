@@ -0,0 +1,150 @@
+package querylimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const testSchema = `
+type Query { user(id: ID!): User }
+type User { id: ID! friends: [User!]! profile: Profile }
+type Profile { name: String }
+`
+
+func TestDepthLimit(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphqls", Input: testSchema})
+
+	tests := []struct {
+		name      string
+		query     string
+		wantDepth int
+	}{
+		{name: "flat", query: `{ user(id: "1") { id } }`, wantDepth: 2},
+		{name: "nested", query: `{ user(id: "1") { friends { friends { profile { name } } } } }`, wantDepth: 5},
+		{name: "deepest branch counts", query: `{ user(id: "1") { id friends { id } } }`, wantDepth: 3},
+		{
+			name:      "fragments are expanded",
+			query:     `{ user(id: "1") { ...F } } fragment F on User { friends { friends { id } } }`,
+			wantDepth: 4,
+		},
+		{
+			name:      "inline fragments do not add depth",
+			query:     `{ user(id: "1") { ... on User { friends { id } } } }`,
+			wantDepth: 3,
+		},
+		{
+			name:      "introspection is ignored",
+			query:     `{ __schema { types { fields { type { name } } } } user(id: "1") { id } }`,
+			wantDepth: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			if errs != nil {
+				t.Fatalf("failed to load query: %v", errs)
+			}
+			opCtx := &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0]}
+
+			if err := (DepthLimit{Max: tt.wantDepth}).MutateOperationContext(context.Background(), opCtx); err != nil {
+				t.Errorf("Max %d: unexpected error: %v", tt.wantDepth, err)
+			}
+			err := (DepthLimit{Max: tt.wantDepth - 1}).MutateOperationContext(context.Background(), opCtx)
+			if err == nil {
+				t.Fatalf("Max %d: want error", tt.wantDepth-1)
+			}
+			if err.Extensions["code"] != ErrCodeDepthLimitExceeded || err.Extensions["depth"] != tt.wantDepth {
+				t.Errorf("extensions = %v, want code %s and depth %d", err.Extensions, ErrCodeDepthLimitExceeded, tt.wantDepth)
+			}
+		})
+	}
+}
+
+func TestSelectionDepthRecursiveFragment(t *testing.T) {
+	// 検証を通らない循環したフラグメントでも止まること
+	doc := &ast.QueryDocument{Fragments: ast.FragmentDefinitionList{{
+		Name: "F",
+		SelectionSet: ast.SelectionSet{&ast.Field{
+			Name:         "friends",
+			SelectionSet: ast.SelectionSet{&ast.FragmentSpread{Name: "F"}},
+		}},
+	}}}
+	set := ast.SelectionSet{&ast.FragmentSpread{Name: "F"}}
+
+	if got := selectionDepth(set, doc, map[string]bool{}); got != 1 {
+		t.Errorf("selectionDepth() = %d, want 1", got)
+	}
+}
+
+func TestDepthLimitValidate(t *testing.T) {
+	for _, max := range []int{0, -1} {
+		if err := (DepthLimit{Max: max}).Validate(nil); err == nil {
+			t.Errorf("Validate() with Max %d: want error", max)
+		}
+	}
+	if err := (DepthLimit{Max: 1}).Validate(nil); err != nil {
+		t.Errorf("Validate() with Max 1: %v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	def := Config{MaxComplexity: DefaultMaxComplexity, MaxDepth: DefaultMaxDepth}
+	tests := []struct {
+		name       string
+		complexity string
+		depth      string
+		want       Config
+		wantErr    bool
+	}{
+		{name: "defaults", want: def},
+		{name: "overrides", complexity: "100", depth: "0", want: Config{MaxComplexity: 100, MaxDepth: 0}},
+		{name: "invalid complexity", complexity: "many", want: def, wantErr: true},
+		{name: "invalid depth", complexity: "100", depth: "deep", want: def, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRAPHQL_MAX_COMPLEXITY", tt.complexity)
+			t.Setenv("GRAPHQL_MAX_DEPTH", tt.depth)
+
+			got, err := ConfigFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want []string
+	}{
+		{cfg: Config{MaxComplexity: 10, MaxDepth: 3}, want: []string{"ComplexityLimit", "DepthLimit"}},
+		{cfg: Config{MaxDepth: 3}, want: []string{"DepthLimit"}},
+		{cfg: Config{MaxComplexity: -1}, want: nil},
+	}
+	for _, tt := range tests {
+		exts := Extensions(tt.cfg)
+		var got []string
+		for _, ext := range exts {
+			got = append(got, ext.ExtensionName())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Extensions(%+v) = %v, want %v", tt.cfg, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Extensions(%+v) = %v, want %v", tt.cfg, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// GraphQLExtension ルートフィールド（Query / Mutation のフィールド）ごとの制限を適用する gqlgen 拡張
+//
+// オペレーション名はクライアントが自由に付けられるため、実際に実行される
+// ルートフィールド名でルールを選ぶ。Limiter.Middleware の内側で使うこと。
+type GraphQLExtension struct {
+	Limiter *Limiter
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = GraphQLExtension{}
+
+// ExtensionName graphql.HandlerExtension の実装
+func (GraphQLExtension) ExtensionName() string {
+	return "RateLimit"
+}
+
+// Validate graphql.HandlerExtension の実装
+func (GraphQLExtension) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext graphql.OperationContextMutator の実装
+func (e GraphQLExtension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	state := stateFromContext(ctx)
+	if state == nil || opCtx.Operation == nil || len(e.Limiter.config.Operations) == 0 {
+		return nil
+	}
+
+	for _, field := range rootFields(opCtx.Operation.SelectionSet, opCtx.Doc) {
+		rule, ok := e.Limiter.config.Operations[field.Name]
+		if !ok {
+			continue
+		}
+
+		d := e.Limiter.allow(ctx, "op:"+field.Name+":"+state.key, rule)
+		if !d.Allowed {
+			state.markLimited(d.RetryAfter)
+			return &gqlerror.Error{
+				Message: "rate limit exceeded for " + field.Name,
+				Extensions: map[string]interface{}{
+					"code":       "RATE_LIMITED",
+					"retryAfter": retryAfterSeconds(d.RetryAfter),
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// rootFields ルートのフィールドを列挙する（フラグメント経由のものも含む）
+func rootFields(set ast.SelectionSet, doc *ast.QueryDocument) []*ast.Field {
+	var fields []*ast.Field
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			fields = append(fields, sel)
+		case *ast.InlineFragment:
+			fields = append(fields, rootFields(sel.SelectionSet, doc)...)
+		case *ast.FragmentSpread:
+			if doc == nil {
+				continue
+			}
+			if def := doc.Fragments.ForName(sel.Name); def != nil {
+				fields = append(fields, rootFields(def.SelectionSet, doc)...)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestState リクエスト単位の状態。GraphQL 拡張で制限された場合に 429 へ書き換える
+type requestState struct {
+	key string
+
+	mu         sync.Mutex
+	limited    bool
+	retryAfter time.Duration
+}
+
+func (s *requestState) markLimited(retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.limited || retryAfter > s.retryAfter {
+		s.retryAfter = retryAfter
+	}
+	s.limited = true
+}
+
+func (s *requestState) limitedFor() (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limited, s.retryAfter
+}
+
+type stateContextKey struct{}
+
+func stateFromContext(ctx context.Context) *requestState {
+	s, _ := ctx.Value(stateContextKey{}).(*requestState)
+	return s
+}
+
+// Middleware ルートごとの制限を適用する HTTP ミドルウェア
+//
+// 制限を超えたリクエストには 429 と Retry-After ヘッダーを返す。
+// GraphQL 拡張が使う呼び出し元のキーもここでコンテキストに設定する。
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &requestState{key: l.key(r)}
+		ctx := context.WithValue(r.Context(), stateContextKey{}, state)
+
+		if rule, ok := l.config.Routes[r.URL.Path]; ok {
+			d := l.allow(ctx, "route:"+r.URL.Path+":"+state.key, rule)
+			if !d.Allowed {
+				writeTooManyRequests(w, d.RetryAfter)
+				return
+			}
+		}
+
+		next.ServeHTTP(&limitedWriter{ResponseWriter: w, state: state}, r.WithContext(ctx))
+	})
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
+
+// limitedWriter GraphQL 拡張で制限された場合にステータスを 429 に書き換える
+type limitedWriter struct {
+	http.ResponseWriter
+	state       *requestState
+	wroteHeader bool
+}
+
+func (w *limitedWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if limited, retryAfter := w.state.limitedFor(); limited {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+		status = http.StatusTooManyRequests
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *limitedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush http.Flusher の委譲
+func (w *limitedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack http.Hijacker の委譲（WebSocket 用）
+func (w *limitedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap http.ResponseController 用に元の ResponseWriter を返す
+func (w *limitedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
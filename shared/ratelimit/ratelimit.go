@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule トークンバケットの設定
+type Rule struct {
+	Rate  float64 // 1秒あたりに補充されるトークン数
+	Burst int     // バケットの容量
+}
+
+// ParseRule "60/m" や "10/m:20"（20 はバースト）形式のルールを解析
+//
+// 単位は s / m / h。バーストを省略した場合は期間あたりの回数と同じ値になる。
+func ParseRule(s string) (Rule, error) {
+	spec, burstStr, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+
+	countStr, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit rule %q", s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit count in %q", s)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Rule{}, fmt.Errorf("invalid rate limit unit in %q", s)
+	}
+
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return Rule{}, fmt.Errorf("invalid rate limit burst in %q", s)
+		}
+	}
+
+	return Rule{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+// Config ルート・GraphQL オペレーションごとの制限
+type Config struct {
+	Routes     map[string]Rule // HTTP パス → ルール
+	Operations map[string]Rule // GraphQL のルートフィールド名 → ルール
+}
+
+// ConfigFromEnv 環境変数でルールを上書き・追加する
+//
+//	RATE_LIMIT_ROUTES      "/graphql=300/m,/api/auth/delete-user=5/m"
+//	RATE_LIMIT_OPERATIONS  "getAvatarUploadUrl=10/m:5"
+//
+// ルールに "off" を指定するとその対象の制限を解除する。
+func ConfigFromEnv(base Config) (Config, error) {
+	cfg := Config{
+		Routes:     copyRules(base.Routes),
+		Operations: copyRules(base.Operations),
+	}
+	if err := parseRules(os.Getenv("RATE_LIMIT_ROUTES"), cfg.Routes); err != nil {
+		return base, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+	}
+	if err := parseRules(os.Getenv("RATE_LIMIT_OPERATIONS"), cfg.Operations); err != nil {
+		return base, fmt.Errorf("RATE_LIMIT_OPERATIONS: %w", err)
+	}
+	return cfg, nil
+}
+
+func parseRules(s string, into map[string]Rule) error {
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid entry %q", entry)
+		}
+		name = strings.TrimSpace(name)
+		if strings.TrimSpace(spec) == "off" {
+			delete(into, name)
+			continue
+		}
+		rule, err := ParseRule(spec)
+		if err != nil {
+			return err
+		}
+		into[name] = rule
+	}
+	return nil
+}
+
+func copyRules(m map[string]Rule) map[string]Rule {
+	out := make(map[string]Rule, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// KeyFunc 呼び出し元を識別するキーを返す
+type KeyFunc func(r *http.Request) string
+
+// Limiter ルート・オペレーション単位のレート制限
+type Limiter struct {
+	store  Store
+	config Config
+	key    KeyFunc
+}
+
+// NewLimiter Limiter のコンストラクタ。key が nil の場合は IP アドレスで識別する
+func NewLimiter(store Store, config Config, key KeyFunc) *Limiter {
+	if key == nil {
+		key = ClientIP
+	}
+	return &Limiter{store: store, config: config, key: key}
+}
+
+// allow ルールに従ってトークンを取得する。ストアのエラー時は制限しない
+func (l *Limiter) allow(ctx context.Context, key string, rule Rule) Decision {
+	d, err := l.store.Take(ctx, key, rule)
+	if err != nil {
+		slog.ErrorContext(ctx, "rate limit store failed, allowing request", slog.String("key", key), slog.Any("error", err))
+		return Decision{Allowed: true}
+	}
+	if !d.Allowed {
+		slog.WarnContext(ctx, "rate limit exceeded", slog.String("key", key), slog.Duration("retry_after", d.RetryAfter))
+	}
+	return d
+}
+
+// ClientIP クライアントの IP アドレス
+//
+// Cloud Run のフロントエンドが X-Forwarded-For の末尾に接続元を追加するため、
+// クライアントが自由に設定できる先頭ではなく末尾の値を使う。
+func ClientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+			return "ip:" + ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// retryAfterSeconds Retry-After ヘッダー用に切り上げた秒数
+func retryAfterSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
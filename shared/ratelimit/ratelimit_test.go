@@ -0,0 +1,215 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Rule
+		wantErr bool
+	}{
+		{s: "60/m", want: Rule{Rate: 1, Burst: 60}},
+		{s: "10/s:20", want: Rule{Rate: 10, Burst: 20}},
+		{s: " 3600/h ", want: Rule{Rate: 1, Burst: 3600}},
+		{s: "60", wantErr: true},
+		{s: "0/m", wantErr: true},
+		{s: "60/d", wantErr: true},
+		{s: "60/m:0", wantErr: true},
+		{s: "60/m:x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseRule(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	base := Config{
+		Routes:     map[string]Rule{"/graphql": {Rate: 5, Burst: 60}},
+		Operations: map[string]Rule{"createUser": {Rate: 1, Burst: 5}, "importUsers": {Rate: 1, Burst: 2}},
+	}
+	tests := []struct {
+		name       string
+		routes     string
+		operations string
+		want       Config
+		wantErr    bool
+	}{
+		{name: "no overrides", want: base},
+		{
+			name:       "override, add and disable rules",
+			routes:     "/graphql=1/s:2, /api/auth/delete-user=5/m",
+			operations: "importUsers=off",
+			want: Config{
+				Routes:     map[string]Rule{"/graphql": {Rate: 1, Burst: 2}, "/api/auth/delete-user": {Rate: 5.0 / 60, Burst: 5}},
+				Operations: map[string]Rule{"createUser": {Rate: 1, Burst: 5}},
+			},
+		},
+		{name: "invalid entry", routes: "/graphql", want: base, wantErr: true},
+		{name: "invalid rule", operations: "createUser=fast", want: base, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RATE_LIMIT_ROUTES", tt.routes)
+			t.Setenv("RATE_LIMIT_OPERATIONS", tt.operations)
+
+			got, err := ConfigFromEnv(base)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if len(base.Operations) != 2 {
+		t.Errorf("ConfigFromEnv modified the base config: %+v", base)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{name: "remote address", remoteAddr: "192.0.2.1:1234", want: "ip:192.0.2.1"},
+		{name: "remote address without port", remoteAddr: "192.0.2.1", want: "ip:192.0.2.1"},
+		{name: "last forwarded address", remoteAddr: "10.0.0.1:1234", xff: "203.0.113.9, 198.51.100.7", want: "ip:198.51.100.7"},
+		{name: "empty forwarded address", remoteAddr: "10.0.0.1:1234", xff: "203.0.113.9, ", want: "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 1},
+		{100 * time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+	}
+	for _, tt := range tests {
+		if got := retryAfterSeconds(tt.d); got != tt.want {
+			t.Errorf("retryAfterSeconds(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	l := NewLimiter(NewMemoryStore(), Config{Routes: map[string]Rule{"/api": {Rate: 0.1, Burst: 1}}}, nil)
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		wantCode   int
+	}{
+		{name: "first request", path: "/api", remoteAddr: "192.0.2.1:1", wantCode: http.StatusOK},
+		{name: "second request is limited", path: "/api", remoteAddr: "192.0.2.1:2", wantCode: http.StatusTooManyRequests},
+		{name: "other clients are not limited", path: "/api", remoteAddr: "192.0.2.2:1", wantCode: http.StatusOK},
+		{name: "routes without rules are not limited", path: "/healthz", remoteAddr: "192.0.2.1:3", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "10" {
+				t.Errorf("Retry-After = %q, want 10", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestGraphQLExtension(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphqls", Input: `
+type Query { users: [String!]! }
+type Mutation { importUsers: Boolean! }
+`})
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{name: "fields without rules", query: `{ users }`, wantCode: http.StatusOK},
+		{name: "first call", query: `mutation { importUsers }`, wantCode: http.StatusOK},
+		{name: "aliases and operation names do not bypass the limit", query: `mutation Other { a: importUsers }`, wantCode: http.StatusTooManyRequests},
+		{name: "fragments do not bypass the limit", query: `mutation { ...F } fragment F on Mutation { importUsers }`, wantCode: http.StatusTooManyRequests},
+	}
+
+	l := NewLimiter(NewMemoryStore(), Config{Operations: map[string]Rule{"importUsers": {Rate: 0.1, Burst: 1}}}, nil)
+	ext := GraphQLExtension{Limiter: l}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			if errs != nil {
+				t.Fatalf("failed to load query: %v", errs)
+			}
+
+			var gqlErr error
+			h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				opCtx := &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0]}
+				if err := ext.MutateOperationContext(r.Context(), opCtx); err != nil {
+					gqlErr = err
+				}
+				w.Write([]byte("{}"))
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", nil).WithContext(context.Background()))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if limited := tt.wantCode == http.StatusTooManyRequests; limited != (gqlErr != nil) {
+				t.Errorf("GraphQL error = %v, want limited %v", gqlErr, limited)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Decision トークン取得の結果
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // 拒否された場合、次のトークンが補充されるまでの時間
+}
+
+// Store トークンバケットの保存先
+//
+// 複数インスタンスで制限を共有する場合は Redis などを使った実装に差し替える。
+type Store interface {
+	// Take key のバケットからトークンを1つ取得する
+	Take(ctx context.Context, key string, rule Rule) (Decision, error)
+}
+
+// bucket トークンバケットの状態
+type bucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time // この時刻以降はバケットが満タンになる
+}
+
+// MemoryStore プロセス内メモリに保存する Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+
+	// 一定回数ごとに満タンになったバケットを掃除する
+	calls      int
+	sweepEvery int
+}
+
+// NewMemoryStore MemoryStore のコンストラクタ
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:    make(map[string]*bucket),
+		now:        time.Now,
+		sweepEvery: 1000,
+	}
+}
+
+// Take Store の実装
+func (s *MemoryStore) Take(_ context.Context, key string, rule Rule) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.calls++
+	if s.calls%s.sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	// 経過時間分のトークンを補充
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+elapsed*rule.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+		return Decision{Allowed: false, RetryAfter: wait}, nil
+	}
+
+	b.tokens--
+	b.fullAt = now.Add(time.Duration((float64(rule.Burst) - b.tokens) / rule.Rate * float64(time.Second)))
+	return Decision{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// sweep 満タンまで回復したバケットを削除（再作成しても同じ状態になるため）
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.After(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
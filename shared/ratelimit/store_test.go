@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock テスト用に進められる時計
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	return s, clock
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	rule := Rule{Rate: 1, Burst: 3} // 1秒に1トークン、最大3
+
+	type step struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then limited",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRetry: time.Second},
+			},
+		},
+		{
+			name: "tokens are refilled over time",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{advance: 500 * time.Millisecond, wantAllowed: false, wantRetry: 500 * time.Millisecond},
+				{advance: 500 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name: "refill does not exceed burst",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{advance: time.Hour, wantAllowed: true, wantRemaining: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clock := newTestStore()
+			for i, st := range tt.steps {
+				clock.advance(st.advance)
+				d, err := s.Take(context.Background(), "k", rule)
+				if err != nil {
+					t.Fatalf("step %d: Take: %v", i, err)
+				}
+				if d.Allowed != st.wantAllowed || d.Remaining != st.wantRemaining || d.RetryAfter != st.wantRetry {
+					t.Errorf("step %d: Take() = %+v, want allowed %v, remaining %d, retry after %v",
+						i, d, st.wantAllowed, st.wantRemaining, st.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	rule := Rule{Rate: 1, Burst: 1}
+
+	for _, key := range []string{"a", "b"} {
+		if d, _ := s.Take(context.Background(), key, rule); !d.Allowed {
+			t.Errorf("first Take(%q) was limited", key)
+		}
+	}
+	if d, _ := s.Take(context.Background(), "a", rule); d.Allowed {
+		t.Error("second Take(\"a\") was allowed")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s, clock := newTestStore()
+	s.sweepEvery = 2
+	rule := Rule{Rate: 1, Burst: 2}
+
+	s.Take(context.Background(), "idle", rule)
+	clock.advance(10 * time.Second)
+	s.Take(context.Background(), "active", rule) // 2回目の呼び出しで掃除する
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+}
@@ -0,0 +1,33 @@
+package graph
+
+import "narratives-test/graph/generated"
+
+const (
+	// relationCost Firestore の読み取りが発生するリレーションフィールドのコスト
+	relationCost = 5
+	// listSize ページングのない一覧（users / wallets）で想定する件数
+	listSize = 50
+)
+
+// Complexity フィールドごとのクエリ複雑度
+//
+// User.wallet ⇄ Wallet.user の循環を深く辿るクエリは
+// extension.FixedComplexityLimit で拒否される。
+func Complexity() generated.ComplexityRoot {
+	var c generated.ComplexityRoot
+
+	c.Query.Users = list
+	c.Query.Wallets = list
+	c.User.Wallet = relation
+	c.Wallet.User = relation
+
+	return c
+}
+
+func relation(childComplexity int) int {
+	return relationCost + childComplexity
+}
+
+func list(childComplexity int) int {
+	return 1 + childComplexity*listSize
+}
@@ -7,7 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"time"
 
 	"cloud.google.com/go/storage"
@@ -23,9 +23,10 @@ type SignedUrlPayload struct {
 
 // アバター画像のアップロード用署名付きURLを発行
 func (r *mutationResolver) GetAvatarUploadUrl(ctx context.Context, filename string) (*SignedUrlPayload, error) {
-	log.Printf("Using bucket: %s", r.Config.GCPCredentials.BucketName)
-	log.Printf("Requested filename: %s", filename)
-	log.Printf("GoogleAccessID: %s", r.Config.GCPCredentials.GoogleAccessID) // ★ 追加ログ
+	slog.DebugContext(ctx, "generating signed upload URL",
+		slog.String("bucket", r.Config.GCPCredentials.BucketName),
+		slog.String("filename", filename),
+		slog.String("google_access_id", r.Config.GCPCredentials.GoogleAccessID))
 
 	// ユニークなファイル名生成
 	uniqueFilename := generateUniqueFilenameWithBase(filename)
@@ -68,7 +69,7 @@ func (r *mutationResolver) GetAvatarUploadUrl(ctx context.Context, filename stri
 
 	publicURL := fmt.Sprintf("https://storage.googleapis.com/%s/%s", r.Config.GCPCredentials.BucketName, uniqueFilename)
 
-	log.Printf("Generated signed URL for file: %s", uniqueFilename)
+	slog.InfoContext(ctx, "signed upload URL generated", slog.String("object", uniqueFilename))
 
 	return &SignedUrlPayload{
 		SignedUrl: signedURL,
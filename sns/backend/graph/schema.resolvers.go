@@ -7,7 +7,7 @@ package graph
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"narratives-test/graph/generated"
 	"narratives-test/graph/model"
 	"strings"
@@ -15,6 +15,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	firebase "firebase.google.com/go/v4"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -23,11 +24,11 @@ func (r *mutationResolver) GetAvatarUploadURL(ctx context.Context, filename stri
 	bucket := r.Config.GCPCredentials.BucketName
 	method := "PUT"
 
-	// 🔍 デバッグログ: AccessID & PrivateKey 先頭
-	log.Printf("🔑 AccessID: %s", r.Config.GCPCredentials.GoogleAccessID)
-	if len(r.Config.GCPCredentials.PrivateKey) > 30 {
-		log.Printf("🧾 PrivateKey HEAD: %s...", r.Config.GCPCredentials.PrivateKey[:30])
-	}
+	slog.DebugContext(ctx, "generating avatar upload URL",
+		slog.String("bucket", bucket),
+		slog.String("folder", folder),
+		slog.String("filename", filename),
+		slog.String("google_access_id", r.Config.GCPCredentials.GoogleAccessID))
 
 	// 改行復元（.envから読んでいる場合は必要 / JSONからは不要だが安全に残す）
 	cleanedPrivateKey := strings.ReplaceAll(r.Config.GCPCredentials.PrivateKey, `\n`, "\n")
@@ -79,15 +80,12 @@ func (r *queryResolver) Empty(ctx context.Context) (*string, error) {
 
 // GetAvatarPublicURL is the resolver for the getAvatarPublicUrl field.
 func (r *queryResolver) GetAvatarPublicURL(ctx context.Context, filename string) (string, error) {
-	log.Println("GetAvatarPublicURL called")
-	log.Println("filename:", filename)
-
 	bucket := r.Config.GCPCredentials.BucketName
-	log.Println("raw bucket value:", bucket)
+	slog.DebugContext(ctx, "resolving avatar public URL", slog.String("filename", filename), slog.String("bucket", bucket))
 
 	// バケット名をクリーンアップ（スペースや余分な文字を除去）
 	if bucket == "" {
-		log.Println("❌ bucket name is empty")
+		slog.ErrorContext(ctx, "bucket name is not configured")
 		return "", fmt.Errorf("bucket name not configured")
 	}
 
@@ -96,15 +94,14 @@ func (r *queryResolver) GetAvatarPublicURL(ctx context.Context, filename string)
 
 	// スペースが含まれている場合は最初の部分のみ使用
 	if spaceIndex := strings.Index(bucket, " "); spaceIndex != -1 {
-		log.Printf("⚠️ bucket name contains space, truncating: '%s' -> '%s'", bucket, bucket[:spaceIndex])
+		slog.WarnContext(ctx, "bucket name contains space, truncating",
+			slog.String("original", bucket), slog.String("bucket", bucket[:spaceIndex]))
 		bucket = bucket[:spaceIndex]
 	}
 
-	log.Println("cleaned bucket:", bucket)
-
 	// Firebase Storage format for public URLs
 	publicURL := fmt.Sprintf("https://firebasestorage.googleapis.com/v0/b/%s/o/%s?alt=media", bucket, strings.ReplaceAll(filename, "/", "%2F"))
-	log.Println("🌐 generated public URL:", publicURL)
+	slog.DebugContext(ctx, "avatar public URL generated", slog.String("public_url", publicURL))
 
 	return publicURL, nil
 }
@@ -124,7 +121,6 @@ type FirestoreUser struct {
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
-	log.Println("Users resolver called - fetching from Firestore")
 
 	// Firebase Admin SDK初期化
 	credentialsFile := r.Config.GCPCredentials.CredentialFile
@@ -137,7 +133,7 @@ func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
 
 	app, err := firebase.NewApp(ctx, config, opt)
 	if err != nil {
-		log.Printf("Error initializing Firebase app: %v", err)
+		slog.ErrorContext(ctx, "failed to initialize Firebase app", slog.Any("error", err))
 		// フォールバック: サンプルデータを返す
 		return getSampleUsers(), nil
 	}
@@ -145,33 +141,31 @@ func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
 	// Firestoreクライアント取得
 	client, err := app.Firestore(ctx)
 	if err != nil {
-		log.Printf("Error getting Firestore client: %v", err)
+		slog.ErrorContext(ctx, "failed to get Firestore client", slog.Any("error", err))
 		// フォールバック: サンプルデータを返す
 		return getSampleUsers(), nil
 	}
 	defer client.Close()
 
 	// Firestoreからusersコレクションを取得
-	log.Printf("Attempting to fetch users from Firestore collection...")
 	iter := client.Collection("users").Documents(ctx)
 	var users []*model.User
 
 	for {
 		doc, err := iter.Next()
 		if err != nil {
-			log.Printf("Iterator finished or error: %v", err)
+			if err != iterator.Done {
+				slog.ErrorContext(ctx, "failed to iterate users", slog.Any("error", err))
+			}
 			break
 		}
 
-		log.Printf("Found document: %s", doc.Ref.ID)
 		var firestoreUser FirestoreUser
 		if err := doc.DataTo(&firestoreUser); err != nil {
-			log.Printf("Error converting document to FirestoreUser: %v", err)
+			slog.WarnContext(ctx, "failed to decode user document", slog.String("user_id", doc.Ref.ID), slog.Any("error", err))
 			continue
 		}
 
-		log.Printf("Document data: %+v", firestoreUser)
-
 		// Firestoreデータをmodel.Userに変換
 		user := &model.User{
 			UserID:            doc.Ref.ID, // ドキュメントIDをuser_idとして使用
@@ -189,11 +183,11 @@ func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
 	}
 
 	if len(users) == 0 {
-		log.Println("No users found in Firestore, returning sample data")
+		slog.InfoContext(ctx, "no users found in Firestore, returning sample data")
 		return getSampleUsers(), nil
 	}
 
-	log.Printf("Returning %d users from Firestore", len(users))
+	slog.DebugContext(ctx, "users fetched from Firestore", slog.Int("count", len(users)))
 	return users, nil
 }
 
@@ -238,8 +232,6 @@ func getSampleUsers() []*model.User {
 
 // User is the resolver for the user field.
 func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
-	log.Printf("User resolver called with id: %s - fetching from Firestore", id)
-
 	// Firebase Admin SDK初期化
 	credentialsFile := r.Config.GCPCredentials.CredentialFile
 	opt := option.WithCredentialsFile(credentialsFile)
@@ -251,7 +243,7 @@ func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error
 
 	app, err := firebase.NewApp(ctx, config, opt)
 	if err != nil {
-		log.Printf("Error initializing Firebase app: %v", err)
+		slog.ErrorContext(ctx, "failed to initialize Firebase app", slog.Any("error", err))
 		// フォールバック: サンプルデータを返す
 		return getSampleUserByID(id), nil
 	}
@@ -259,7 +251,7 @@ func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error
 	// Firestoreクライアント取得
 	client, err := app.Firestore(ctx)
 	if err != nil {
-		log.Printf("Error getting Firestore client: %v", err)
+		slog.ErrorContext(ctx, "failed to get Firestore client", slog.Any("error", err))
 		// フォールバック: サンプルデータを返す
 		return getSampleUserByID(id), nil
 	}
@@ -268,14 +260,14 @@ func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error
 	// FirestoreからユーザードキュメントIDを取得
 	doc, err := client.Collection("users").Doc(id).Get(ctx)
 	if err != nil {
-		log.Printf("Error getting user document: %v", err)
+		slog.WarnContext(ctx, "failed to get user document", slog.String("user_id", id), slog.Any("error", err))
 		// フォールバック: サンプルデータを返す
 		return getSampleUserByID(id), nil
 	}
 
 	var firestoreUser FirestoreUser
 	if err := doc.DataTo(&firestoreUser); err != nil {
-		log.Printf("Error converting document to FirestoreUser: %v", err)
+		slog.WarnContext(ctx, "failed to decode user document", slog.String("user_id", id), slog.Any("error", err))
 		return getSampleUserByID(id), nil
 	}
 
@@ -341,8 +333,6 @@ func getSampleUserByID(id string) *model.User {
 
 // Wallets is the resolver for the wallets field.
 func (r *queryResolver) Wallets(ctx context.Context) ([]*model.Wallet, error) {
-	log.Println("Wallets resolver called")
-
 	// サンプルウォレットデータを返す
 	wallets := []*model.Wallet{
 		{
@@ -371,14 +361,11 @@ func (r *queryResolver) Wallets(ctx context.Context) ([]*model.Wallet, error) {
 		},
 	}
 
-	log.Printf("Returning %d wallets", len(wallets))
 	return wallets, nil
 }
 
 // Wallet is the resolver for the wallet field.
 func (r *queryResolver) Wallet(ctx context.Context, id string) (*model.Wallet, error) {
-	log.Printf("Wallet resolver called with id: %s", id)
-
 	// サンプルウォレット取得
 	switch id {
 	case "wallet_001":
@@ -4,11 +4,17 @@ package main
 import (
 	"encoding/json"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
 
+	"narratives-shared/cors"
+	"narratives-shared/health"
+	"narratives-shared/logging"
+	"narratives-shared/persisted"
+	"narratives-shared/querylimit"
+	"narratives-shared/ratelimit"
 	"narratives-test/graph"
 	"narratives-test/graph/generated"
 
@@ -17,7 +23,6 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/joho/godotenv"
-	"github.com/rs/cors"
 )
 
 func getServiceAccountPath() string {
@@ -61,21 +66,23 @@ func loadCleanedPrivateKeyFromJSON(path string) (string, error) {
 func main() {
 	_ = godotenv.Load() // .env 読み込み（任意）
 
+	// 構造化ロガーを初期化
+	logging.Setup(logging.ConfigFromEnv("narratives-sns"))
+
 	serviceAccountPath := getServiceAccountPath()
-	log.Printf("🔍 Using service account path: %s", serviceAccountPath)
+	slog.Info("using service account", slog.String("path", serviceAccountPath))
 
 	// サービスアカウントからキー情報を取得
 	privateKeyID, err := loadPrivateKeyIDFromJSON(serviceAccountPath)
 	if err != nil {
-		log.Fatalf("❌ Failed to read private_key_id from JSON: %v", err)
+		fatal("failed to read private_key_id from service account", err)
 	}
 	privateKey, err := loadCleanedPrivateKeyFromJSON(serviceAccountPath)
 	if err != nil {
-		log.Fatalf("❌ Failed to read private_key from JSON: %v", err)
+		fatal("failed to read private_key from service account", err)
 	}
 
-	log.Printf("✅ PrivateKey loaded. Length: %d", len(privateKey))
-	log.Printf("🧾 PrivateKey starts with: %.30s...", privateKey)
+	slog.Info("service account private key loaded")
 
 	// Resolver 構成
 	resolver := &graph.Resolver{}
@@ -88,58 +95,97 @@ func main() {
 
 	// 環境変数の検証とクリーンアップ
 	if bucketName == "" {
-		log.Fatalf("❌ GCS_BUCKET_NAME environment variable is not set")
+		fatal("GCS_BUCKET_NAME environment variable is not set", nil)
 	}
 	if googleAccessID == "" {
-		log.Fatalf("❌ GCS_GOOGLE_ACCESS_ID environment variable is not set")
+		fatal("GCS_GOOGLE_ACCESS_ID environment variable is not set", nil)
 	}
 
 	// バケット名をクリーンアップ
 	bucketName = strings.TrimSpace(bucketName)
 	if strings.Contains(bucketName, " ") {
-		log.Printf("⚠️ バケット名にスペースが含まれています: '%s'", bucketName)
+		original := bucketName
 		bucketName = strings.Split(bucketName, " ")[0]
-		log.Printf("✅ クリーンアップ後のバケット名: '%s'", bucketName)
+		slog.Warn("bucket name contains spaces, truncated",
+			slog.String("original", original), slog.String("bucket", bucketName))
 	}
 
 	resolver.Config.GCPCredentials.BucketName = bucketName
 	resolver.Config.GCPCredentials.GoogleAccessID = googleAccessID
 
-	log.Printf("✅ バケット名: %s", bucketName)
-	log.Printf("✅ Google Access ID: %s", googleAccessID)
+	slog.Info("storage configured",
+		slog.String("bucket", bucketName), slog.String("google_access_id", googleAccessID))
 
 	// GraphQL ハンドラー構成
-	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: resolver, Complexity: graph.Complexity()}))
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
 	srv.Use(extension.Introspection{})
 
-	// CORS設定
-	c := cors.New(cors.Options{
-		AllowedOrigins: []string{
-			"http://localhost:3000",
-			"http://localhost:*",
-			"https://localhost:*",
-			"https://narratives-test-64976.web.app",
-			"https://narratives-test-64976.firebaseapp.com",
-			"https://narratives-crm.web.app",
-			"https://narratives-crm.firebaseapp.com",
+	// 永続化クエリ（APQ）。PERSISTED_QUERIES_ALLOWLIST=true の場合はマニフェストのクエリのみ実行
+	persistedConfig, err := persisted.ConfigFromEnv()
+	if err != nil {
+		fatal("invalid persisted query configuration", err)
+	}
+	persistedExt, manifest, err := persisted.Extension(persistedConfig)
+	if err != nil {
+		fatal("failed to load persisted query manifest", err)
+	}
+	srv.Use(persistedExt)
+	slog.Info("persisted queries configured",
+		slog.Int("manifest_queries", manifest.Len()), slog.Bool("allow_list", persistedConfig.AllowList))
+
+	// クエリの複雑度・深さの上限（User.wallet ⇄ Wallet.user の循環対策）
+	queryLimits, err := querylimit.ConfigFromEnv()
+	if err != nil {
+		slog.Error("invalid GraphQL query limit configuration, using defaults", slog.Any("error", err))
+	}
+	for _, ext := range querylimit.Extensions(queryLimits) {
+		srv.Use(ext)
+	}
+
+	// レート制限（IPアドレス単位）
+	rateLimitConfig, err := ratelimit.ConfigFromEnv(ratelimit.Config{
+		Routes: map[string]ratelimit.Rule{
+			"/query": {Rate: 300.0 / 60, Burst: 60},
+		},
+		Operations: map[string]ratelimit.Rule{
+			"getAvatarUploadUrl": {Rate: 10.0 / 60, Burst: 5},
 		},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		ExposedHeaders:   []string{"*"},
-		AllowCredentials: true,
-		Debug:            true,
 	})
+	if err != nil {
+		slog.Error("invalid rate limit configuration, using defaults", slog.Any("error", err))
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rateLimitConfig, nil)
+	srv.Use(ratelimit.GraphQLExtension{Limiter: limiter})
+
+	// CORS設定（CORS_ORIGIN 等の環境変数で上書き可能）
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowedOrigins = []string{
+		"http://localhost:*",
+		"https://localhost:*",
+		"https://narratives-test-64976.web.app",
+		"https://narratives-test-64976.firebaseapp.com",
+		"https://narratives-crm.web.app",
+		"https://narratives-crm.firebaseapp.com",
+	}
+	corsConfig.AllowCredentials = true
+	corsHandler := cors.Middleware(cors.ConfigFromEnv(corsConfig))
 
 	// ルーティング設定
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
+
+	// Liveness / Readiness プローブ
+	readiness := health.NewChecker(health.TimeoutFromEnv())
+	readiness.Register("storage", health.StorageCheck(serviceAccountPath, bucketName, privateKey))
+	mux.Handle("/livez", health.LiveHandler())
+	mux.Handle("/readyz", readiness.ReadyHandler())
 	mux.Handle("/", playground.Handler("GraphQL Playground", "/query"))
-	mux.Handle("/query", c.Handler(srv))
+	mux.Handle("/query", srv)
 
 	// ポート取得（Cloud Run は PORT 環境変数を自動で渡す）
 	port := os.Getenv("PORT")
@@ -147,6 +193,18 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("🌐 Starting server on 0.0.0.0:%s", port)
-	log.Fatal(http.ListenAndServe("0.0.0.0:"+port, mux))
+	slog.Info("server starting", slog.String("addr", "0.0.0.0:"+port))
+	if err := http.ListenAndServe("0.0.0.0:"+port, logging.Middleware(corsHandler(limiter.Middleware(mux)))); err != nil {
+		fatal("server stopped", err)
+	}
+}
+
+// fatal エラーログを出力して終了
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, slog.Any("error", err))
+	} else {
+		slog.Error(msg)
+	}
+	os.Exit(1)
 }